@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"log"
 	"net/http"
 	"os"
 	"time"
+	_ "time/tzdata"
 
 	_ "github.com/NeGat1FF/todolist-api/cmd/todolist-api/docs"
 	"github.com/NeGat1FF/todolist-api/internal/database"
 	"github.com/NeGat1FF/todolist-api/internal/handlers"
 	"github.com/NeGat1FF/todolist-api/internal/middleware"
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/NeGat1FF/todolist-api/internal/notifier"
 	"github.com/NeGat1FF/todolist-api/internal/repository"
 	"github.com/NeGat1FF/todolist-api/internal/service"
+	"github.com/NeGat1FF/todolist-api/internal/utils"
+	"github.com/NeGat1FF/todolist-api/internal/worker"
 	"github.com/joho/godotenv"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 )
@@ -34,28 +41,123 @@ func main() {
 
 	mux := http.NewServeMux()
 
+	keyRing := utils.NewKeyRing([]byte(os.Getenv("SECRET_KEY")))
+	if path := os.Getenv("JWT_KEYS_FILE"); path != "" {
+		// HS256 tokens issued before the switch are only accepted on request
+		var legacySecret []byte
+		if os.Getenv("JWT_ACCEPT_HS256") == "true" {
+			legacySecret = []byte(os.Getenv("SECRET_KEY"))
+		}
+		keyRing, err = utils.LoadKeyRing(path, legacySecret)
+		if err != nil {
+			log.Fatal("Error loading JWT keys: ", err)
+		}
+	}
+	utils.SetDefaultKeyRing(keyRing)
+
+	cursorSecret := []byte(os.Getenv("CURSOR_SECRET"))
+	if len(cursorSecret) == 0 {
+		// Cursors and sync tokens then only work until the next restart, and
+		// only against this instance
+		cursorSecret = make([]byte, 32)
+		if _, err := rand.Read(cursorSecret); err != nil {
+			log.Fatal(err)
+		}
+		log.Print("CURSOR_SECRET is not set, using a random one")
+	}
+	utils.SetCursorSecret(cursorSecret)
+
 	db := database.InitDB()
 
 	userRepo := repository.NewUserRepository(db)
 	taskRepo := repository.NewTaskRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	listRepo := repository.NewListRepository(db)
+	reminderRepo := repository.NewReminderRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
 
 	servLogger := log.New(os.Stderr, "[SYSTEM] ", log.Ldate|log.Ltime|log.Lshortfile)
 
-	serv := service.NewService(userRepo, taskRepo, servLogger)
+	serv := service.NewService(userRepo, taskRepo, tagRepo, listRepo, reminderRepo, tokenRepo, apiKeyRepo, servLogger)
+
+	workerLogger := log.New(os.Stderr, "[WORKER] ", log.Ldate|log.Ltime|log.Lshortfile)
+
+	if n := newNotifiers(); len(n) > 0 {
+		go worker.NewReminderWorker(reminderRepo, n, utils.EnvDuration("REMINDER_POLL_INTERVAL", time.Minute), workerLogger).Run(context.Background())
+	} else {
+		servLogger.Print("No notifier configured, reminders will not be sent")
+	}
+
+	go worker.NewTrashPurger(taskRepo, utils.EnvDuration("TRASH_RETENTION", 30*24*time.Hour), utils.EnvDuration("CHANGE_LOG_RETENTION", 90*24*time.Hour), time.Hour, workerLogger).Run(context.Background())
+	go worker.NewIdempotencyKeyPurger(idempotencyRepo, time.Hour, workerLogger).Run(context.Background())
+	go worker.NewTokenPurger(tokenRepo, time.Hour, workerLogger).Run(context.Background())
+	go worker.NewKeyReloader(keyRing, utils.EnvDuration("JWT_KEYS_RELOAD_INTERVAL", time.Minute), workerLogger).Run(context.Background())
 
 	taskHandler := handlers.NewTaskHandler(serv)
 	userHandler := handlers.NewUserHandler(serv)
+	tagHandler := handlers.NewTagHandler(serv)
+	listHandler := handlers.NewListHandler(serv)
+	reminderHandler := handlers.NewReminderHandler(serv)
+	syncHandler := handlers.NewSyncHandler(serv)
+	keyHandler := handlers.NewKeyHandler(keyRing)
+	apiKeyHandler := handlers.NewAPIKeyHandler(serv)
 
 	rateLimiter := middleware.NewRateLimiter(50, time.Minute)
+	auth := middleware.NewAuth(serv)
+	idempotency := middleware.NewIdempotency(idempotencyRepo, utils.EnvDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour), utils.EnvDuration("IDEMPOTENCY_LOCK_TIMEOUT", time.Minute), servLogger)
+
+	mux.HandleFunc("GET /.well-known/jwks.json", rateLimiter.Middleware(keyHandler.JWKS))
 
 	mux.HandleFunc("POST /register", rateLimiter.Middleware(middleware.ValidateRegistration(userHandler.RegisterUser)))
 	mux.HandleFunc("POST /login", rateLimiter.Middleware(middleware.ValidateLogin(userHandler.LoginUser)))
 	mux.HandleFunc("POST /refresh", rateLimiter.Middleware(userHandler.RefreshToken))
+	mux.HandleFunc("POST /logout", rateLimiter.Middleware(auth.Middleware(idempotency.Middleware(userHandler.Logout))))
+	mux.HandleFunc("POST /logout/all", rateLimiter.Middleware(auth.Middleware(idempotency.Middleware(userHandler.LogoutAll))))
+	mux.HandleFunc("GET /me/sessions", rateLimiter.Middleware(auth.Middleware(userHandler.GetSessions)))
+	mux.HandleFunc("DELETE /me/sessions/{id}", rateLimiter.Middleware(auth.Middleware(userHandler.EndSession)))
+	mux.HandleFunc("GET /me/api-keys", rateLimiter.Middleware(auth.Middleware(apiKeyHandler.GetAPIKeys)))
+	mux.HandleFunc("POST /me/api-keys", rateLimiter.Middleware(auth.Middleware(idempotency.Middleware(middleware.ValidateAPIKey(apiKeyHandler.AddAPIKey)))))
+	mux.HandleFunc("DELETE /me/api-keys/{id}", rateLimiter.Middleware(auth.Middleware(apiKeyHandler.DeleteAPIKey)))
+	mux.HandleFunc("PUT /me/timezone", rateLimiter.Middleware(auth.Middleware(middleware.ValidateTimeZone(userHandler.UpdateTimeZone))))
+
+	mux.HandleFunc("POST /todos", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, idempotency.Middleware(middleware.ValidateAddTask(taskHandler.AddTask)))))
+	mux.HandleFunc("GET /todos", rateLimiter.Middleware(auth.Require(models.ScopeTasksRead, taskHandler.GetTasks)))
+	mux.HandleFunc("GET /todos/search", rateLimiter.Middleware(auth.Require(models.ScopeTasksRead, taskHandler.SearchTasks)))
+	mux.HandleFunc("GET /todos/{id}", rateLimiter.Middleware(auth.Require(models.ScopeTasksRead, taskHandler.GetTask)))
+	mux.HandleFunc("PUT /todos/{id}", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, middleware.ValidateUpdateTask(taskHandler.UpdateTask))))
+	mux.HandleFunc("PATCH /todos/{id}", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, middleware.ValidatePatchTask(taskHandler.PatchTask))))
+	mux.HandleFunc("DELETE /todos/{id}", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, taskHandler.DeleteTask)))
+	mux.HandleFunc("POST /todos/{id}/complete", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, idempotency.Middleware(taskHandler.CompleteTask))))
+	mux.HandleFunc("POST /todos/{id}/reopen", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, idempotency.Middleware(taskHandler.ReopenTask))))
+	mux.HandleFunc("GET /todos/{id}/subtasks", rateLimiter.Middleware(auth.Require(models.ScopeTasksRead, taskHandler.GetSubtasks)))
+	mux.HandleFunc("POST /todos/archive-completed", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, idempotency.Middleware(taskHandler.ArchiveCompleted))))
+	mux.HandleFunc("POST /todos/bulk", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, idempotency.Middleware(middleware.ValidateBulk(taskHandler.BulkTasks)))))
+	mux.HandleFunc("POST /todos/{id}/archive", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, idempotency.Middleware(taskHandler.ArchiveTask))))
+	mux.HandleFunc("POST /todos/{id}/unarchive", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, idempotency.Middleware(taskHandler.UnarchiveTask))))
+	mux.HandleFunc("POST /todos/{id}/restore", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, idempotency.Middleware(taskHandler.RestoreTask))))
+	mux.HandleFunc("POST /todos/{id}/move", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, idempotency.Middleware(middleware.ValidateMoveTask(taskHandler.MoveTask)))))
+	mux.HandleFunc("GET /todos/{id}/reminders", rateLimiter.Middleware(auth.Require(models.ScopeTasksRead, reminderHandler.GetReminders)))
+	mux.HandleFunc("POST /todos/{id}/reminders", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, idempotency.Middleware(middleware.ValidateReminder(reminderHandler.AddReminder)))))
+	mux.HandleFunc("DELETE /todos/{id}/reminders/{reminder_id}", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, reminderHandler.DeleteReminder)))
 
-	mux.HandleFunc("POST /todos", rateLimiter.Middleware(middleware.AuthUserMiddleware(middleware.ValidateAddTask(taskHandler.AddTask))))
-	mux.HandleFunc("GET /todos", rateLimiter.Middleware(middleware.AuthUserMiddleware(taskHandler.GetTasks)))
-	mux.HandleFunc("PUT /todos/{id}", rateLimiter.Middleware(middleware.AuthUserMiddleware(middleware.ValidateUpdateTask(taskHandler.UpdateTask))))
-	mux.HandleFunc("DELETE /todos/{id}", rateLimiter.Middleware(middleware.AuthUserMiddleware(taskHandler.DeleteTask)))
+	mux.HandleFunc("POST /sync", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, idempotency.Middleware(middleware.ValidateSync(syncHandler.Sync)))))
+
+	mux.HandleFunc("GET /trash", rateLimiter.Middleware(auth.Require(models.ScopeTasksRead, taskHandler.GetTrash)))
+	mux.HandleFunc("DELETE /trash", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, taskHandler.EmptyTrash)))
+	mux.HandleFunc("DELETE /trash/{id}", rateLimiter.Middleware(auth.Require(models.ScopeTasksWrite, taskHandler.PurgeTask)))
+
+	mux.HandleFunc("GET /tags", rateLimiter.Middleware(auth.Require(models.ScopeTagsRead, tagHandler.GetTags)))
+	mux.HandleFunc("POST /tags", rateLimiter.Middleware(auth.Require(models.ScopeTagsWrite, idempotency.Middleware(middleware.ValidateTag(tagHandler.AddTag)))))
+	mux.HandleFunc("PUT /tags/{id}", rateLimiter.Middleware(auth.Require(models.ScopeTagsWrite, middleware.ValidateTag(tagHandler.UpdateTag))))
+	mux.HandleFunc("DELETE /tags/{id}", rateLimiter.Middleware(auth.Require(models.ScopeTagsWrite, tagHandler.DeleteTag)))
+
+	mux.HandleFunc("GET /lists", rateLimiter.Middleware(auth.Require(models.ScopeListsRead, listHandler.GetLists)))
+	mux.HandleFunc("POST /lists", rateLimiter.Middleware(auth.Require(models.ScopeListsWrite, idempotency.Middleware(middleware.ValidateList(listHandler.AddList)))))
+	mux.HandleFunc("PUT /lists/{id}", rateLimiter.Middleware(auth.Require(models.ScopeListsWrite, middleware.ValidateList(listHandler.UpdateList))))
+	mux.HandleFunc("DELETE /lists/{id}", rateLimiter.Middleware(auth.Require(models.ScopeListsWrite, listHandler.DeleteList)))
+	mux.HandleFunc("GET /lists/{id}/todos", rateLimiter.Middleware(auth.Require(models.ScopeTasksRead, listHandler.GetListTasks)))
 
 	mux.HandleFunc("/swagger/", httpSwagger.Handler(
 		httpSwagger.URL("http://localhost:8080/swagger/doc.json"),
@@ -63,3 +165,24 @@ func main() {
 
 	http.ListenAndServe("localhost:8080", mux)
 }
+
+// newNotifiers builds the notifiers for reminders from the environment, by
+// name. It returns none when neither a webhook nor an SMTP server is
+// configured.
+func newNotifiers() map[string]notifier.Notifier {
+	notifiers := map[string]notifier.Notifier{}
+
+	if url := os.Getenv("REMINDER_WEBHOOK_URL"); url != "" {
+		notifiers["webhook"] = notifier.NewWebhookNotifier(url, os.Getenv("REMINDER_WEBHOOK_SECRET"))
+	}
+
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		notifiers["email"] = notifier.NewSMTPNotifier(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("SMTP_FROM"))
+	}
+
+	return notifiers
+}
@@ -6,6 +6,7 @@ import (
 
 	"os"
 
+	"github.com/NeGat1FF/todolist-api/internal/models"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/driver/pgdriver"
@@ -26,7 +27,14 @@ func InitDB() *bun.DB {
 		pgdriver.WithWriteTimeout(5*time.Second),
 	)
 
-	// Create and return a Bun database instance
-	return bun.NewDB(sql.OpenDB(pgconn), pgdialect.New())
+	// Create a Bun database instance
+	// Columns maintained by PostgreSQL alone, such as tasks.search_vector,
+	// are not part of the models and are skipped when scanning
+	db := bun.NewDB(sql.OpenDB(pgconn), pgdialect.New(), bun.WithDiscardUnknownColumns())
+
+	// Join models have to be registered before m2m relations can be used
+	db.RegisterModel((*models.TaskTag)(nil))
+
+	return db
 
 }
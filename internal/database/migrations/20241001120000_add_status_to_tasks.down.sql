@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS tasks_user_id_status_idx;
+
+ALTER TABLE tasks
+  DROP COLUMN IF EXISTS completed_at,
+  DROP COLUMN IF EXISTS status;
\ No newline at end of file
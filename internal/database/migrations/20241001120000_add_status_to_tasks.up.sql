@@ -0,0 +1,5 @@
+ALTER TABLE tasks
+  ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'todo' CHECK (status IN ('todo', 'in_progress', 'done')),
+  ADD COLUMN completed_at TIMESTAMPTZ;
+
+CREATE INDEX tasks_user_id_status_idx ON tasks (user_id, status);
\ No newline at end of file
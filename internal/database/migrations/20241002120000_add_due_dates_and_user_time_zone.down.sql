@@ -0,0 +1,9 @@
+ALTER TABLE users
+  DROP COLUMN IF EXISTS time_zone;
+
+DROP INDEX IF EXISTS tasks_user_id_due_at_idx;
+
+ALTER TABLE tasks
+  DROP CONSTRAINT IF EXISTS tasks_start_before_due,
+  DROP COLUMN IF EXISTS due_at,
+  DROP COLUMN IF EXISTS start_at;
\ No newline at end of file
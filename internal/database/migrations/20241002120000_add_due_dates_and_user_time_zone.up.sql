@@ -0,0 +1,9 @@
+ALTER TABLE tasks
+  ADD COLUMN start_at TIMESTAMPTZ,
+  ADD COLUMN due_at TIMESTAMPTZ,
+  ADD CONSTRAINT tasks_start_before_due CHECK (start_at IS NULL OR due_at IS NULL OR start_at <= due_at);
+
+CREATE INDEX tasks_user_id_due_at_idx ON tasks (user_id, due_at);
+
+ALTER TABLE users
+  ADD COLUMN time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC';
\ No newline at end of file
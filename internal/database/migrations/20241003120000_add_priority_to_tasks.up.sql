@@ -0,0 +1,2 @@
+ALTER TABLE tasks
+  ADD COLUMN priority SMALLINT NOT NULL DEFAULT 0 CHECK (priority BETWEEN 0 AND 4);
\ No newline at end of file
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS tasks_user_id_list_id_idx;
+
+ALTER TABLE tasks
+  DROP COLUMN IF EXISTS list_id;
+
+DROP TABLE IF EXISTS lists;
\ No newline at end of file
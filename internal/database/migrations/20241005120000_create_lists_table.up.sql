@@ -0,0 +1,11 @@
+CREATE TABLE lists (
+  id SERIAL PRIMARY KEY,
+  user_id INT NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+ALTER TABLE tasks
+  ADD COLUMN list_id INT REFERENCES lists(id) ON DELETE SET NULL;
+
+CREATE INDEX tasks_user_id_list_id_idx ON tasks (user_id, list_id);
\ No newline at end of file
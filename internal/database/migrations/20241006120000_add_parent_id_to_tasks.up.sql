@@ -0,0 +1,5 @@
+ALTER TABLE tasks
+  ADD COLUMN parent_id INT REFERENCES tasks(id) ON DELETE CASCADE,
+  ADD CONSTRAINT tasks_parent_not_self CHECK (parent_id <> id);
+
+CREATE INDEX tasks_parent_id_idx ON tasks (parent_id);
\ No newline at end of file
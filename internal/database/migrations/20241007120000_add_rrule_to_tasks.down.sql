@@ -0,0 +1,2 @@
+ALTER TABLE tasks
+  DROP COLUMN IF EXISTS rrule;
\ No newline at end of file
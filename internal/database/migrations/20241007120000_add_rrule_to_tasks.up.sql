@@ -0,0 +1,2 @@
+ALTER TABLE tasks
+  ADD COLUMN rrule TEXT;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE reminders (
+  id SERIAL PRIMARY KEY,
+  task_id INT NOT NULL,
+  user_id INT NOT NULL,
+  remind_at TIMESTAMPTZ,
+  offset_minutes INT,
+  sent_at TIMESTAMPTZ,
+  attempts INT NOT NULL DEFAULT 0,
+  CHECK ((remind_at IS NULL) <> (offset_minutes IS NULL)),
+  FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX reminders_pending_idx ON reminders (task_id) WHERE sent_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE tasks
+  ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B')
+  ) STORED;
+
+CREATE INDEX tasks_search_vector_idx ON tasks USING GIN (search_vector);
\ No newline at end of file
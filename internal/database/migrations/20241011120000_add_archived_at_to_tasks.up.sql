@@ -0,0 +1,4 @@
+ALTER TABLE tasks
+  ADD COLUMN archived_at TIMESTAMPTZ;
+
+CREATE INDEX tasks_archived_at_idx ON tasks (user_id, archived_at) WHERE archived_at IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS tasks_user_id_updated_at_idx;
+
+DROP TRIGGER IF EXISTS tasks_set_updated_at ON tasks;
+DROP TRIGGER IF EXISTS users_set_updated_at ON users;
+
+ALTER TABLE tasks
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS created_at;
+
+ALTER TABLE users
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS created_at;
+
+DROP FUNCTION IF EXISTS set_updated_at();
\ No newline at end of file
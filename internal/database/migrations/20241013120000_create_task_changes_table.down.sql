@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS tasks_log_change ON tasks;
+DROP TRIGGER IF EXISTS tasks_bump_version ON tasks;
+
+DROP FUNCTION IF EXISTS log_task_change();
+DROP FUNCTION IF EXISTS bump_task_version();
+
+DROP TABLE IF EXISTS task_changes;
+
+ALTER TABLE tasks
+  DROP COLUMN IF EXISTS version;
\ No newline at end of file
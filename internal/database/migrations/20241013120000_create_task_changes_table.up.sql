@@ -0,0 +1,53 @@
+ALTER TABLE tasks
+  ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+CREATE TABLE task_changes (
+  id BIGSERIAL PRIMARY KEY,
+  user_id INT NOT NULL,
+  task_id INT NOT NULL,
+  version BIGINT NOT NULL,
+  fields TEXT[] NOT NULL DEFAULT '{}',
+  xid XID8 NOT NULL DEFAULT pg_current_xact_id(),
+  changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX task_changes_user_id_xid_idx ON task_changes (user_id, xid);
+CREATE INDEX task_changes_task_id_version_idx ON task_changes (task_id, version);
+
+CREATE FUNCTION bump_task_version() RETURNS TRIGGER AS $$
+BEGIN
+  NEW.version = OLD.version + 1;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION log_task_change() RETURNS TRIGGER AS $$
+DECLARE
+  changed TEXT[] := '{}';
+BEGIN
+  IF TG_OP = 'UPDATE' THEN
+    IF NEW.title IS DISTINCT FROM OLD.title THEN changed := array_append(changed, 'title'); END IF;
+    IF NEW.description IS DISTINCT FROM OLD.description THEN changed := array_append(changed, 'description'); END IF;
+    IF NEW.status IS DISTINCT FROM OLD.status THEN changed := array_append(changed, 'status'); END IF;
+    IF NEW.completed_at IS DISTINCT FROM OLD.completed_at THEN changed := array_append(changed, 'completed_at'); END IF;
+    IF NEW.start_at IS DISTINCT FROM OLD.start_at THEN changed := array_append(changed, 'start_at'); END IF;
+    IF NEW.due_at IS DISTINCT FROM OLD.due_at THEN changed := array_append(changed, 'due_at'); END IF;
+    IF NEW.priority IS DISTINCT FROM OLD.priority THEN changed := array_append(changed, 'priority'); END IF;
+    IF NEW.list_id IS DISTINCT FROM OLD.list_id THEN changed := array_append(changed, 'list_id'); END IF;
+    IF NEW.parent_id IS DISTINCT FROM OLD.parent_id THEN changed := array_append(changed, 'parent_id'); END IF;
+    IF NEW.rrule IS DISTINCT FROM OLD.rrule THEN changed := array_append(changed, 'rrule'); END IF;
+    IF NEW.archived_at IS DISTINCT FROM OLD.archived_at THEN changed := array_append(changed, 'archived_at'); END IF;
+    IF NEW.deleted_at IS DISTINCT FROM OLD.deleted_at THEN changed := array_append(changed, 'deleted_at'); END IF;
+  END IF;
+
+  INSERT INTO task_changes (user_id, task_id, version, fields)
+    VALUES (NEW.user_id, NEW.id, NEW.version, changed);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_bump_version BEFORE UPDATE ON tasks
+  FOR EACH ROW EXECUTE FUNCTION bump_task_version();
+
+CREATE TRIGGER tasks_log_change AFTER INSERT OR UPDATE ON tasks
+  FOR EACH ROW EXECUTE FUNCTION log_task_change();
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE idempotency_keys (
+  user_id INT NOT NULL,
+  key VARCHAR(255) NOT NULL,
+  request_hash TEXT NOT NULL,
+  status_code INT,
+  response_header JSONB,
+  response_body BYTEA,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (user_id, key),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
\ No newline at end of file
@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN token_version INT NOT NULL DEFAULT 0;
+
+CREATE TABLE revoked_tokens (
+  jti TEXT PRIMARY KEY,
+  user_id INT NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
\ No newline at end of file
@@ -0,0 +1,18 @@
+CREATE TABLE sessions (
+  id TEXT PRIMARY KEY,
+  user_id INT NOT NULL,
+  device_name TEXT,
+  user_agent TEXT,
+  ip TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_used_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+
+INSERT INTO sessions (id, user_id, created_at, last_used_at)
+SELECT family_id, user_id, min(created_at), max(created_at)
+FROM refresh_tokens
+WHERE revoked_at IS NULL
+GROUP BY family_id, user_id;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS task_changes_changed_at_idx;
+
+DROP TABLE IF EXISTS task_changes_horizon;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE task_changes_horizon (
+  id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+  xid BIGINT NOT NULL DEFAULT 0,
+  changed_at TIMESTAMPTZ NOT NULL DEFAULT 'epoch'
+);
+
+INSERT INTO task_changes_horizon DEFAULT VALUES;
+
+CREATE INDEX task_changes_changed_at_idx ON task_changes (changed_at);
\ No newline at end of file
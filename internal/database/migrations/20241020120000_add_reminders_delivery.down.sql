@@ -0,0 +1,3 @@
+ALTER TABLE reminders
+  DROP COLUMN IF EXISTS delivered,
+  DROP COLUMN IF EXISTS next_attempt_at;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE reminders
+  ADD COLUMN next_attempt_at TIMESTAMPTZ,
+  ADD COLUMN delivered TEXT[] NOT NULL DEFAULT '{}';
\ No newline at end of file
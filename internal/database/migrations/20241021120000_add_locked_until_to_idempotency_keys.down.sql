@@ -0,0 +1,2 @@
+ALTER TABLE idempotency_keys
+  DROP COLUMN IF EXISTS locked_until;
\ No newline at end of file
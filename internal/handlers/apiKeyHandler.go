@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/NeGat1FF/todolist-api/internal/service"
+)
+
+type APIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes" example:"tasks:read,tasks:write"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APIKeyHandler struct {
+	ser *service.Service
+}
+
+func NewAPIKeyHandler(ser *service.Service) *APIKeyHandler {
+	return &APIKeyHandler{ser}
+}
+
+// GetAPIKeys godoc
+//
+//	@Summary		List API keys
+//	@Description	List the API keys of the user, without the keys themselves
+//	@Tags			users
+//	@Produce		json
+//	@Security		Bearer
+//	@Success		200	{array}	models.APIKey
+//	@Router			/me/api-keys [get]
+func (ah *APIKeyHandler) GetAPIKeys(rw http.ResponseWriter, r *http.Request) {
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	keys, err := ah.ser.GetAPIKeys(r.Context(), user_id)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(keys)
+}
+
+// AddAPIKey godoc
+//
+//	@Summary		Create an API key
+//	@Description	Create an API key for scripts and integrations. The key is only returned in this response
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			key	body		APIKeyRequest	true	"Name, scopes and optional expiry of the key"
+//	@Success		201	{object}	models.APIKey
+//	@Router			/me/api-keys [post]
+func (ah *APIKeyHandler) AddAPIKey(rw http.ResponseWriter, r *http.Request) {
+	key := r.Context().Value(models.APIKeyKey{}).(models.APIKey)
+	key.UserID = r.Context().Value(models.UserIDKey{}).(int)
+
+	key, err := ah.ser.CreateAPIKey(r.Context(), key)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(key)
+}
+
+// DeleteAPIKey godoc
+//
+//	@Summary		Revoke an API key
+//	@Description	Delete an API key, so that it cannot be used anymore
+//	@Tags			users
+//	@Security		Bearer
+//	@Param			id	path	int	true	"API key ID"
+//	@Success		204
+//	@Router			/me/api-keys/{id} [delete]
+func (ah *APIKeyHandler) DeleteAPIKey(rw http.ResponseWriter, r *http.Request) {
+	key_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	err = ah.ser.DeleteAPIKey(r.Context(), user_id, key_id)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/NeGat1FF/todolist-api/internal/utils"
+)
+
+type KeyHandler struct {
+	ring *utils.KeyRing
+}
+
+func NewKeyHandler(ring *utils.KeyRing) *KeyHandler {
+	return &KeyHandler{ring}
+}
+
+// JWKS godoc
+//
+//	@Summary		Get the token signing keys
+//	@Description	Get the public keys access tokens are signed with, by kid, so that other services can verify them
+//	@Tags			users
+//	@Produce		json
+//	@Success		200	{object}	utils.JWKSet
+//	@Router			/.well-known/jwks.json [get]
+func (kh *KeyHandler) JWKS(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "public, max-age=300")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(kh.ring.JWKS())
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/NeGat1FF/todolist-api/internal/service"
+)
+
+type ListHandler struct {
+	ser *service.Service
+}
+
+func NewListHandler(ser *service.Service) *ListHandler {
+	return &ListHandler{ser}
+}
+
+type ListRequest struct {
+	Name string `json:"name"`
+}
+
+// GetLists godoc
+//
+//	@Summary		Get all lists
+//	@Description	Get all lists of the user
+//	@Tags			lists
+//	@Produce		json
+//	@Success		200	{array}	models.List
+//	@Router			/lists [get]
+func (lh *ListHandler) GetLists(rw http.ResponseWriter, r *http.Request) {
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	lists, err := lh.ser.GetLists(r.Context(), user_id)
+	if err != nil {
+		InternalError(rw)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(lists)
+}
+
+// GetListTasks godoc
+//
+//	@Summary		Get tasks of a list
+//	@Description	Get tasks of a list, accepts the same filters as GET /todos
+//	@Tags			lists
+//	@Produce		json
+//	@Param			id		path		int	true	"List ID"
+//	@Param			page	query		int	false	"Page number"
+//	@Param			limit	query		int	false	"Limit number"
+//	@Success		200		{object}	GetTasksResponse
+//	@Router			/lists/{id}/todos [get]
+func (lh *ListHandler) GetListTasks(rw http.ResponseWriter, r *http.Request) {
+	list_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+
+	filter, page, limit, err := parseTaskQuery(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	tasks, total, err := lh.ser.GetListTasks(r.Context(), list_id, user_id, filter, page, limit)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	writeTasksPage(rw, newTasksResponse(r, filter, tasks, total, page, limit))
+}
+
+// AddList godoc
+//
+//	@Summary		Add a new list
+//	@Description	Add a new list
+//	@Tags			lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			list	body		ListRequest	true	"List object that needs to be added"
+//	@Success		201		{object}	models.List
+//	@Router			/lists [post]
+func (lh *ListHandler) AddList(rw http.ResponseWriter, r *http.Request) {
+	list := r.Context().Value(models.ListKey{}).(models.List)
+	list.UserID = r.Context().Value(models.UserIDKey{}).(int)
+
+	list, err := lh.ser.AddList(r.Context(), list)
+	if err != nil {
+		InternalError(rw)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(list)
+}
+
+// UpdateList godoc
+//
+//	@Summary		Rename a list
+//	@Description	Rename a list
+//	@Tags			lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int			true	"List ID"
+//	@Param			list	body		ListRequest	true	"New list name"
+//	@Success		200		{object}	models.List
+//	@Router			/lists/{id} [put]
+func (lh *ListHandler) UpdateList(rw http.ResponseWriter, r *http.Request) {
+	list_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+	list := r.Context().Value(models.ListKey{}).(models.List)
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	list, err = lh.ser.UpdateList(r.Context(), list, list_id, user_id)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(list)
+}
+
+// DeleteList godoc
+//
+//	@Summary		Delete a list
+//	@Description	Delete a list, moving its tasks to the inbox (default) or deleting them
+//	@Tags			lists
+//	@Param			id		path	int		true	"List ID"
+//	@Param			tasks	query	string	false	"What to do with the tasks of the list"	Enums(move, delete)
+//	@Success		204
+//	@Router			/lists/{id} [delete]
+func (lh *ListHandler) DeleteList(rw http.ResponseWriter, r *http.Request) {
+	list_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+
+	var deleteTasks bool
+	switch r.URL.Query().Get("tasks") {
+	case "", models.ListDeleteMoveTasks:
+	case models.ListDeleteDeleteTasks:
+		deleteTasks = true
+	default:
+		http.Error(rw, "invalid tasks mode", http.StatusBadRequest)
+		return
+	}
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	err = lh.ser.DeleteList(r.Context(), list_id, user_id, deleteTasks)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
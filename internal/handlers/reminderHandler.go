@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/NeGat1FF/todolist-api/internal/service"
+)
+
+type ReminderHandler struct {
+	ser *service.Service
+}
+
+func NewReminderHandler(ser *service.Service) *ReminderHandler {
+	return &ReminderHandler{ser}
+}
+
+type ReminderRequest struct {
+	RemindAt      *time.Time `json:"remind_at"`
+	OffsetMinutes *int       `json:"offset_minutes"`
+}
+
+// GetReminders godoc
+//
+//	@Summary		Get reminders of a task
+//	@Description	Get all reminders of a task
+//	@Tags			reminders
+//	@Produce		json
+//	@Param			id	path	int	true	"Task ID"
+//	@Success		200	{array}	models.Reminder
+//	@Router			/todos/{id}/reminders [get]
+func (rh *ReminderHandler) GetReminders(rw http.ResponseWriter, r *http.Request) {
+	task_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	reminders, err := rh.ser.GetReminders(r.Context(), task_id, user_id)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(reminders)
+}
+
+// AddReminder godoc
+//
+//	@Summary		Add a reminder to a task
+//	@Description	Add a reminder firing at remind_at or offset_minutes before the due date of the task
+//	@Tags			reminders
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int				true	"Task ID"
+//	@Param			reminder	body		ReminderRequest	true	"Reminder object that needs to be added"
+//	@Success		201			{object}	models.Reminder
+//	@Router			/todos/{id}/reminders [post]
+func (rh *ReminderHandler) AddReminder(rw http.ResponseWriter, r *http.Request) {
+	task_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+	reminder := r.Context().Value(models.ReminderKey{}).(models.Reminder)
+	reminder.TaskID = task_id
+	reminder.UserID = r.Context().Value(models.UserIDKey{}).(int)
+
+	reminder, err = rh.ser.AddReminder(r.Context(), reminder)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(reminder)
+}
+
+// DeleteReminder godoc
+//
+//	@Summary		Delete a reminder
+//	@Description	Delete a reminder of a task
+//	@Tags			reminders
+//	@Param			id			path	int	true	"Task ID"
+//	@Param			reminder_id	path	int	true	"Reminder ID"
+//	@Success		204
+//	@Router			/todos/{id}/reminders/{reminder_id} [delete]
+func (rh *ReminderHandler) DeleteReminder(rw http.ResponseWriter, r *http.Request) {
+	task_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+	reminder_id, err := strconv.Atoi(r.PathValue("reminder_id"))
+	if err != nil {
+		http.Error(rw, "incorrect reminder id", http.StatusBadRequest)
+		return
+	}
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	err = rh.ser.DeleteReminder(r.Context(), reminder_id, task_id, user_id)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
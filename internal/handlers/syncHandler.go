@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/NeGat1FF/todolist-api/internal/service"
+	"github.com/NeGat1FF/todolist-api/internal/utils"
+)
+
+type SyncHandler struct {
+	ser *service.Service
+}
+
+func NewSyncHandler(ser *service.Service) *SyncHandler {
+	return &SyncHandler{ser}
+}
+
+// Sync godoc
+//
+//	@Summary		Sync offline changes
+//	@Description	Apply a batch of task changes made offline and return the server's changes since the last sync token
+//	@Tags			sync
+//	@Accept			json
+//	@Produce		json
+//	@Param			sync	body		models.SyncRequest	true	"Sync token and changes"
+//	@Success		200		{object}	models.SyncResult
+//	@Router			/sync [post]
+func (sh *SyncHandler) Sync(rw http.ResponseWriter, r *http.Request) {
+	req := r.Context().Value(models.SyncKey{}).(models.SyncRequest)
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	result, next, err := sh.ser.Sync(r.Context(), user_id, req)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	result.SyncToken, err = utils.SignCursor(models.SyncToken{XID: next})
+	if err != nil {
+		InternalError(rw)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(result)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/NeGat1FF/todolist-api/internal/service"
+)
+
+type TagHandler struct {
+	ser *service.Service
+}
+
+func NewTagHandler(ser *service.Service) *TagHandler {
+	return &TagHandler{ser}
+}
+
+type TagRequest struct {
+	Name string `json:"name"`
+}
+
+// GetTags godoc
+//
+//	@Summary		Get all tags
+//	@Description	Get all tags of the user
+//	@Tags			tags
+//	@Produce		json
+//	@Success		200	{array}	models.Tag
+//	@Router			/tags [get]
+func (th *TagHandler) GetTags(rw http.ResponseWriter, r *http.Request) {
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	tags, err := th.ser.GetTags(r.Context(), user_id)
+	if err != nil {
+		InternalError(rw)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(tags)
+}
+
+// AddTag godoc
+//
+//	@Summary		Add a new tag
+//	@Description	Add a new tag
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			tag	body		TagRequest	true	"Tag object that needs to be added"
+//	@Success		201	{object}	models.Tag
+//	@Router			/tags [post]
+func (th *TagHandler) AddTag(rw http.ResponseWriter, r *http.Request) {
+	tag := r.Context().Value(models.TagKey{}).(models.Tag)
+	tag.UserID = r.Context().Value(models.UserIDKey{}).(int)
+
+	tag, err := th.ser.AddTag(r.Context(), tag)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(tag)
+}
+
+// UpdateTag godoc
+//
+//	@Summary		Rename a tag
+//	@Description	Rename a tag
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int			true	"Tag ID"
+//	@Param			tag	body		TagRequest	true	"New tag name"
+//	@Success		200	{object}	models.Tag
+//	@Router			/tags/{id} [put]
+func (th *TagHandler) UpdateTag(rw http.ResponseWriter, r *http.Request) {
+	tag_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+	tag := r.Context().Value(models.TagKey{}).(models.Tag)
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	tag, err = th.ser.UpdateTag(r.Context(), tag, tag_id, user_id)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(tag)
+}
+
+// DeleteTag godoc
+//
+//	@Summary		Delete a tag
+//	@Description	Delete a tag and remove it from all tasks
+//	@Tags			tags
+//	@Param			id	path	int	true	"Tag ID"
+//	@Success		204
+//	@Router			/tags/{id} [delete]
+func (th *TagHandler) DeleteTag(rw http.ResponseWriter, r *http.Request) {
+	tag_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	err = th.ser.DeleteTag(r.Context(), tag_id, user_id)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/NeGat1FF/todolist-api/internal/models"
 	"github.com/NeGat1FF/todolist-api/internal/service"
+	"github.com/NeGat1FF/todolist-api/internal/utils"
 )
 
 type TaskHandler struct {
@@ -21,21 +27,82 @@ func InternalError(rw http.ResponseWriter) {
 	http.Error(rw, "internal server error", http.StatusInternalServerError)
 }
 
+// ServiceError writes err with the status code carried by service.ServerError,
+// falling back to 500 for any other error.
+func ServiceError(rw http.ResponseWriter, err error) {
+	var serr service.ServerError
+	if errors.As(err, &serr) {
+		http.Error(rw, serr.Error(), serr.Code)
+		return
+	}
+	InternalError(rw)
+}
+
 type AddTaskRequest struct {
 	Title       string
 	Description string
+	Status      string
+	StartAt     *time.Time `json:"start_at"`
+	DueAt       *time.Time `json:"due_at"`
+	Priority    int        `json:"priority"`
+	Tags        []TagRequest
+	ListID      *int   `json:"list_id"`
+	ParentID    *int   `json:"parent_id"`
+	RRule       string `json:"rrule"`
 }
 
 type UpdateTaskRequest struct {
 	Title       *string
 	Description *string
+	Status      *string
+	StartAt     *time.Time `json:"start_at"`
+	DueAt       *time.Time `json:"due_at"`
+	Priority    *int       `json:"priority"`
+	Tags        *[]TagRequest
+	ListID      *int    `json:"list_id"`
+	ParentID    *int    `json:"parent_id"`
+	RRule       *string `json:"rrule"`
+}
+
+// PatchOperationRequest is an operation of a JSON Patch; a JSON Merge Patch
+// has the shape of UpdateTaskRequest instead.
+type PatchOperationRequest struct {
+	Op    string `json:"op" enums:"add,remove,replace,move,copy,test"`
+	Path  string `json:"path" example:"/description"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+type MoveTaskRequest struct {
+	ListID *int `json:"list_id"`
+}
+
+// Pagination describes the page of a listing. Next and Prev are the URLs of
+// the neighbouring pages, or null at either end. Listings paged by cursor
+// leave out the page number and counts.
+type Pagination struct {
+	Page       int     `json:"page,omitempty"`
+	Limit      int     `json:"limit"`
+	Total      *int    `json:"total,omitempty"`
+	TotalPages *int    `json:"total_pages,omitempty"`
+	Next       *string `json:"next"`
+	Prev       *string `json:"prev"`
 }
 
 type GetTasksResponse struct {
-	Data  []models.Task
-	Page  int
-	Limit int
-	Total int
+	Data []models.Task `json:"data"`
+	Pagination
+	// NextCursor continues the listing after this page, or is null on the
+	// last page
+	NextCursor *string `json:"next_cursor"`
+	// Deleted lists the tasks purged from the trash since updated_since,
+	// which are no longer returned as tombstones
+	Deleted []int `json:"deleted,omitempty"`
+}
+
+type SearchTasksResponse struct {
+	Data []models.TaskSearchResult `json:"data"`
+	Pagination
 }
 
 // AddTask godoc
@@ -56,7 +123,7 @@ func (th *TaskHandler) AddTask(rw http.ResponseWriter, r *http.Request) {
 
 	task, err := th.ser.AddTask(r.Context(), task)
 	if err != nil {
-		InternalError(rw)
+		ServiceError(rw, err)
 		return
 	}
 
@@ -72,11 +139,214 @@ func (th *TaskHandler) AddTask(rw http.ResponseWriter, r *http.Request) {
 //	@Tags			tasks
 //	@Accept			json
 //	@Produce		json
-//	@Param			page	query		int						false	"Page number"
-//	@Param			limit	query		int						false	"Limit number"
+//	@Param			page		query		int						false	"Page number"
+//	@Param			limit		query		int						false	"Limit number"
+//	@Param			list_id		query		string					false	"Only tasks of this list, or inbox for tasks without a list"
+//	@Param			status		query		string					false	"Filter by status"	Enums(todo, in_progress, done)
+//	@Param			due			query		string					false	"Due date window in the user's time zone"	Enums(today, tomorrow, week, overdue)
+//	@Param			due_after	query		string					false	"Only tasks due at or after this RFC 3339 timestamp"
+//	@Param			due_before	query		string					false	"Only tasks due before this RFC 3339 timestamp"
+//	@Param			tag			query		[]string				false	"Only tasks with these tags"	collectionFormat(multi)
+//	@Param			tag_mode	query		string					false	"Require all or any of the tags (default all)"	Enums(all, any)
+//	@Param			tree		query		bool					false	"Return top-level tasks with their subtasks nested"
+//	@Param			include_archived	query	bool				false	"Also return archived tasks"
+//	@Param			archived	query		string					false	"Return only archived tasks"	Enums(only)
+//	@Param			updated_since	query	string					false	"Only tasks changed after this RFC 3339 timestamp, including deleted ones"
+//	@Param			sort		query		string					false	"Comma separated sort fields, prefix with - for descending (e.g. priority,-due_at)"
+//	@Param			cursor		query		string					false	"next_cursor of the previous page; replaces page"
 //	@Success		200		{object}	GetTasksResponse
+//	@Failure		410		{string}	string	"updated_since is older than the change log"
 //	@Router			/tasks [get]
 func (th *TaskHandler) GetTasks(rw http.ResponseWriter, r *http.Request) {
+	filter, page, limit, err := parseTaskQuery(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if l := r.URL.Query().Get("list_id"); l != "" {
+		if l == models.ListInbox {
+			filter.Inbox = true
+		} else if list_id, err := strconv.Atoi(l); err == nil {
+			filter.ListID = &list_id
+		} else {
+			http.Error(rw, "invalid list_id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	userID := r.Context().Value(models.UserIDKey{}).(int)
+
+	// Fetch tasks
+	tasks, total, err := th.ser.GetTasks(r.Context(), userID, filter, page, limit)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	response := newTasksResponse(r, filter, tasks, total, page, limit)
+
+	// Looked up after the tasks, so a task purged in between is reported
+	// twice rather than not at all
+	if filter.UpdatedSince != nil {
+		response.Deleted, err = th.ser.GetPurgedTasks(r.Context(), userID, *filter.UpdatedSince)
+		if err != nil {
+			ServiceError(rw, err)
+			return
+		}
+	}
+
+	writeTasksPage(rw, response)
+}
+
+// parseTaskQuery reads the paging, filtering and sorting parameters shared by
+// the task listing endpoints.
+func parseTaskQuery(r *http.Request) (models.TaskFilter, int, int, error) {
+	var filter models.TaskFilter
+	page, limit := parsePage(r)
+
+	if st := r.URL.Query().Get("status"); st != "" {
+		if !models.IsValidTaskStatus(st) {
+			return filter, page, limit, errors.New("invalid status")
+		}
+		filter.Status = st
+	}
+
+	if due := r.URL.Query().Get("due"); due != "" {
+		if !models.IsValidDueMode(due) {
+			return filter, page, limit, errors.New("invalid due mode")
+		}
+		filter.Due = due
+	}
+
+	if da := r.URL.Query().Get("due_after"); da != "" {
+		t, err := time.Parse(time.RFC3339, da)
+		if err != nil {
+			return filter, page, limit, errors.New("invalid due_after, expected RFC 3339 timestamp")
+		}
+		filter.DueFrom = &t
+	}
+
+	if db := r.URL.Query().Get("due_before"); db != "" {
+		t, err := time.Parse(time.RFC3339, db)
+		if err != nil {
+			return filter, page, limit, errors.New("invalid due_before, expected RFC 3339 timestamp")
+		}
+		filter.DueBefore = &t
+	}
+
+	if tags := r.URL.Query()["tag"]; len(tags) > 0 {
+		seen := make(map[string]bool)
+		for _, tag := range tags {
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+	}
+
+	switch mode := r.URL.Query().Get("tag_mode"); mode {
+	case "", models.TagModeAll, models.TagModeAny:
+		filter.TagMode = mode
+	default:
+		return filter, page, limit, errors.New("invalid tag_mode")
+	}
+
+	if tree := r.URL.Query().Get("tree"); tree != "" {
+		var err error
+		filter.Tree, err = strconv.ParseBool(tree)
+		if err != nil {
+			return filter, page, limit, errors.New("invalid tree flag")
+		}
+	}
+
+	var err error
+	filter.Archived, err = parseArchived(r)
+	if err != nil {
+		return filter, page, limit, err
+	}
+
+	if us := r.URL.Query().Get("updated_since"); us != "" {
+		t, err := time.Parse(time.RFC3339, us)
+		if err != nil {
+			return filter, page, limit, errors.New("invalid updated_since, expected RFC 3339 timestamp")
+		}
+		filter.UpdatedSince = &t
+
+		// Archiving is a change like any other, so delta queries report
+		// archived tasks unless asked otherwise
+		if !r.URL.Query().Has("include_archived") && !r.URL.Query().Has("archived") {
+			filter.Archived = models.TaskArchivedInclude
+		}
+	}
+
+	if sort := r.URL.Query().Get("sort"); sort != "" {
+		keys, err := models.ParseTaskSort(sort)
+		if err != nil {
+			return filter, page, limit, err
+		}
+		filter.Sort = keys
+	} else if filter.UpdatedSince != nil {
+		filter.Sort = []models.TaskSort{{Field: "updated_at"}}
+	}
+
+	if c := r.URL.Query().Get("cursor"); c != "" {
+		var cursor models.TaskCursor
+		if err := utils.ParseCursor(c, &cursor); err != nil {
+			return filter, page, limit, err
+		}
+
+		// The cursor carries the sort it was made for
+		if r.URL.Query().Has("sort") && models.FormatTaskSort(filter.Sort) != cursor.Sort {
+			return filter, page, limit, errors.New("cursor does not match sort")
+		}
+		if cursor.Sort != "" {
+			keys, err := models.ParseTaskSort(cursor.Sort)
+			if err != nil {
+				return filter, page, limit, utils.ErrInvalidCursor
+			}
+			filter.Sort = keys
+		}
+		if len(cursor.Values) != len(models.TaskSortKeys(filter.Sort)) {
+			return filter, page, limit, utils.ErrInvalidCursor
+		}
+
+		filter.After = cursor.Values
+		page = 1
+	}
+
+	return filter, page, limit, nil
+}
+
+// parseArchived reads the include_archived and archived parameters into one
+// of the models.TaskArchived* modes.
+func parseArchived(r *http.Request) (string, error) {
+	mode := models.TaskArchivedExclude
+
+	if ia := r.URL.Query().Get("include_archived"); ia != "" {
+		include, err := strconv.ParseBool(ia)
+		if err != nil {
+			return mode, errors.New("invalid include_archived flag")
+		}
+		if include {
+			mode = models.TaskArchivedInclude
+		}
+	}
+
+	switch archived := r.URL.Query().Get("archived"); archived {
+	case "":
+	case models.TaskArchivedOnly:
+		mode = archived
+	default:
+		return mode, errors.New("invalid archived mode")
+	}
+
+	return mode, nil
+}
+
+// parsePage reads the page and limit parameters, falling back to the first
+// page of 10 items.
+func parsePage(r *http.Request) (int, int) {
 	page := 1
 	limit := 10
 
@@ -92,68 +362,248 @@ func (th *TaskHandler) GetTasks(rw http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	userID := r.Context().Value(models.UserIDKey{}).(int)
+	return page, limit
+}
 
-	// Fetch tasks
-	tasks, err := th.ser.GetTasks(r.Context(), userID, page, limit)
+// newPagination describes page out of total items, linking the neighbouring
+// pages by the request URL with only the page parameter changed.
+func newPagination(r *http.Request, total, page, limit int) Pagination {
+	totalPages := (total + limit - 1) / limit
+	p := Pagination{
+		Page:       page,
+		Limit:      limit,
+		Total:      &total,
+		TotalPages: &totalPages,
+	}
+
+	link := func(page int) *string {
+		u := *r.URL
+		q := u.Query()
+		q.Set("page", strconv.Itoa(page))
+		u.RawQuery = q.Encode()
+		s := u.RequestURI()
+		return &s
+	}
+
+	if page < totalPages {
+		p.Next = link(page + 1)
+	}
+	if page > 1 {
+		p.Prev = link(min(page-1, max(totalPages, 1)))
+	}
+
+	return p
+}
+
+// newTasksResponse builds the response for a page of tasks listed with
+// filter. In cursor mode (filter.After set) there are no page numbers or
+// counts, total only exceeds len(tasks) when more tasks follow, and next
+// links the next cursor.
+func newTasksResponse(r *http.Request, filter models.TaskFilter, tasks []models.Task, total, page, limit int) GetTasksResponse {
+	response := GetTasksResponse{Data: tasks, Pagination: newPagination(r, total, page, limit)}
+
+	more := page < *response.TotalPages
+	if filter.After != nil {
+		more = total > len(tasks)
+		response.Page, response.Total, response.TotalPages, response.Next, response.Prev = 0, nil, nil, nil, nil
+	}
+	if !more || len(tasks) == 0 {
+		return response
+	}
+
+	sort := tasks[len(tasks)-1].SortValues(models.TaskSortKeys(filter.Sort))
+	cursor, err := utils.SignCursor(models.TaskCursor{Sort: models.FormatTaskSort(filter.Sort), Values: sort})
 	if err != nil {
-		InternalError(rw)
+		return response
+	}
+	response.NextCursor = &cursor
+
+	if filter.After != nil {
+		u := *r.URL
+		q := u.Query()
+		q.Del("page")
+		q.Set("cursor", cursor)
+		u.RawQuery = q.Encode()
+		next := u.RequestURI()
+		response.Next = &next
+	}
+
+	return response
+}
+
+func writeTasksPage(rw http.ResponseWriter, response any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(response)
+}
+
+// GetTask godoc
+//
+//	@Summary		Get a task
+//	@Description	Get a task; answers 304 when If-None-Match holds its current ETag
+//	@Tags			tasks
+//	@Produce		json
+//	@Param			id				path		int		true	"Task ID"
+//	@Param			If-None-Match	header		string	false	"ETag of the cached task"
+//	@Success		200				{object}	models.Task
+//	@Success		304				{string}	string
+//	@Router			/todos/{id} [get]
+func (th *TaskHandler) GetTask(rw http.ResponseWriter, r *http.Request) {
+	task_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	task, err := th.ser.GetTask(r.Context(), task_id, user_id)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	etag := taskETag(task)
+	rw.Header().Set("ETag", etag)
+	if inm := r.Header.Get("If-None-Match"); inm != "" && matchETag(inm, etag, true) {
+		rw.WriteHeader(http.StatusNotModified)
 		return
 	}
 
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(task)
+}
 
-	response := map[string]any{
-		"data":  tasks,
-		"page":  page,
-		"limit": limit,
-		"total": len(tasks),
+// taskETag is the entity tag of a task, which follows its version.
+func taskETag(task models.Task) string {
+	return `"` + strconv.FormatInt(task.Version, 10) + `"`
+}
+
+// matchETag reports whether the comma separated list of entity tags in header
+// holds etag or is "*". Weak tags only match when weak comparison is allowed.
+func matchETag(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == "*" || tag == etag {
+			return true
+		}
 	}
+	return false
+}
 
-	json.NewEncoder(rw).Encode(response)
+// checkIfMatch evaluates the If-Match header of r against the current task.
+// It returns the version a write has to be made against, 0 without the
+// header, and false after writing an error response.
+func (th *TaskHandler) checkIfMatch(rw http.ResponseWriter, r *http.Request, task_id, user_id int) (int64, bool) {
+	im := r.Header.Get("If-Match")
+	if im == "" {
+		return 0, true
+	}
+
+	task, err := th.ser.GetTask(r.Context(), task_id, user_id)
+	if err != nil {
+		ServiceError(rw, err)
+		return 0, false
+	}
+
+	if !matchETag(im, taskETag(task), false) {
+		rw.Header().Set("ETag", taskETag(task))
+		http.Error(rw, "task was changed in the meantime", http.StatusPreconditionFailed)
+		return 0, false
+	}
+	return task.Version, true
 }
 
 // UpdateTask godoc
 //
 //	@Summary		Update a task
-//	@Description	Update a task
+//	@Description	Update a task; with If-Match only if it is still at that ETag
 //	@Tags			tasks
 //	@Accept			json
 //	@Produce		json
-//	@Param			id		path		int					true	"Task ID"
-//	@Param			task	body		UpdateTaskRequest	true	"Task object that needs to be updated"
-//	@Success		202		{object}	models.Task
+//	@Param			id			path		int					true	"Task ID"
+//	@Param			If-Match	header		string				false	"ETag the task is expected to have"
+//	@Param			task		body		UpdateTaskRequest	true	"Task object that needs to be updated"
+//	@Success		202			{object}	models.Task
 //	@Router			/tasks/{id} [put]
 func (th *TaskHandler) UpdateTask(rw http.ResponseWriter, r *http.Request) {
 	task_id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		http.Error(rw, "incorrect id", http.StatusBadGateway)
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
 		return
 	}
 	task := r.Context().Value(models.TaskKey{}).(models.Task)
 	user_id := r.Context().Value(models.UserIDKey{}).(int)
 
-	task, err = th.ser.UpdateTask(r.Context(), task, task_id, user_id)
+	version, ok := th.checkIfMatch(rw, r, task_id, user_id)
+	if !ok {
+		return
+	}
+
+	task, err = th.ser.UpdateTask(r.Context(), task, task_id, user_id, version)
 	if err != nil {
-		InternalError(rw)
+		ServiceError(rw, err)
 		return
 	}
 
+	rw.Header().Set("ETag", taskETag(task))
 	rw.Header().Set("Content-type", "application/json")
 	rw.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(rw).Encode(task)
 }
 
+// PatchTask godoc
+//
+//	@Summary		Patch a task
+//	@Description	Change some fields of a task with a JSON Merge Patch (null clears a field) or a JSON Patch; with If-Match only if it is still at that ETag
+//	@Tags			tasks
+//	@Accept			application/merge-patch+json
+//	@Accept			application/json-patch+json
+//	@Produce		json
+//	@Param			id			path		int						true	"Task ID"
+//	@Param			If-Match	header		string					false	"ETag the task is expected to have"
+//	@Param			patch		body		[]PatchOperationRequest	true	"JSON Patch, or a JSON Merge Patch object of task fields"
+//	@Success		200			{object}	models.Task
+//	@Router			/todos/{id} [patch]
+func (th *TaskHandler) PatchTask(rw http.ResponseWriter, r *http.Request) {
+	task_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+	patch := r.Context().Value(models.TaskPatchKey{}).(models.TaskPatch)
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	version, ok := th.checkIfMatch(rw, r, task_id, user_id)
+	if !ok {
+		return
+	}
+
+	task, err := th.ser.PatchTask(r.Context(), patch, task_id, user_id, version)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.Header().Set("ETag", taskETag(task))
+	rw.Header().Set("Content-type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(task)
+}
+
 // DeleteTask godoc
 //
 //	@Summary		Delete a task
-//	@Description	Delete a task
+//	@Description	Move a task and its subtasks to the trash
 //	@Tags			tasks
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		int	true	"Task ID"
-//	@Success		204	{object}	string
+//	@Param			id			path		int		true	"Task ID"
+//	@Param			If-Match	header		string	false	"ETag the task is expected to have"
+//	@Success		204			{object}	string
 //	@Router			/tasks/{id} [delete]
 func (th *TaskHandler) DeleteTask(rw http.ResponseWriter, r *http.Request) {
 	task_id, err := strconv.Atoi(r.PathValue("id"))
@@ -163,11 +613,361 @@ func (th *TaskHandler) DeleteTask(rw http.ResponseWriter, r *http.Request) {
 	}
 	user_id := r.Context().Value(models.UserIDKey{}).(int)
 
-	err = th.ser.DeleteTask(r.Context(), task_id, user_id)
+	version, ok := th.checkIfMatch(rw, r, task_id, user_id)
+	if !ok {
+		return
+	}
+
+	err = th.ser.DeleteTask(r.Context(), task_id, user_id, version)
 	if err != nil {
-		InternalError(rw)
+		ServiceError(rw, err)
 		return
 	}
 
 	rw.WriteHeader(http.StatusNoContent)
 }
+
+// GetTrash godoc
+//
+//	@Summary		Get trashed tasks
+//	@Description	Get deleted tasks that can still be restored, most recently deleted first
+//	@Tags			trash
+//	@Produce		json
+//	@Param			page	query		int	false	"Page number"
+//	@Param			limit	query		int	false	"Limit number"
+//	@Success		200		{object}	GetTasksResponse
+//	@Router			/trash [get]
+func (th *TaskHandler) GetTrash(rw http.ResponseWriter, r *http.Request) {
+	page, limit := parsePage(r)
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	tasks, total, err := th.ser.GetTrash(r.Context(), user_id, page, limit)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	writeTasksPage(rw, GetTasksResponse{Data: tasks, Pagination: newPagination(r, total, page, limit)})
+}
+
+// EmptyTrash godoc
+//
+//	@Summary		Empty the trash
+//	@Description	Permanently delete all trashed tasks
+//	@Tags			trash
+//	@Success		204
+//	@Router			/trash [delete]
+func (th *TaskHandler) EmptyTrash(rw http.ResponseWriter, r *http.Request) {
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	err := th.ser.EmptyTrash(r.Context(), user_id)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// PurgeTask godoc
+//
+//	@Summary		Permanently delete a task
+//	@Description	Permanently delete a trashed task and its subtasks
+//	@Tags			trash
+//	@Param			id	path	int	true	"Task ID"
+//	@Success		204
+//	@Router			/trash/{id} [delete]
+func (th *TaskHandler) PurgeTask(rw http.ResponseWriter, r *http.Request) {
+	task_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	err = th.ser.PurgeTask(r.Context(), task_id, user_id)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreTask godoc
+//
+//	@Summary		Restore a task
+//	@Description	Take a task and the subtasks deleted with it out of the trash
+//	@Tags			trash
+//	@Produce		json
+//	@Param			id	path		int	true	"Task ID"
+//	@Success		200	{object}	models.Task
+//	@Router			/todos/{id}/restore [post]
+func (th *TaskHandler) RestoreTask(rw http.ResponseWriter, r *http.Request) {
+	task_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	task, err := th.ser.RestoreTask(r.Context(), task_id, user_id)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(task)
+}
+
+// ArchiveTask godoc
+//
+//	@Summary		Archive a task
+//	@Description	Hide a task and its subtasks from listings without deleting them
+//	@Tags			tasks
+//	@Produce		json
+//	@Param			id	path		int	true	"Task ID"
+//	@Success		200	{object}	models.Task
+//	@Router			/todos/{id}/archive [post]
+func (th *TaskHandler) ArchiveTask(rw http.ResponseWriter, r *http.Request) {
+	th.runTaskAction(rw, r, th.ser.ArchiveTask)
+}
+
+// UnarchiveTask godoc
+//
+//	@Summary		Unarchive a task
+//	@Description	Take a task and the subtasks archived with it out of the archive
+//	@Tags			tasks
+//	@Produce		json
+//	@Param			id	path		int	true	"Task ID"
+//	@Success		200	{object}	models.Task
+//	@Router			/todos/{id}/unarchive [post]
+func (th *TaskHandler) UnarchiveTask(rw http.ResponseWriter, r *http.Request) {
+	th.runTaskAction(rw, r, th.ser.UnarchiveTask)
+}
+
+type ArchiveCompletedResponse struct {
+	Archived int `json:"archived"`
+}
+
+// ArchiveCompleted godoc
+//
+//	@Summary		Archive completed tasks
+//	@Description	Archive all done tasks with their subtasks, optionally only those of one list or the inbox
+//	@Tags			tasks
+//	@Produce		json
+//	@Param			list_id	query		string	false	"Only tasks of this list, or inbox for tasks without a list"
+//	@Success		200		{object}	ArchiveCompletedResponse
+//	@Router			/todos/archive-completed [post]
+func (th *TaskHandler) ArchiveCompleted(rw http.ResponseWriter, r *http.Request) {
+	var list_id *int
+	var inbox bool
+	if l := r.URL.Query().Get("list_id"); l != "" {
+		if l == models.ListInbox {
+			inbox = true
+		} else if id, err := strconv.Atoi(l); err == nil {
+			list_id = &id
+		} else {
+			http.Error(rw, "invalid list_id", http.StatusBadRequest)
+			return
+		}
+	}
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	n, err := th.ser.ArchiveCompleted(r.Context(), user_id, list_id, inbox)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(ArchiveCompletedResponse{n})
+}
+
+// CompleteTask godoc
+//
+//	@Summary		Complete a task
+//	@Description	Mark a task as done and record when it was completed
+//	@Tags			tasks
+//	@Produce		json
+//	@Param			id	path		int	true	"Task ID"
+//	@Success		200	{object}	models.Task
+//	@Router			/todos/{id}/complete [post]
+func (th *TaskHandler) CompleteTask(rw http.ResponseWriter, r *http.Request) {
+	th.runTaskAction(rw, r, th.ser.CompleteTask)
+}
+
+// ReopenTask godoc
+//
+//	@Summary		Reopen a task
+//	@Description	Move a task back to todo and clear its completion time
+//	@Tags			tasks
+//	@Produce		json
+//	@Param			id	path		int	true	"Task ID"
+//	@Success		200	{object}	models.Task
+//	@Router			/todos/{id}/reopen [post]
+func (th *TaskHandler) ReopenTask(rw http.ResponseWriter, r *http.Request) {
+	th.runTaskAction(rw, r, th.ser.ReopenTask)
+}
+
+// runTaskAction runs action on the task of the path and responds with the
+// resulting task.
+func (th *TaskHandler) runTaskAction(rw http.ResponseWriter, r *http.Request, action func(ctx context.Context, task_id, user_id int) (models.Task, error)) {
+	task_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	task, err := action(r.Context(), task_id, user_id)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(task)
+}
+
+// MoveTask godoc
+//
+//	@Summary		Move a task to another list
+//	@Description	Move a task to another list, or to the inbox when list_id is null
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"Task ID"
+//	@Param			body	body		MoveTaskRequest	true	"Target list"
+//	@Success		200		{object}	models.Task
+//	@Router			/todos/{id}/move [post]
+func (th *TaskHandler) MoveTask(rw http.ResponseWriter, r *http.Request) {
+	task_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+	target := r.Context().Value(models.TaskKey{}).(models.Task)
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	task, err := th.ser.MoveTask(r.Context(), task_id, user_id, target.ListID)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(task)
+}
+
+// SearchTasks godoc
+//
+//	@Summary		Search tasks
+//	@Description	Full-text search over task titles and descriptions, best matches first
+//	@Tags			tasks
+//	@Produce		json
+//	@Param			q		query		string	true	"Search query, supports \"quoted phrases\", OR and -excluded words"
+//	@Param			include_archived	query	bool	false	"Also search archived tasks"
+//	@Param			archived	query		string	false	"Search only archived tasks"	Enums(only)
+//	@Param			page	query		int		false	"Page number"
+//	@Param			limit	query		int		false	"Limit number"
+//	@Success		200		{object}	SearchTasksResponse
+//	@Router			/todos/search [get]
+func (th *TaskHandler) SearchTasks(rw http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	switch {
+	case query == "":
+		http.Error(rw, "search query is not specified", http.StatusBadRequest)
+		return
+	case utf8.RuneCountInString(query) > models.TaskSearchMaxLength:
+		http.Error(rw, "search query is too long", http.StatusBadRequest)
+		return
+	}
+
+	archived, err := parseArchived(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, limit := parsePage(r)
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	results, total, err := th.ser.SearchTasks(r.Context(), user_id, query, archived, page, limit)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	writeTasksPage(rw, SearchTasksResponse{results, newPagination(r, total, page, limit)})
+}
+
+// GetSubtasks godoc
+//
+//	@Summary		Get subtasks of a task
+//	@Description	Get direct subtasks of a task, accepts the same filters as GET /todos
+//	@Tags			tasks
+//	@Produce		json
+//	@Param			id		path		int		true	"Task ID"
+//	@Param			page	query		int		false	"Page number"
+//	@Param			limit	query		int		false	"Limit number"
+//	@Param			tree	query		bool	false	"Nest all deeper subtasks as well"
+//	@Success		200		{object}	GetTasksResponse
+//	@Router			/todos/{id}/subtasks [get]
+func (th *TaskHandler) GetSubtasks(rw http.ResponseWriter, r *http.Request) {
+	task_id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(rw, "incorrect id", http.StatusBadRequest)
+		return
+	}
+
+	filter, page, limit, err := parseTaskQuery(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	tasks, total, err := th.ser.GetSubtasks(r.Context(), task_id, user_id, filter, page, limit)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	writeTasksPage(rw, newTasksResponse(r, filter, tasks, total, page, limit))
+}
+
+// BulkTasks godoc
+//
+//	@Summary		Run task operations in bulk
+//	@Description	Create, update, delete and complete tasks in one transaction. With atomic the first failed operation rolls back all of them and the response is 409.
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Param			bulk	body		models.BulkRequest	true	"Operations"
+//	@Success		200		{object}	models.BulkResult
+//	@Failure		409		{object}	models.BulkResult
+//	@Router			/todos/bulk [post]
+func (th *TaskHandler) BulkTasks(rw http.ResponseWriter, r *http.Request) {
+	req := r.Context().Value(models.BulkKey{}).(models.BulkRequest)
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	result, err := th.ser.BulkTasks(r.Context(), user_id, req)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if result.Committed {
+		rw.WriteHeader(http.StatusOK)
+	} else {
+		rw.WriteHeader(http.StatusConflict)
+	}
+	json.NewEncoder(rw).Encode(result)
+}
@@ -0,0 +1,489 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/NeGat1FF/todolist-api/internal/service"
+	"github.com/NeGat1FF/todolist-api/internal/utils"
+	"github.com/NeGat1FF/todolist-api/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAddTaskToForeignList(t *testing.T) {
+	TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+	ListRepoMock := mocks.NewListRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	th := NewTaskHandler(service.NewService(nil, TaskRepoMock, nil, ListRepoMock, nil, nil, nil, logger))
+
+	listID := 7
+	ListRepoMock.On("GetListByID", mock.Anything, 7, 1).Return(models.List{}, sql.ErrNoRows)
+
+	ctx := context.WithValue(context.Background(), models.TaskKey{}, models.Task{Title: "Test Task", ListID: &listID})
+	ctx = context.WithValue(ctx, models.UserIDKey{}, 1)
+	req := httptest.NewRequest(http.MethodPost, "/tasks", nil).WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	th.AddTask(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestGetTasksUpdatedSinceAfterPurge(t *testing.T) {
+	purgedAt := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name            string
+		updatedSince    string
+		expectedCode    int
+		expectedDeleted []int
+	}{
+		{
+			name:            "Deleted and purged since",
+			updatedSince:    "2024-10-10T00:00:00Z",
+			expectedCode:    http.StatusOK,
+			expectedDeleted: []int{5},
+		},
+		{
+			name:         "Change log purged since",
+			updatedSince: "2024-09-01T00:00:00Z",
+			expectedCode: http.StatusGone,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			th := NewTaskHandler(service.NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger))
+
+			since, _ := time.Parse(time.RFC3339, tc.updatedSince)
+			// The purged task is gone from the tasks, leaving only its change log
+			TaskRepoMock.On("GetTasks", mock.Anything, 1, mock.Anything, 1, 10).Return([]models.Task{}, 0, nil)
+			TaskRepoMock.On("GetChangeLogHorizon", mock.Anything).Return(uint64(100), purgedAt, nil)
+			if tc.expectedDeleted != nil {
+				TaskRepoMock.On("GetPurgedTaskIDs", mock.Anything, 1, since).Return(tc.expectedDeleted, nil)
+			}
+
+			ctx := context.WithValue(context.Background(), models.UserIDKey{}, 1)
+			req := httptest.NewRequest(http.MethodGet, "/todos?updated_since="+tc.updatedSince, nil).WithContext(ctx)
+			rr := httptest.NewRecorder()
+
+			th.GetTasks(rr, req)
+
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedCode, rr.Code)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response GetTasksResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(response.Deleted, tc.expectedDeleted) {
+				t.Errorf("Expected deleted: %v, got: %v", tc.expectedDeleted, response.Deleted)
+			}
+		})
+	}
+}
+
+func TestSearchTasks(t *testing.T) {
+	results := []models.TaskSearchResult{
+		{Task: models.Task{ID: 2, Title: "Buy milk"}, Rank: 0.6, TitleHighlight: "Buy <mark>milk</mark>"},
+		{Task: models.Task{ID: 1, Title: "Milk the cow"}, Rank: 0.3, TitleHighlight: "<mark>Milk</mark> the cow"},
+	}
+
+	testCases := []struct {
+		name         string
+		query        string
+		mockSetup    func(taskRepoMock *mocks.TaskRepositoryInterface)
+		expectedCode int
+	}{
+		{
+			name:  "Best matches first",
+			query: "q=+milk+",
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("SearchTasks", mock.Anything, 1, "milk", models.TaskArchivedExclude, 1, 10).Return(results, 2, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:  "Archived tasks only",
+			query: "q=milk&archived=only",
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("SearchTasks", mock.Anything, 1, "milk", models.TaskArchivedOnly, 1, 10).Return(results, 2, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Empty query",
+			query:        "q=+",
+			mockSetup:    func(taskRepoMock *mocks.TaskRepositoryInterface) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Query too long",
+			query:        "q=" + strings.Repeat("ä", models.TaskSearchMaxLength+1),
+			mockSetup:    func(taskRepoMock *mocks.TaskRepositoryInterface) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid archived mode",
+			query:        "q=milk&archived=all",
+			mockSetup:    func(taskRepoMock *mocks.TaskRepositoryInterface) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			th := NewTaskHandler(service.NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger))
+			tc.mockSetup(TaskRepoMock)
+
+			ctx := context.WithValue(context.Background(), models.UserIDKey{}, 1)
+			req := httptest.NewRequest(http.MethodGet, "/todos/search?"+tc.query, nil).WithContext(ctx)
+			rr := httptest.NewRecorder()
+
+			th.SearchTasks(rr, req)
+
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedCode, rr.Code)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response SearchTasksResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatal(err)
+			}
+			if len(response.Data) != 2 || response.Data[0].ID != 2 || response.Data[1].ID != 1 {
+				t.Fatalf("Expected the results in rank order, got: %+v", response.Data)
+			}
+			if response.Data[0].Rank != 0.6 || response.Data[0].TitleHighlight != "Buy <mark>milk</mark>" {
+				t.Errorf("Expected rank and highlight, got: %+v", response.Data[0])
+			}
+			if response.Total == nil || *response.Total != 2 {
+				t.Errorf("Expected a total of 2, got: %v", response.Total)
+			}
+		})
+	}
+}
+
+func TestMatchETag(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   string
+		weak     bool
+		expected bool
+	}{
+		{name: "Same tag", header: `"5"`, expected: true},
+		{name: "Other tag", header: `"4"`, expected: false},
+		{name: "Any tag", header: `*`, expected: true},
+		{name: "List holding the tag", header: `"3", "5"`, expected: true},
+		{name: "List without the tag", header: `"3","4"`, expected: false},
+		{name: "Weak tag with weak comparison", header: `W/"5"`, weak: true, expected: true},
+		{name: "Weak tag with strong comparison", header: `W/"5"`, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if matched := matchETag(tc.header, `"5"`, tc.weak); matched != tc.expected {
+				t.Errorf("Expected match: %v, got: %v", tc.expected, matched)
+			}
+		})
+	}
+}
+
+func TestGetTaskIfNoneMatch(t *testing.T) {
+	testCases := []struct {
+		name         string
+		ifNoneMatch  string
+		expectedCode int
+	}{
+		{name: "Without header", expectedCode: http.StatusOK},
+		{name: "Current tag", ifNoneMatch: `"5"`, expectedCode: http.StatusNotModified},
+		{name: "Stale tag", ifNoneMatch: `"4"`, expectedCode: http.StatusOK},
+		{name: "Weak current tag", ifNoneMatch: `W/"5"`, expectedCode: http.StatusNotModified},
+		{name: "List holding the current tag", ifNoneMatch: `"4", "5"`, expectedCode: http.StatusNotModified},
+		{name: "Any tag", ifNoneMatch: `*`, expectedCode: http.StatusNotModified},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			th := NewTaskHandler(service.NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger))
+			TaskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(models.Task{ID: 1, UserID: 1, Title: "Buy milk", Version: 5}, nil)
+
+			ctx := context.WithValue(context.Background(), models.UserIDKey{}, 1)
+			req := httptest.NewRequest(http.MethodGet, "/todos/1", nil).WithContext(ctx)
+			req.SetPathValue("id", "1")
+			if tc.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			rr := httptest.NewRecorder()
+
+			th.GetTask(rr, req)
+
+			if rr.Code != tc.expectedCode {
+				t.Errorf("Expected status code: %d, got: %d", tc.expectedCode, rr.Code)
+			}
+			if etag := rr.Header().Get("ETag"); etag != `"5"` {
+				t.Errorf(`Expected ETag "5", got: %s`, etag)
+			}
+			if tc.expectedCode == http.StatusNotModified && rr.Body.Len() != 0 {
+				t.Errorf("Expected no body, got: %s", rr.Body)
+			}
+		})
+	}
+}
+
+func TestConditionalWrites(t *testing.T) {
+	current := models.Task{ID: 1, UserID: 1, Title: "Buy milk", Status: models.TaskStatusTodo, Version: 5}
+	updated := models.Task{ID: 1, UserID: 1, Title: "Buy bread", Status: models.TaskStatusTodo, Version: 6}
+
+	write := func(th *TaskHandler, method string) func(rw http.ResponseWriter, r *http.Request) {
+		switch method {
+		case http.MethodPut:
+			return th.UpdateTask
+		case http.MethodPatch:
+			return th.PatchTask
+		}
+		return th.DeleteTask
+	}
+
+	testCases := []struct {
+		name         string
+		method       string
+		ifMatch      string
+		mockSetup    func(taskRepoMock *mocks.TaskRepositoryInterface)
+		expectedCode int
+		expectedETag string
+	}{
+		{
+			name:    "PUT at the current tag",
+			method:  http.MethodPut,
+			ifMatch: `"5"`,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("UpdateTask", mock.Anything, mock.Anything, 1, 1, int64(5)).Return(updated, nil)
+			},
+			expectedCode: http.StatusAccepted,
+			expectedETag: `"6"`,
+		},
+		{
+			name:         "PUT at a stale tag",
+			method:       http.MethodPut,
+			ifMatch:      `"4"`,
+			mockSetup:    func(taskRepoMock *mocks.TaskRepositoryInterface) {},
+			expectedCode: http.StatusPreconditionFailed,
+			expectedETag: `"5"`,
+		},
+		{
+			name:         "PUT at a weak tag",
+			method:       http.MethodPut,
+			ifMatch:      `W/"5"`,
+			mockSetup:    func(taskRepoMock *mocks.TaskRepositoryInterface) {},
+			expectedCode: http.StatusPreconditionFailed,
+			expectedETag: `"5"`,
+		},
+		{
+			name:    "PATCH at any tag",
+			method:  http.MethodPatch,
+			ifMatch: `*`,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("UpdateTaskFields", mock.Anything, mock.Anything, []string{"title"}, 1, 1, int64(5)).Return(updated, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedETag: `"6"`,
+		},
+		{
+			name:         "PATCH at a stale tag",
+			method:       http.MethodPatch,
+			ifMatch:      `"3", "4"`,
+			mockSetup:    func(taskRepoMock *mocks.TaskRepositoryInterface) {},
+			expectedCode: http.StatusPreconditionFailed,
+			expectedETag: `"5"`,
+		},
+		{
+			name:    "DELETE at one of the tags",
+			method:  http.MethodDelete,
+			ifMatch: `"4", "5"`,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("DeleteTask", mock.Anything, 1, 1, int64(5)).Return(nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "DELETE at a stale tag",
+			method:       http.MethodDelete,
+			ifMatch:      `"4"`,
+			mockSetup:    func(taskRepoMock *mocks.TaskRepositoryInterface) {},
+			expectedCode: http.StatusPreconditionFailed,
+			expectedETag: `"5"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			th := NewTaskHandler(service.NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger))
+			TaskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(current, nil)
+			TaskRepoMock.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			}).Maybe()
+			tc.mockSetup(TaskRepoMock)
+
+			ctx := context.WithValue(context.Background(), models.UserIDKey{}, 1)
+			ctx = context.WithValue(ctx, models.TaskKey{}, models.Task{Title: "Buy bread"})
+			ctx = context.WithValue(ctx, models.TaskPatchKey{}, models.TaskPatch{Merge: map[string]any{"title": "Buy bread"}})
+			req := httptest.NewRequest(tc.method, "/todos/1", nil).WithContext(ctx)
+			req.SetPathValue("id", "1")
+			req.Header.Set("If-Match", tc.ifMatch)
+			rr := httptest.NewRecorder()
+
+			write(th, tc.method)(rr, req)
+
+			if rr.Code != tc.expectedCode {
+				t.Errorf("Expected status code: %d, got: %d", tc.expectedCode, rr.Code)
+			}
+			if etag := rr.Header().Get("ETag"); etag != tc.expectedETag {
+				t.Errorf("Expected ETag %q, got: %q", tc.expectedETag, etag)
+			}
+		})
+	}
+}
+
+func TestNewPagination(t *testing.T) {
+	target := "/todos?status=todo&sort=-priority&tag=work&tag=home&limit=10"
+
+	testCases := []struct {
+		name               string
+		total              int
+		page               int
+		expectedTotalPages int
+		expectedNext       int
+		expectedPrev       int
+	}{
+		{name: "First page", total: 21, page: 1, expectedTotalPages: 3, expectedNext: 2},
+		{name: "Middle page", total: 21, page: 2, expectedTotalPages: 3, expectedNext: 3, expectedPrev: 1},
+		{name: "Last page", total: 21, page: 3, expectedTotalPages: 3, expectedPrev: 2},
+		{name: "Full last page", total: 20, page: 2, expectedTotalPages: 2, expectedPrev: 1},
+		{name: "Past the last page", total: 21, page: 5, expectedTotalPages: 3, expectedPrev: 3},
+		{name: "No items", total: 0, page: 1, expectedTotalPages: 0},
+		{name: "Past the end without items", total: 0, page: 2, expectedTotalPages: 0, expectedPrev: 1},
+	}
+
+	// checkLink asserts that link leads to page with the other parameters of
+	// the request kept
+	checkLink := func(t *testing.T, link *string, page int) {
+		t.Helper()
+		if page == 0 {
+			if link != nil {
+				t.Errorf("Expected no link, got: %s", *link)
+			}
+			return
+		}
+		if link == nil {
+			t.Fatalf("Expected a link to page %d, got none", page)
+		}
+
+		u, err := url.Parse(*link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := u.Query()
+		if u.Path != "/todos" || q.Get("page") != strconv.Itoa(page) || q.Get("limit") != "10" ||
+			q.Get("status") != "todo" || q.Get("sort") != "-priority" || !reflect.DeepEqual(q["tag"], []string{"work", "home"}) {
+			t.Errorf("Expected a link to page %d keeping the filters, got: %s", page, *link)
+		}
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newPagination(httptest.NewRequest(http.MethodGet, target, nil), tc.total, tc.page, 10)
+
+			if *p.Total != tc.total || *p.TotalPages != tc.expectedTotalPages {
+				t.Errorf("Expected %d items on %d pages, got %d on %d", tc.total, tc.expectedTotalPages, *p.Total, *p.TotalPages)
+			}
+			checkLink(t, p.Next, tc.expectedNext)
+			checkLink(t, p.Prev, tc.expectedPrev)
+		})
+	}
+}
+
+func TestGetTasksPage(t *testing.T) {
+	utils.SetCursorSecret([]byte("secret"))
+	t.Cleanup(func() { utils.SetCursorSecret(nil) })
+
+	TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	th := NewTaskHandler(service.NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger))
+	TaskRepoMock.On("GetTasks", mock.Anything, 1, mock.MatchedBy(func(filter models.TaskFilter) bool {
+		return filter.Status == models.TaskStatusTodo && reflect.DeepEqual(filter.Tags, []string{"work"})
+	}), 2, 2).Return([]models.Task{{ID: 3, UserID: 1, Title: "Call Bob"}, {ID: 4, UserID: 1, Title: "Write report"}}, 5, nil)
+
+	ctx := context.WithValue(context.Background(), models.UserIDKey{}, 1)
+	req := httptest.NewRequest(http.MethodGet, "/todos?status=todo&tag=work&page=2&limit=2", nil).WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	th.GetTasks(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, rr.Code)
+	}
+
+	// Decoded generically to check the payload as clients see it
+	var payload map[string]any
+	if err := json.NewDecoder(rr.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]any{
+		"page":        2.0,
+		"limit":       2.0,
+		"total":       5.0,
+		"total_pages": 3.0,
+		"next":        "/todos?limit=2&page=3&status=todo&tag=work",
+		"prev":        "/todos?limit=2&page=1&status=todo&tag=work",
+	}
+	for key, value := range expected {
+		if payload[key] != value {
+			t.Errorf("Expected %s: %v, got: %v", key, value, payload[key])
+		}
+	}
+	if data, ok := payload["data"].([]any); !ok || len(data) != 2 {
+		t.Errorf("Expected 2 tasks in data, got: %v", payload["data"])
+	}
+	if cursor, ok := payload["next_cursor"].(string); !ok || cursor == "" {
+		t.Errorf("Expected a next_cursor, got: %v", payload["next_cursor"])
+	}
+	if _, ok := payload["deleted"]; ok {
+		t.Errorf("Expected no deleted without updated_since, got: %v", payload["deleted"])
+	}
+}
@@ -2,35 +2,41 @@ package handlers
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/NeGat1FF/todolist-api/internal/models"
 	"github.com/NeGat1FF/todolist-api/internal/service"
-	"github.com/NeGat1FF/todolist-api/internal/utils"
 )
 
 type RegisterUserRequest struct {
-	Username string
-	Email    string
-	Password string
+	Username   string
+	Email      string
+	Password   string
+	TimeZone   string `json:"time_zone"`
+	DeviceName string `json:"device_name"`
+}
+
+type UpdateTimeZoneRequest struct {
+	TimeZone string `json:"time_zone"`
 }
 
 type LoginUserRequest struct {
-	Email    string
-	Password string
+	Email      string
+	Password   string
+	DeviceName string `json:"device_name"`
 }
 
+// userAgentMaxLength is the number of bytes of the User-Agent header kept
+// with a session.
+const userAgentMaxLength = 512
+
 type TokensResponse struct {
 	Token        string
 	RefreshToken string
 }
 
-type RefreshTokenResponse struct {
-	RefreshToken string
-}
-
 type UserHandler struct {
 	ser *service.Service
 }
@@ -39,6 +45,21 @@ func NewUserHandler(ser *service.Service) *UserHandler {
 	return &UserHandler{ser}
 }
 
+// newSession describes the device a login comes from.
+func newSession(r *http.Request, deviceName string) models.Session {
+	userAgent := r.UserAgent()
+	if len(userAgent) > userAgentMaxLength {
+		userAgent = strings.ToValidUTF8(userAgent[:userAgentMaxLength], "")
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return models.Session{DeviceName: deviceName, UserAgent: userAgent, IP: ip}
+}
+
 // RegisterUser godoc
 //
 //	@Summary		Register a new user
@@ -52,7 +73,7 @@ func NewUserHandler(ser *service.Service) *UserHandler {
 func (uh *UserHandler) RegisterUser(rw http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(models.UserKey{}).(models.User)
 
-	tokenString, refreshTokenString, err := uh.ser.RegisterUser(r.Context(), user)
+	tokenString, refreshTokenString, err := uh.ser.RegisterUser(r.Context(), user, newSession(r, user.DeviceName))
 	if err != nil {
 		http.Error(rw, err.Error(), err.(service.ServerError).Code)
 		return
@@ -76,7 +97,7 @@ func (uh *UserHandler) RegisterUser(rw http.ResponseWriter, r *http.Request) {
 func (uh *UserHandler) LoginUser(rw http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(models.UserKey{}).(models.User)
 
-	tokenString, refreshTokenString, err := uh.ser.LoginUser(r.Context(), user)
+	tokenString, refreshTokenString, err := uh.ser.LoginUser(r.Context(), user, newSession(r, user.DeviceName))
 	if err != nil {
 		http.Error(rw, err.Error(), http.StatusExpectationFailed)
 		return
@@ -90,12 +111,12 @@ func (uh *UserHandler) LoginUser(rw http.ResponseWriter, r *http.Request) {
 // RefreshToken godoc
 //
 //	@Summary		Refresh a token
-//	@Description	Refresh a token
+//	@Description	Exchange a refresh token for a new access token and a new refresh token; the old refresh token cannot be used again
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
 //	@Security		Bearer
-//	@Success		200		{object}	RefreshTokenResponse
+//	@Success		200		{object}	TokensResponse
 //	@Router			/users/refresh [post]
 func (uh *UserHandler) RefreshToken(rw http.ResponseWriter, r *http.Request) {
 	tokenString := strings.TrimSpace(strings.Replace(r.Header.Get("Authorization"), "Bearer", "", -1))
@@ -104,29 +125,120 @@ func (uh *UserHandler) RefreshToken(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	claims, err := utils.ValidateJWT(tokenString)
+	token, refreshToken, err := uh.ser.RefreshTokens(r.Context(), tokenString)
 	if err != nil {
-		http.Error(rw, "failed to validate token", http.StatusUnauthorized)
+		ServiceError(rw, err)
 		return
 	}
 
-	var user_id int
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(map[string]any{"token": token, "refreshToken": refreshToken})
+}
+
+// Logout godoc
+//
+//	@Summary		Log out
+//	@Description	Revoke the access token of the request and the refresh tokens of its session
+//	@Tags			users
+//	@Security		Bearer
+//	@Success		204
+//	@Router			/logout [post]
+func (uh *UserHandler) Logout(rw http.ResponseWriter, r *http.Request) {
+	token := r.Context().Value(models.AccessTokenKey{}).(models.AccessToken)
 
-	expTime := claims["exp"].(float64)
-	if time.Now().After(time.Unix(int64(expTime), 0)) {
-		http.Error(rw, "token expired", http.StatusUnauthorized)
+	err := uh.ser.Logout(r.Context(), token)
+	if err != nil {
+		ServiceError(rw, err)
 		return
 	}
-	tokenType, ok := claims["type"].(string)
-	if !ok || tokenType != "refresh" {
-		http.Error(rw, "invalid type of token", http.StatusUnauthorized)
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll godoc
+//
+//	@Summary		Log out everywhere
+//	@Description	Revoke all access and refresh tokens of the user, ending every session
+//	@Tags			users
+//	@Security		Bearer
+//	@Success		204
+//	@Router			/logout/all [post]
+func (uh *UserHandler) LogoutAll(rw http.ResponseWriter, r *http.Request) {
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	err := uh.ser.LogoutAll(r.Context(), user_id)
+	if err != nil {
+		ServiceError(rw, err)
 		return
 	}
-	user_id = int(claims["uid"].(float64))
 
-	newToken := uh.ser.IssueAccessToken(user_id)
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// GetSessions godoc
+//
+//	@Summary		List sessions
+//	@Description	List the devices the user is logged in on, most recently used first
+//	@Tags			users
+//	@Produce		json
+//	@Security		Bearer
+//	@Success		200	{array}	models.Session
+//	@Router			/me/sessions [get]
+func (uh *UserHandler) GetSessions(rw http.ResponseWriter, r *http.Request) {
+	token := r.Context().Value(models.AccessTokenKey{}).(models.AccessToken)
+
+	sessions, err := uh.ser.GetSessions(r.Context(), token)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
 
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusOK)
-	json.NewEncoder(rw).Encode(map[string]any{"token": newToken})
+	json.NewEncoder(rw).Encode(sessions)
+}
+
+// EndSession godoc
+//
+//	@Summary		End a session
+//	@Description	Log the user out of a device, revoking the tokens of the session
+//	@Tags			users
+//	@Security		Bearer
+//	@Param			id	path	string	true	"Session ID"
+//	@Success		204
+//	@Router			/me/sessions/{id} [delete]
+func (uh *UserHandler) EndSession(rw http.ResponseWriter, r *http.Request) {
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	err := uh.ser.EndSession(r.Context(), user_id, r.PathValue("id"))
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateTimeZone godoc
+//
+//	@Summary		Set the user's time zone
+//	@Description	Set the IANA time zone used for due date queries such as due=today
+//	@Tags			users
+//	@Accept			json
+//	@Security		Bearer
+//	@Param			body	body	UpdateTimeZoneRequest	true	"IANA time zone name"
+//	@Success		204
+//	@Router			/me/timezone [put]
+func (uh *UserHandler) UpdateTimeZone(rw http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(models.UserKey{}).(models.User)
+	user_id := r.Context().Value(models.UserIDKey{}).(int)
+
+	err := uh.ser.UpdateTimeZone(r.Context(), user_id, user.TimeZone)
+	if err != nil {
+		ServiceError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
 }
@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -12,7 +14,7 @@ import (
 
 func AuthUserMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
-		tokenString := strings.TrimSpace(strings.Replace(r.Header.Get("Authorization"), "Bearer", "", -1))
+		tokenString := bearerToken(r)
 		if tokenString == "" {
 			http.Error(rw, "no authorization token", http.StatusUnauthorized)
 			return
@@ -42,9 +44,94 @@ func AuthUserMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		// Tokens issued before logouts were introduced carry no jti, sid and ver
+		token := models.AccessToken{UserID: int(user_id), ExpiresAt: time.Unix(int64(expTime), 0)}
+		token.JTI, _ = claims["jti"].(string)
+		token.SessionID, _ = claims["sid"].(string)
+		if version, ok := claims["ver"].(float64); ok {
+			token.Version = int(version)
+		}
+
 		ctx := context.WithValue(r.Context(), models.UserIDKey{}, int(user_id))
+		ctx = context.WithValue(ctx, models.AccessTokenKey{}, token)
 		r = r.WithContext(ctx)
 
 		next.ServeHTTP(rw, r)
 	}
 }
+
+// TokenChecker reports models.ErrTokenRevoked for access tokens that have
+// been revoked and models.ErrInvalidAPIKey for unknown or expired API keys.
+type TokenChecker interface {
+	CheckAccessToken(ctx context.Context, token models.AccessToken) error
+	CheckAPIKey(ctx context.Context, key string) (models.APIKey, error)
+}
+
+// Auth authenticates requests like AuthUserMiddleware and rejects revoked
+// access tokens. Routes behind Require also accept API keys with the scope.
+type Auth struct {
+	checker TokenChecker
+}
+
+func NewAuth(checker TokenChecker) *Auth {
+	return &Auth{checker: checker}
+}
+
+// Middleware only accepts access tokens, for routes API keys must not reach
+// such as managing the account.
+func (a *Auth) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return a.Require("", next)
+}
+
+// Require accepts access tokens, which carry every scope, and API keys given
+// scope. An empty scope only accepts access tokens.
+func (a *Auth) Require(scope string, next http.HandlerFunc) http.HandlerFunc {
+	checkToken := AuthUserMiddleware(func(rw http.ResponseWriter, r *http.Request) {
+		token := r.Context().Value(models.AccessTokenKey{}).(models.AccessToken)
+
+		err := a.checker.CheckAccessToken(r.Context(), token)
+		if errors.Is(err, models.ErrTokenRevoked) {
+			http.Error(rw, "token revoked", http.StatusUnauthorized)
+			return
+		} else if err != nil {
+			http.Error(rw, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		keyString := bearerToken(r)
+		if !strings.HasPrefix(keyString, models.APIKeyPrefix) {
+			checkToken(rw, r)
+			return
+		}
+
+		key, err := a.checker.CheckAPIKey(r.Context(), keyString)
+		if errors.Is(err, models.ErrInvalidAPIKey) {
+			http.Error(rw, "invalid API key", http.StatusUnauthorized)
+			return
+		} else if err != nil {
+			http.Error(rw, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if scope == "" {
+			http.Error(rw, "API keys cannot be used here", http.StatusForbidden)
+			return
+		}
+		if !key.HasScope(scope) {
+			http.Error(rw, fmt.Sprintf("API key lacks the %s scope", scope), http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), models.UserIDKey{}, key.UserID)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rw, r)
+	}
+}
+
+func bearerToken(r *http.Request) string {
+	return strings.TrimSpace(strings.Replace(r.Header.Get("Authorization"), "Bearer", "", -1))
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/NeGat1FF/todolist-api/internal/repository"
+)
+
+// Idempotency replays the stored response to a request retried with the same
+// Idempotency-Key header, so that a retried POST does not run twice. Keys
+// belong to a user and expire after ttl. A key whose request has not been
+// answered within lockTimeout, e.g. because the server crashed, can be used
+// again.
+type Idempotency struct {
+	rep         repository.IdempotencyRepositoryInterface
+	ttl         time.Duration
+	lockTimeout time.Duration
+	logger      *log.Logger
+}
+
+func NewIdempotency(rep repository.IdempotencyRepositoryInterface, ttl, lockTimeout time.Duration, logger *log.Logger) *Idempotency {
+	return &Idempotency{rep: rep, ttl: ttl, lockTimeout: lockTimeout, logger: logger}
+}
+
+// Middleware must run after AuthUserMiddleware. Requests without an
+// Idempotency-Key header are passed through.
+func (id *Idempotency) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		if len(key) > models.IdempotencyKeyMaxLength {
+			http.Error(rw, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(rw, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		user_id := r.Context().Value(models.UserIDKey{}).(int)
+		hash := requestHash(r, body)
+		stored, reserved, err := id.rep.ReserveKey(r.Context(), models.IdempotencyKey{
+			UserID:      user_id,
+			Key:         key,
+			RequestHash: hash,
+			ExpiresAt:   time.Now().Add(id.ttl),
+			LockedUntil: time.Now().Add(id.lockTimeout),
+		})
+		if err != nil {
+			id.logger.Print(err)
+			http.Error(rw, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case stored.RequestHash != hash:
+				http.Error(rw, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+			case stored.StatusCode == 0:
+				http.Error(rw, "a request with this Idempotency-Key is still in progress", http.StatusConflict)
+			default:
+				for name, values := range stored.ResponseHeader {
+					rw.Header()[name] = values
+				}
+				rw.Header().Set("Idempotent-Replayed", "true")
+				rw.WriteHeader(stored.StatusCode)
+				rw.Write(stored.ResponseBody)
+			}
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// The response is stored even if the client has gone away meanwhile,
+		// except for server errors so that the request can be retried
+		ctx := context.WithoutCancel(r.Context())
+		if rec.status >= http.StatusInternalServerError {
+			err = id.rep.DeleteKey(ctx, user_id, key)
+		} else {
+			err = id.rep.SaveResponse(ctx, user_id, key, rec.status, rec.header, rec.body.Bytes())
+		}
+		if err != nil {
+			id.logger.Print(err)
+		}
+	}
+}
+
+// requestHash identifies a request by its method, URL and body.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder writes a response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	header      http.Header
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status, rec.header, rec.wroteHeader = status, rec.Header().Clone(), true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
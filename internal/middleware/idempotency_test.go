@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/NeGat1FF/todolist-api/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIdempotency(t *testing.T) {
+	body := `{"title": "Buy milk", "description": "2 litres"}`
+	req := httptest.NewRequest("POST", "/todos", nil)
+	hash := requestHash(req, []byte(body))
+
+	testCases := []struct {
+		name           string
+		key            string
+		body           string
+		handlerStatus  int
+		mockSetup      func(rep *mocks.IdempotencyRepositoryInterface)
+		expectedCode   int
+		expectedCalled bool
+		expectedBody   string
+	}{
+		{
+			name:           "No key",
+			handlerStatus:  http.StatusAccepted,
+			mockSetup:      func(rep *mocks.IdempotencyRepositoryInterface) {},
+			expectedCode:   http.StatusAccepted,
+			expectedCalled: true,
+		},
+		{
+			name:          "First request",
+			key:           "a",
+			handlerStatus: http.StatusAccepted,
+			mockSetup: func(rep *mocks.IdempotencyRepositoryInterface) {
+				rep.On("ReserveKey", mock.Anything, mock.MatchedBy(func(key models.IdempotencyKey) bool {
+					return key.UserID == 1 && key.Key == "a" && key.RequestHash == hash && time.Until(key.ExpiresAt) > 23*time.Hour &&
+						time.Until(key.LockedUntil) > 59*time.Second && time.Until(key.LockedUntil) <= time.Minute
+				})).Return(models.IdempotencyKey{}, true, nil)
+				rep.On("SaveResponse", mock.Anything, 1, "a", http.StatusAccepted, mock.MatchedBy(func(header http.Header) bool {
+					return header.Get("Content-Type") == "application/json"
+				}), []byte(`{"id":1}`)).Return(nil)
+			},
+			expectedCode:   http.StatusAccepted,
+			expectedCalled: true,
+			expectedBody:   `{"id":1}`,
+		},
+		{
+			name: "Retried request",
+			key:  "a",
+			mockSetup: func(rep *mocks.IdempotencyRepositoryInterface) {
+				rep.On("ReserveKey", mock.Anything, mock.Anything).Return(models.IdempotencyKey{
+					RequestHash:    hash,
+					StatusCode:     http.StatusAccepted,
+					ResponseHeader: http.Header{"Content-Type": {"application/json"}},
+					ResponseBody:   []byte(`{"id":1}`),
+				}, false, nil)
+			},
+			expectedCode: http.StatusAccepted,
+			expectedBody: `{"id":1}`,
+		},
+		{
+			name: "Key reused with a different body",
+			key:  "a",
+			body: `{"title": "Buy bread", "description": "1 loaf"}`,
+			mockSetup: func(rep *mocks.IdempotencyRepositoryInterface) {
+				rep.On("ReserveKey", mock.Anything, mock.Anything).Return(models.IdempotencyKey{RequestHash: hash, StatusCode: http.StatusAccepted}, false, nil)
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "Request still in progress",
+			key:  "a",
+			mockSetup: func(rep *mocks.IdempotencyRepositoryInterface) {
+				rep.On("ReserveKey", mock.Anything, mock.Anything).Return(models.IdempotencyKey{RequestHash: hash}, false, nil)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:          "Server error releases the key",
+			key:           "a",
+			handlerStatus: http.StatusInternalServerError,
+			mockSetup: func(rep *mocks.IdempotencyRepositoryInterface) {
+				rep.On("ReserveKey", mock.Anything, mock.Anything).Return(models.IdempotencyKey{}, true, nil)
+				rep.On("DeleteKey", mock.Anything, 1, "a").Return(nil)
+			},
+			expectedCode:   http.StatusInternalServerError,
+			expectedCalled: true,
+		},
+		{
+			name:         "Key too long",
+			key:          string(bytes.Repeat([]byte("a"), models.IdempotencyKeyMaxLength+1)),
+			mockSetup:    func(rep *mocks.IdempotencyRepositoryInterface) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			rep := mocks.NewIdempotencyRepositoryInterface(t)
+			test.mockSetup(rep)
+
+			called := false
+			next := func(rw http.ResponseWriter, r *http.Request) {
+				called = true
+				if b, _ := io.ReadAll(r.Body); string(b) != body {
+					t.Errorf("expected the handler to read the body, got: %s", b)
+				}
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(test.handlerStatus)
+				if test.handlerStatus < http.StatusInternalServerError {
+					rw.Write([]byte(`{"id":1}`))
+				}
+			}
+
+			reqBody := body
+			if test.body != "" {
+				reqBody = test.body
+			}
+			req := httptest.NewRequest("POST", "/todos", bytes.NewBufferString(reqBody))
+			req = req.WithContext(context.WithValue(req.Context(), models.UserIDKey{}, 1))
+			if test.key != "" {
+				req.Header.Set("Idempotency-Key", test.key)
+			}
+			rw := httptest.NewRecorder()
+
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+			NewIdempotency(rep, 24*time.Hour, time.Minute, logger).Middleware(next)(rw, req)
+
+			if rw.Code != test.expectedCode {
+				t.Errorf("expected status code %d, but got: %d", test.expectedCode, rw.Code)
+			}
+			if called != test.expectedCalled {
+				t.Errorf("expected handler called: %v, but got: %v", test.expectedCalled, called)
+			}
+			if test.expectedBody != "" && rw.Body.String() != test.expectedBody {
+				t.Errorf("expected body %s, but got: %s", test.expectedBody, rw.Body.String())
+			}
+		})
+	}
+}
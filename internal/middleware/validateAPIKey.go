@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+)
+
+func ValidateAPIKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var body models.APIKey
+
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			http.Error(rw, "failed to parse body", http.StatusBadRequest)
+			return
+		}
+
+		// Only the name, scopes and expiry are chosen by the user
+		key := models.APIKey{Name: strings.TrimSpace(body.Name), ExpiresAt: body.ExpiresAt}
+		for _, scope := range body.Scopes {
+			if !models.APIKeyScopes[scope] {
+				http.Error(rw, fmt.Sprintf("unknown scope %q", scope), http.StatusBadRequest)
+				return
+			}
+			if !slices.Contains(key.Scopes, scope) {
+				key.Scopes = append(key.Scopes, scope)
+			}
+		}
+
+		switch {
+		case key.Name == "":
+			http.Error(rw, "API key name is not specified", http.StatusBadRequest)
+			return
+		case utf8.RuneCountInString(key.Name) > models.APIKeyNameMaxLength:
+			http.Error(rw, fmt.Sprintf("API key name cannot be longer than %d characters", models.APIKeyNameMaxLength), http.StatusBadRequest)
+			return
+		case len(key.Scopes) == 0:
+			http.Error(rw, "at least one scope is required", http.StatusBadRequest)
+			return
+		case key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()):
+			http.Error(rw, "expiry must be in the future", http.StatusBadRequest)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), models.APIKeyKey{}, key)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rw, r)
+	}
+}
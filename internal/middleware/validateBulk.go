@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+)
+
+func ValidateBulk(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var req models.BulkRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(rw, "failed to parse body", http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case len(req.Operations) == 0:
+			http.Error(rw, "at least one operation is required", http.StatusBadRequest)
+			return
+		case len(req.Operations) > models.BulkMaxOperations:
+			http.Error(rw, fmt.Sprintf("at most %d operations can be run at once", models.BulkMaxOperations), http.StatusBadRequest)
+			return
+		}
+
+		for i := range req.Operations {
+			if err := validateBulkOperation(&req.Operations[i]); err != nil {
+				http.Error(rw, fmt.Sprintf("operation %d: %s", i, err), http.StatusBadRequest)
+				return
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), models.BulkKey{}, req)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rw, r)
+	}
+}
+
+// validateBulkOperation checks a single operation and decodes its fields into
+// op.Task.
+func validateBulkOperation(op *models.BulkOperation) error {
+	switch op.Op {
+	case models.BulkCreate:
+		if op.ID != 0 || op.Version != 0 {
+			return errors.New("a created task cannot have an id or version")
+		}
+	case models.BulkUpdate, models.BulkDelete, models.BulkComplete:
+		if op.ID <= 0 {
+			return errors.New("invalid id")
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	if op.Op != models.BulkCreate && op.Op != models.BulkUpdate {
+		if len(op.Fields) > 0 {
+			return fmt.Errorf("%s operation cannot change fields", op.Op)
+		}
+		if op.Op == models.BulkComplete && op.Version != 0 {
+			return errors.New("complete operation cannot have a version")
+		}
+		return nil
+	}
+
+	if len(op.Fields) == 0 {
+		return errors.New("task is not specified")
+	}
+	if err := json.Unmarshal(op.Fields, &op.Task); err != nil {
+		var perr *time.ParseError
+		if errors.As(err, &perr) {
+			return errors.New("invalid date, expected RFC 3339 timestamp")
+		}
+		return errors.New("failed to parse task")
+	}
+
+	return checkTaskInput(&op.Task, op.Op == models.BulkCreate)
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+)
+
+func ValidateList(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var list models.List
+
+		err := json.NewDecoder(r.Body).Decode(&list)
+		if err != nil {
+			http.Error(rw, "failed to parse body", http.StatusBadRequest)
+			return
+		}
+
+		list.Name = strings.TrimSpace(list.Name)
+		switch {
+		case list.Name == "":
+			http.Error(rw, "list name is not specified", http.StatusBadRequest)
+			return
+		case utf8.RuneCountInString(list.Name) > 255:
+			http.Error(rw, "list name is too long", http.StatusBadRequest)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), models.ListKey{}, list)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rw, r)
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+)
+
+func ValidateReminder(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var reminder models.Reminder
+
+		err := json.NewDecoder(r.Body).Decode(&reminder)
+		if err != nil {
+			var perr *time.ParseError
+			if errors.As(err, &perr) {
+				http.Error(rw, "invalid date, expected RFC 3339 timestamp", http.StatusBadRequest)
+				return
+			}
+			http.Error(rw, "failed to parse body", http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case (reminder.RemindAt == nil) == (reminder.OffsetMinutes == nil):
+			http.Error(rw, "either remind_at or offset_minutes is required", http.StatusBadRequest)
+			return
+		case reminder.OffsetMinutes != nil && (*reminder.OffsetMinutes < 0 || *reminder.OffsetMinutes > models.ReminderMaxOffset):
+			http.Error(rw, "offset_minutes is out of range", http.StatusBadRequest)
+			return
+		}
+
+		// Delivery state is maintained by the server
+		reminder.ID, reminder.SentAt, reminder.Attempts = 0, nil, 0
+
+		ctx := context.WithValue(r.Context(), models.ReminderKey{}, reminder)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rw, r)
+	}
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/NeGat1FF/todolist-api/internal/utils"
+)
+
+func ValidateSync(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var req models.SyncRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(rw, "failed to parse body", http.StatusBadRequest)
+			return
+		}
+
+		switch req.Strategy {
+		case "":
+			req.Strategy = models.SyncStrategyReport
+		case models.SyncStrategyReport, models.SyncStrategyLastWriterWins:
+		default:
+			http.Error(rw, "invalid conflict strategy", http.StatusBadRequest)
+			return
+		}
+
+		if req.SyncToken != "" {
+			var token models.SyncToken
+			if err := utils.ParseCursor(req.SyncToken, &token); err != nil {
+				http.Error(rw, "invalid sync token", http.StatusBadRequest)
+				return
+			}
+			req.Since = token.XID
+		}
+
+		if len(req.Changes) > models.SyncMaxChanges {
+			http.Error(rw, fmt.Sprintf("at most %d changes can be synced at once", models.SyncMaxChanges), http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		for i := range req.Changes {
+			if err := validateTaskChange(&req.Changes[i], now); err != nil {
+				http.Error(rw, fmt.Sprintf("change %d: %s", i, err), http.StatusBadRequest)
+				return
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), models.SyncKey{}, req)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rw, r)
+	}
+}
+
+// validateTaskChange checks a single change and decodes its fields into
+// change.Task.
+func validateTaskChange(change *models.TaskChange, now time.Time) error {
+	switch {
+	case change.ID < 0:
+		return errors.New("invalid id")
+	case change.ID == 0 && change.ClientID == "":
+		return errors.New("either id or client_id is required")
+	case change.ID == 0 && change.Deleted:
+		return errors.New("only existing tasks can be deleted")
+	case change.ID != 0 && change.BaseVersion <= 0:
+		return errors.New("base_version is required")
+	case change.Deleted && len(change.Fields) > 0:
+		return errors.New("a deletion cannot change fields")
+	case !change.Deleted && len(change.Fields) == 0:
+		return errors.New("at least one field is required")
+	}
+
+	// Clients cannot win every conflict by claiming changes from the future
+	if change.ChangedAt.IsZero() || change.ChangedAt.After(now) {
+		change.ChangedAt = now
+	}
+
+	if change.Deleted {
+		return nil
+	}
+
+	for field := range change.Fields {
+		if !models.TaskSyncFields[field] {
+			return fmt.Errorf("field %s cannot be synced", field)
+		}
+	}
+
+	raw, err := json.Marshal(change.Fields)
+	if err != nil {
+		return errors.New("failed to parse fields")
+	}
+	if err := json.Unmarshal(raw, &change.Task); err != nil {
+		var perr *time.ParseError
+		if errors.As(err, &perr) {
+			return errors.New("invalid date, expected RFC 3339 timestamp")
+		}
+		return errors.New("failed to parse fields")
+	}
+
+	if err := change.Task.Normalize(); err != nil {
+		return err
+	}
+
+	// Fields that are sent must hold a value where the column requires one
+	_, hasTitle := change.Fields["title"]
+	if (hasTitle || change.ID == 0) && change.Task.Title == "" {
+		return errors.New("task title is not specified")
+	}
+	if change.ID == 0 && change.Task.Description == "" {
+		return errors.New("task description is not specified")
+	}
+	if _, ok := change.Fields["status"]; ok && change.Task.Status == "" {
+		return errors.New("invalid task status")
+	}
+
+	return nil
+}
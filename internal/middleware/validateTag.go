@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+)
+
+func ValidateTag(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var tag models.Tag
+
+		err := json.NewDecoder(r.Body).Decode(&tag)
+		if err != nil {
+			http.Error(rw, "failed to parse body", http.StatusBadRequest)
+			return
+		}
+
+		tag.Name, err = models.NormalizeTagName(tag.Name)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), models.TagKey{}, tag)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rw, r)
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"time"
 
 	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/NeGat1FF/todolist-api/internal/utils"
 )
 
 func validateTask(r *http.Request, requireBoth bool) (models.Task, error) {
@@ -14,23 +18,46 @@ func validateTask(r *http.Request, requireBoth bool) (models.Task, error) {
 
 	err := json.NewDecoder(r.Body).Decode(&task)
 	if err != nil {
+		var perr *time.ParseError
+		if errors.As(err, &perr) {
+			return task, errors.New("invalid date, expected RFC 3339 timestamp")
+		}
 		return task, errors.New("failed to parse body")
 	}
 
+	return task, checkTaskInput(&task, requireBoth)
+}
+
+// checkTaskInput validates a task sent for creation (requireBoth) or update.
+func checkTaskInput(task *models.Task, requireBoth bool) error {
+	// IDs, progress, nested subtasks, versions, timestamps, the archive and
+	// the trash state are maintained by the server
+	task.ID = 0
+	task.SubtasksTotal, task.SubtasksDone, task.Subtasks = 0, 0, nil
+	task.Version, task.CreatedAt, task.UpdatedAt = 0, time.Time{}, time.Time{}
+	task.ArchivedAt, task.DeletedAt = nil, nil
+
+	if err := task.Normalize(); err != nil {
+		return err
+	}
+
 	if requireBoth {
 		if task.Title == "" {
-			return task, errors.New("task title is not specified")
+			return errors.New("task title is not specified")
 		}
 		if task.Description == "" {
-			return task, errors.New("task description is not specified")
+			return errors.New("task description is not specified")
 		}
 	} else {
-		if task.Title == "" && task.Description == "" {
-			return task, errors.New("it least one field is required")
+		if task.Title == "" && task.Description == "" && task.Status == "" &&
+			task.StartAt == nil && task.DueAt == nil && task.Priority == models.TaskPriorityNone &&
+			task.Tags == nil && task.ListID == nil &&
+			task.ParentID == nil && task.RRule == "" {
+			return errors.New("it least one field is required")
 		}
 	}
 
-	return task, nil
+	return nil
 }
 
 func ValidateAddTask(next http.HandlerFunc) http.HandlerFunc {
@@ -60,3 +87,94 @@ func ValidateUpdateTask(next http.HandlerFunc) http.HandlerFunc {
 		next.ServeHTTP(rw, r)
 	}
 }
+
+// ValidateMoveTask accepts {"list_id": <id>} or {"list_id": null} for the inbox.
+func ValidateMoveTask(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var task models.Task
+
+		err := json.NewDecoder(r.Body).Decode(&task)
+		if err != nil {
+			http.Error(rw, "failed to parse body", http.StatusBadRequest)
+			return
+		}
+
+		if task.ListID != nil && *task.ListID <= 0 {
+			http.Error(rw, "invalid list id", http.StatusBadRequest)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), models.TaskKey{}, models.Task{ListID: task.ListID})
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rw, r)
+	}
+}
+
+// ValidatePatchTask accepts a JSON Merge Patch or a JSON Patch of the fields
+// listed in models.TaskSyncFields, told apart by the Content-Type header.
+func ValidatePatchTask(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var patch models.TaskPatch
+
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case models.PatchMergeType:
+			if err := json.NewDecoder(r.Body).Decode(&patch.Merge); err != nil || patch.Merge == nil {
+				http.Error(rw, "failed to parse body", http.StatusBadRequest)
+				return
+			}
+			if len(patch.Merge) == 0 {
+				http.Error(rw, "at least one field is required", http.StatusBadRequest)
+				return
+			}
+			for field := range patch.Merge {
+				if !models.TaskSyncFields[field] {
+					http.Error(rw, fmt.Sprintf("field %s cannot be patched", field), http.StatusBadRequest)
+					return
+				}
+			}
+		case models.PatchJSONType:
+			if err := json.NewDecoder(r.Body).Decode(&patch.Ops); err != nil {
+				http.Error(rw, "failed to parse body", http.StatusBadRequest)
+				return
+			}
+			if len(patch.Ops) == 0 {
+				http.Error(rw, "at least one operation is required", http.StatusBadRequest)
+				return
+			}
+			for i, op := range patch.Ops {
+				if err := checkPatchOperation(op); err != nil {
+					http.Error(rw, fmt.Sprintf("operation %d: %s", i, err), http.StatusBadRequest)
+					return
+				}
+			}
+		default:
+			http.Error(rw, fmt.Sprintf("content type must be %s or %s", models.PatchMergeType, models.PatchJSONType), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), models.TaskPatchKey{}, patch)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rw, r)
+	}
+}
+
+// checkPatchOperation checks an operation only touches the fields a client
+// may patch.
+func checkPatchOperation(op utils.PatchOperation) error {
+	if err := op.Check(); err != nil {
+		return err
+	}
+
+	paths := []string{op.Path}
+	if op.Op == utils.PatchMove || op.Op == utils.PatchCopy {
+		paths = append(paths, op.From)
+	}
+	for _, path := range paths {
+		tokens, _ := utils.ParsePointer(path)
+		if len(tokens) == 0 || !models.TaskSyncFields[tokens[0]] {
+			return fmt.Errorf("path %q cannot be patched", path)
+		}
+	}
+	return nil
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"regexp"
+	"time"
+	"unicode/utf8"
 
 	"github.com/NeGat1FF/todolist-api/internal/models"
 )
@@ -20,6 +23,9 @@ func validateUser(r *http.Request, checkUsername bool) (models.User, error) {
 		return user, errors.New("failed to parse request body")
 	}
 
+	// Timestamps are maintained by the database
+	user.CreatedAt, user.UpdatedAt = time.Time{}, time.Time{}
+
 	regex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 
 	switch {
@@ -31,6 +37,10 @@ func validateUser(r *http.Request, checkUsername bool) (models.User, error) {
 		return user, errors.New("email is not specified")
 	case !regex.MatchString(user.Email):
 		return user, errors.New("invalid email address")
+	case user.TimeZone != "" && !isValidTimeZone(user.TimeZone):
+		return user, errors.New("invalid time zone")
+	case utf8.RuneCountInString(user.DeviceName) > models.SessionDeviceNameMaxLength:
+		return user, fmt.Errorf("device name cannot be longer than %d characters", models.SessionDeviceNameMaxLength)
 	}
 
 	return user, nil
@@ -63,3 +73,33 @@ func ValidateLogin(next http.HandlerFunc) http.HandlerFunc {
 		next.ServeHTTP(rw, r)
 	}
 }
+
+func isValidTimeZone(name string) bool {
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+func ValidateTimeZone(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var user models.User
+
+		err := json.NewDecoder(r.Body).Decode(&user)
+		if err != nil {
+			http.Error(rw, "failed to parse request body", http.StatusBadRequest)
+			return
+		}
+
+		if user.TimeZone == "" {
+			http.Error(rw, "time zone is not specified", http.StatusBadRequest)
+			return
+		}
+		if !isValidTimeZone(user.TimeZone) {
+			http.Error(rw, "invalid time zone", http.StatusBadRequest)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), models.UserKey{}, user)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rw, r)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/NeGat1FF/todolist-api/internal/models"
 )
@@ -15,6 +17,9 @@ func TestValidateAddTask(t *testing.T) {
 		rw.WriteHeader(http.StatusOK)
 	}
 
+	startAt := time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC)
+	dueAt := time.Date(2024, 10, 3, 17, 0, 0, 0, time.UTC)
+
 	testCases := []struct {
 		name         string
 		task         models.Task
@@ -47,6 +52,81 @@ func TestValidateAddTask(t *testing.T) {
 			task:         models.Task{},
 			expectedCode: http.StatusBadRequest,
 		},
+		{
+			name: "Invalid status",
+			task: models.Task{
+				Title:       "Test task",
+				Description: "This is description of test task",
+				Status:      "finished",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "Start after due",
+			task: models.Task{
+				Title:       "Test task",
+				Description: "This is description of test task",
+				StartAt:     &dueAt,
+				DueAt:       &startAt,
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "Priority out of range",
+			task: models.Task{
+				Title:       "Test task",
+				Description: "This is description of test task",
+				Priority:    5,
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "Tags",
+			task: models.Task{
+				Title:       "Test task",
+				Description: "This is description of test task",
+				Tags:        []models.Tag{{Name: "work"}, {Name: " urgent "}},
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "Empty tag name",
+			task: models.Task{
+				Title:       "Test task",
+				Description: "This is description of test task",
+				Tags:        []models.Tag{{Name: "  "}},
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "Start and due dates",
+			task: models.Task{
+				Title:       "Test task",
+				Description: "This is description of test task",
+				StartAt:     &startAt,
+				DueAt:       &dueAt,
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "Recurrence rule",
+			task: models.Task{
+				Title:       "Test task",
+				Description: "This is description of test task",
+				DueAt:       &dueAt,
+				RRule:       "RRULE:freq=weekly;byday=MO,TH",
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "Invalid recurrence rule",
+			task: models.Task{
+				Title:       "Test task",
+				Description: "This is description of test task",
+				RRule:       "FREQ=HOURLY",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
 	}
 
 	for _, test := range testCases {
@@ -76,6 +156,26 @@ func TestValidateAddTask(t *testing.T) {
 	}
 }
 
+func TestValidateAddTaskClearsServerFields(t *testing.T) {
+	var got models.Task
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		got = r.Context().Value(models.TaskKey{}).(models.Task)
+	}
+
+	body := `{"id": 42, "title": "Test task", "description": "Test", "version": 3, "subtasks_total": 2}`
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	ValidateAddTask(next)(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, but got: %d", http.StatusOK, rr.Code)
+	}
+	if got.ID != 0 || got.Version != 0 || got.SubtasksTotal != 0 {
+		t.Errorf("expected server fields to be cleared, got: %+v", got)
+	}
+}
+
 func TestValidateUpdateTask(t *testing.T) {
 	next := func(rw http.ResponseWriter, r *http.Request) {
 		rw.WriteHeader(http.StatusOK)
@@ -113,6 +213,34 @@ func TestValidateUpdateTask(t *testing.T) {
 			task:         models.Task{},
 			expectedCode: http.StatusBadRequest,
 		},
+		{
+			name: "Only tags",
+			task: models.Task{
+				Tags: []models.Tag{{Name: "work"}},
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "Clear tags",
+			task: models.Task{
+				Tags: []models.Tag{},
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "Only status",
+			task: models.Task{
+				Status: models.TaskStatusInProgress,
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "Invalid status",
+			task: models.Task{
+				Status: "finished",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
 	}
 
 	for _, test := range testCases {
@@ -170,6 +298,16 @@ func TestValidateRegistration(t *testing.T) {
 			},
 			expectedCode: http.StatusBadRequest,
 		},
+		{
+			name: "Device name too long",
+			user: models.User{
+				Username:   "TestUser",
+				Email:      "exampleEmail@test.com",
+				Password:   "Password",
+				DeviceName: strings.Repeat("a", models.SessionDeviceNameMaxLength+1),
+			},
+			expectedCode: http.StatusBadRequest,
+		},
 		{
 			name: "No Username",
 			user: models.User{
@@ -199,6 +337,26 @@ func TestValidateRegistration(t *testing.T) {
 			user:         models.User{},
 			expectedCode: http.StatusBadRequest,
 		},
+		{
+			name: "Valid time zone",
+			user: models.User{
+				Username: "TestUser",
+				Email:    "exampleEmail@test.com",
+				Password: "Password",
+				TimeZone: "Europe/Kyiv",
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "Invalid time zone",
+			user: models.User{
+				Username: "TestUser",
+				Email:    "exampleEmail@test.com",
+				Password: "Password",
+				TimeZone: "Mars/Olympus_Mons",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
 	}
 
 	for _, test := range testCases {
@@ -227,3 +385,433 @@ func TestValidateRegistration(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateTag(t *testing.T) {
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	testCases := []struct {
+		name         string
+		tag          models.Tag
+		expectedCode int
+	}{
+		{
+			name:         "Valid name",
+			tag:          models.Tag{Name: "work"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Empty name",
+			tag:          models.Tag{Name: " "},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Too long name",
+			tag:          models.Tag{Name: strings.Repeat("a", models.TagNameMaxLength+1)},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(ValidateTag(next))
+			defer server.Close()
+
+			data, err := json.Marshal(&test.tag)
+			if err != nil {
+				t.Error(err)
+			}
+
+			req, err := http.NewRequest("POST", server.URL, bytes.NewBuffer(data))
+			if err != nil {
+				t.Error(err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if resp.StatusCode != test.expectedCode {
+				t.Errorf("expected status code %d, but got: %d", test.expectedCode, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestValidateReminder(t *testing.T) {
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	remindAt := time.Date(2024, 10, 8, 9, 0, 0, 0, time.UTC)
+	offset, negative, tooLong := 30, -5, models.ReminderMaxOffset+1
+
+	testCases := []struct {
+		name         string
+		reminder     models.Reminder
+		expectedCode int
+	}{
+		{
+			name:         "Absolute time",
+			reminder:     models.Reminder{RemindAt: &remindAt},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Offset from due date",
+			reminder:     models.Reminder{OffsetMinutes: &offset},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Not fields",
+			reminder:     models.Reminder{},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Both time and offset",
+			reminder:     models.Reminder{RemindAt: &remindAt, OffsetMinutes: &offset},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Negative offset",
+			reminder:     models.Reminder{OffsetMinutes: &negative},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Too long offset",
+			reminder:     models.Reminder{OffsetMinutes: &tooLong},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(ValidateReminder(next))
+			defer server.Close()
+
+			data, err := json.Marshal(&test.reminder)
+			if err != nil {
+				t.Error(err)
+			}
+
+			req, err := http.NewRequest("POST", server.URL, bytes.NewBuffer(data))
+			if err != nil {
+				t.Error(err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if resp.StatusCode != test.expectedCode {
+				t.Errorf("expected status code %d, but got: %d", test.expectedCode, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestValidateSync(t *testing.T) {
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	testCases := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{
+			name:         "Initial sync",
+			body:         `{}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Create, update and delete",
+			body:         `{"strategy": "last_writer_wins", "changes": [{"client_id": "a", "fields": {"title": "Buy milk", "description": "2 litres"}}, {"id": 1, "base_version": 3, "fields": {"due_at": null, "tags": [{"name": "home"}]}}, {"id": 2, "base_version": 1, "deleted": true}]}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Invalid strategy",
+			body:         `{"strategy": "first_writer_wins"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Forged sync token",
+			body:         `{"sync_token": "eyJ4IjoxfQ.AAAA"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Update without base version",
+			body:         `{"changes": [{"id": 1, "fields": {"title": "Buy milk"}}]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Create without title",
+			body:         `{"changes": [{"client_id": "a", "fields": {"description": "2 litres"}}]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Field that cannot be synced",
+			body:         `{"changes": [{"id": 1, "base_version": 1, "fields": {"completed_at": null}}]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Clearing the status",
+			body:         `{"changes": [{"id": 1, "base_version": 1, "fields": {"status": null}}]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Deletion with fields",
+			body:         `{"changes": [{"id": 1, "base_version": 1, "deleted": true, "fields": {"title": "x"}}]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(ValidateSync(next))
+			defer server.Close()
+
+			req, err := http.NewRequest("POST", server.URL, bytes.NewBufferString(test.body))
+			if err != nil {
+				t.Error(err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if resp.StatusCode != test.expectedCode {
+				t.Errorf("expected status code %d, but got: %d", test.expectedCode, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestValidatePatchTask(t *testing.T) {
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	testCases := []struct {
+		name         string
+		contentType  string
+		body         string
+		expectedCode int
+	}{
+		{
+			name:         "Merge patch",
+			contentType:  "application/merge-patch+json",
+			body:         `{"description": null, "priority": 2, "tags": [{"name": "home"}]}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "JSON patch",
+			contentType:  "application/json-patch+json; charset=utf-8",
+			body:         `[{"op": "test", "path": "/status", "value": "todo"}, {"op": "remove", "path": "/due_at"}, {"op": "add", "path": "/tags/-", "value": {"name": "home"}}]`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Plain JSON",
+			contentType:  "application/json",
+			body:         `{"description": null}`,
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:         "Empty merge patch",
+			contentType:  "application/merge-patch+json",
+			body:         `{}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Merge patch of a server field",
+			contentType:  "application/merge-patch+json",
+			body:         `{"version": 7}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Merge patch that is not an object",
+			contentType:  "application/merge-patch+json",
+			body:         `null`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "JSON patch of a server field",
+			contentType:  "application/json-patch+json",
+			body:         `[{"op": "replace", "path": "/completed_at", "value": null}]`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "JSON patch copying a server field",
+			contentType:  "application/json-patch+json",
+			body:         `[{"op": "copy", "from": "/created_at", "path": "/due_at"}]`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "JSON patch of the whole task",
+			contentType:  "application/json-patch+json",
+			body:         `[{"op": "replace", "path": "", "value": {}}]`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "JSON patch without value",
+			contentType:  "application/json-patch+json",
+			body:         `[{"op": "replace", "path": "/title"}]`,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(ValidatePatchTask(next))
+			defer server.Close()
+
+			req, err := http.NewRequest("PATCH", server.URL, bytes.NewBufferString(test.body))
+			if err != nil {
+				t.Error(err)
+			}
+			req.Header.Set("Content-Type", test.contentType)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if resp.StatusCode != test.expectedCode {
+				t.Errorf("expected status code %d, but got: %d", test.expectedCode, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestValidateBulk(t *testing.T) {
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	testCases := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{
+			name:         "All operations",
+			body:         `{"atomic": true, "operations": [{"op": "create", "task": {"title": "Buy milk", "description": "2 litres"}}, {"op": "update", "id": 1, "version": 3, "task": {"priority": 2}}, {"op": "delete", "id": 2}, {"op": "complete", "id": 3}]}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "No operations",
+			body:         `{"operations": []}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown operation",
+			body:         `{"operations": [{"op": "archive", "id": 1}]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Create without description",
+			body:         `{"operations": [{"op": "create", "task": {"title": "Buy milk"}}]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Update without fields",
+			body:         `{"operations": [{"op": "update", "id": 1, "task": {}}]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Update without id",
+			body:         `{"operations": [{"op": "update", "task": {"priority": 2}}]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Delete with fields",
+			body:         `{"operations": [{"op": "delete", "id": 1, "task": {"priority": 2}}]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid date",
+			body:         `{"operations": [{"op": "update", "id": 1, "task": {"due_at": "tomorrow"}}]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(ValidateBulk(next))
+			defer server.Close()
+
+			req, err := http.NewRequest("POST", server.URL, bytes.NewBufferString(test.body))
+			if err != nil {
+				t.Error(err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if resp.StatusCode != test.expectedCode {
+				t.Errorf("expected status code %d, but got: %d", test.expectedCode, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestValidateAPIKey(t *testing.T) {
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		key := r.Context().Value(models.APIKeyKey{}).(models.APIKey)
+		if key.Name != "CI" || len(key.Scopes) != 2 || key.Prefix != "" {
+			t.Errorf("unexpected API key: %+v", key)
+		}
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	testCases := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{
+			name:         "All fields",
+			body:         `{"name": " CI ", "scopes": ["tasks:read", "tasks:write", "tasks:read"], "expires_at": "2100-01-01T00:00:00Z", "prefix": "tdl_x"}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "No name",
+			body:         `{"scopes": ["tasks:read"]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "No scopes",
+			body:         `{"name": "CI", "scopes": []}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown scope",
+			body:         `{"name": "CI", "scopes": ["admin"]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Expiry in the past",
+			body:         `{"name": "CI", "scopes": ["tasks:read"], "expires_at": "2000-01-01T00:00:00Z"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/me/api-keys", bytes.NewBufferString(test.body))
+			rw := httptest.NewRecorder()
+
+			ValidateAPIKey(next)(rw, req)
+
+			if rw.Code != test.expectedCode {
+				t.Errorf("expected status code %d, but got: %d", test.expectedCode, rw.Code)
+			}
+		})
+	}
+}
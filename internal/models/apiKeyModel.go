@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Scopes of an API key
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+	ScopeTagsRead   = "tags:read"
+	ScopeTagsWrite  = "tags:write"
+	ScopeListsRead  = "lists:read"
+	ScopeListsWrite = "lists:write"
+)
+
+// APIKeyScopes are the scopes an API key can be given.
+var APIKeyScopes = map[string]bool{
+	ScopeTasksRead:  true,
+	ScopeTasksWrite: true,
+	ScopeTagsRead:   true,
+	ScopeTagsWrite:  true,
+	ScopeListsRead:  true,
+	ScopeListsWrite: true,
+}
+
+// APIKeyPrefix starts every API key, telling it apart from a JWT.
+const APIKeyPrefix = "tdl_"
+
+// APIKeyNameMaxLength is the maximum length of the name of an API key.
+const APIKeyNameMaxLength = 100
+
+// ErrInvalidAPIKey is returned for unknown and expired API keys.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKey lets scripts act for a user within its scopes. Only the hash of the
+// key is stored; the key itself is returned once, when it is created.
+type APIKey struct {
+	bun.BaseModel `bun:"api_keys" swaggerignore:"true"`
+	ID            int        `bun:"id,pk,autoincrement" json:"id"`
+	UserID        int        `bun:"user_id,notnull" json:"-"`
+	Name          string     `bun:"name,notnull" json:"name"`
+	Prefix        string     `bun:"prefix,notnull" json:"prefix"`
+	KeyHash       string     `bun:"key_hash,notnull" json:"-"`
+	Scopes        []string   `bun:"scopes,array,notnull" json:"scopes"`
+	CreatedAt     time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	ExpiresAt     *time.Time `bun:"expires_at" json:"expires_at,omitempty"`
+	LastUsedAt    *time.Time `bun:"last_used_at" json:"last_used_at,omitempty"`
+
+	// Key is the API key itself, only set when it is created
+	Key string `bun:"-" json:"key,omitempty"`
+}
+
+type APIKeyKey struct{}
+
+// HasScope reports whether the key was given scope.
+func (k APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
@@ -0,0 +1,62 @@
+package models
+
+import "encoding/json"
+
+// Operations of a bulk request
+const (
+	BulkCreate   = "create"
+	BulkUpdate   = "update"
+	BulkDelete   = "delete"
+	BulkComplete = "complete"
+)
+
+// BulkMaxOperations is the maximum number of operations in one bulk request.
+const BulkMaxOperations = 500
+
+// Outcomes of a single bulk operation
+const (
+	BulkOK         = "ok"
+	BulkFailed     = "failed"
+	BulkRolledBack = "rolled_back"
+	BulkSkipped    = "skipped"
+)
+
+// BulkRequest is a list of task operations run in one transaction. Without
+// Atomic every operation succeeds or fails on its own; with it the first
+// failure rolls back all of them.
+type BulkRequest struct {
+	Atomic     bool            `json:"atomic"`
+	Operations []BulkOperation `json:"operations"`
+}
+
+// BulkOperation creates a task from Fields, updates task ID with Fields, or
+// deletes or completes task ID. Updates and deletes with a Version only apply
+// while the task is at that version.
+type BulkOperation struct {
+	Op      string          `json:"op"`
+	ID      int             `json:"id,omitempty"`
+	Version int64           `json:"version,omitempty"`
+	Fields  json.RawMessage `json:"task,omitempty" swaggertype:"object"`
+
+	// Task holds the decoded and validated Fields
+	Task Task `json:"-"`
+}
+
+// BulkOperationResult reports what became of a BulkOperation. Code and Error
+// are the status code and message the operation failed with.
+type BulkOperationResult struct {
+	ID     int    `json:"id,omitempty"`
+	Status string `json:"status"`
+	Code   int    `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Task   *Task  `json:"task,omitempty"`
+}
+
+// BulkResult is the response to a bulk request, with a result for every
+// operation in the order of the request.
+type BulkResult struct {
+	Committed bool                  `json:"committed"`
+	Results   []BulkOperationResult `json:"results"`
+}
+
+type BulkKey struct{}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// IdempotencyKeyMaxLength is the maximum length of an Idempotency-Key header.
+const IdempotencyKeyMaxLength = 255
+
+// IdempotencyKey is a request a user made with an Idempotency-Key header and,
+// once it has completed, the response to replay for it. StatusCode is 0 while
+// the request is in progress, which it is assumed to be until LockedUntil.
+type IdempotencyKey struct {
+	bun.BaseModel  `bun:"idempotency_keys" swaggerignore:"true"`
+	UserID         int         `bun:"user_id,pk"`
+	Key            string      `bun:"key,pk"`
+	RequestHash    string      `bun:"request_hash,notnull"`
+	StatusCode     int         `bun:"status_code,nullzero"`
+	ResponseHeader http.Header `bun:"response_header,type:jsonb,nullzero"`
+	ResponseBody   []byte      `bun:"response_body"`
+	CreatedAt      time.Time   `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	ExpiresAt      time.Time   `bun:"expires_at,notnull"`
+	LockedUntil    time.Time   `bun:"locked_until,notnull"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "github.com/uptrace/bun"
+
+// What happens to the tasks of a deleted list
+const (
+	ListDeleteMoveTasks   = "move"
+	ListDeleteDeleteTasks = "delete"
+)
+
+const ListInbox = "inbox"
+
+type List struct {
+	bun.BaseModel `bun:"lists" swaggerignore:"true"`
+	ID            int    `bun:"id,pk,autoincrement" json:"id"`
+	UserID        int    `bun:"user_id,notnull" json:"-"`
+	Name          string `bun:"name,notnull" json:"name"`
+}
+
+type ListKey struct{}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/utils"
+)
+
+// Media types of a PATCH request
+const (
+	PatchMergeType = "application/merge-patch+json"
+	PatchJSONType  = "application/json-patch+json"
+)
+
+// ErrPatchConflict is returned when a patch cannot be applied to the
+// current state of a task.
+var ErrPatchConflict = errors.New("patch cannot be applied")
+
+// TaskPatch is a JSON Merge Patch (Merge) or a JSON Patch (Ops) of the
+// TaskSyncFields of a task.
+type TaskPatch struct {
+	Merge map[string]any
+	Ops   []utils.PatchOperation
+}
+
+type TaskPatchKey struct{}
+
+// Apply applies the patch to task and returns the patched task together with
+// the fields the patch changed. A missing or null field is cleared; title and
+// status cannot be cleared.
+func (p TaskPatch) Apply(task Task) (Task, []string, error) {
+	doc, err := task.patchDocument()
+	if err != nil {
+		return task, nil, err
+	}
+
+	var result any
+	if p.Ops != nil {
+		result, err = utils.ApplyJSONPatch(doc, p.Ops)
+		if err != nil {
+			return task, nil, fmt.Errorf("%w: %s", ErrPatchConflict, err)
+		}
+	} else {
+		result = utils.MergePatch(doc, p.Merge)
+	}
+
+	patchedDoc, ok := result.(map[string]any)
+	if !ok {
+		return task, nil, errors.New("patched task must be an object")
+	}
+
+	var fields []string
+	for field := range TaskSyncFields {
+		if !reflect.DeepEqual(doc[field], patchedDoc[field]) {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+	if len(fields) == 0 {
+		return task, nil, nil
+	}
+
+	patched, err := decodePatchDocument(patchedDoc)
+	if err != nil {
+		return task, nil, err
+	}
+	patched.ID, patched.UserID = task.ID, task.UserID
+
+	if err := patched.Normalize(); err != nil {
+		return task, nil, err
+	}
+	if patched.Title == "" {
+		return task, nil, errors.New("task title is not specified")
+	}
+	if patched.Status == "" {
+		return task, nil, errors.New("invalid task status")
+	}
+
+	return patched, fields, nil
+}
+
+// patchDocument returns the JSON representation of the TaskSyncFields of a
+// task, which patches are applied to. A task without tags has an empty list
+// of them, so that tags can be added with a JSON Patch.
+func (t Task) patchDocument() (map[string]any, error) {
+	if t.Tags == nil {
+		t.Tags = []Tag{}
+	}
+
+	raw, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	for field := range doc {
+		if !TaskSyncFields[field] {
+			delete(doc, field)
+		}
+	}
+	return doc, nil
+}
+
+func decodePatchDocument(doc map[string]any) (Task, error) {
+	var task Task
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return task, err
+	}
+
+	if err := json.Unmarshal(raw, &task); err != nil {
+		var perr *time.ParseError
+		if errors.As(err, &perr) {
+			return task, errors.New("invalid date, expected RFC 3339 timestamp")
+		}
+		var terr *json.UnmarshalTypeError
+		if errors.As(err, &terr) && terr.Field != "" {
+			return task, fmt.Errorf("invalid value of %s", terr.Field)
+		}
+		return task, errors.New("invalid patched task")
+	}
+	return task, nil
+}
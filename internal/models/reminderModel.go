@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ReminderMaxAttempts is how many times the delivery of a reminder is tried
+// before it is given up.
+const ReminderMaxAttempts = 5
+
+// ReminderMaxOffset is the largest offset before the due date, in minutes (4 weeks).
+const ReminderMaxOffset = 4 * 7 * 24 * 60
+
+// Reminder fires either at RemindAt or OffsetMinutes before the due date of
+// its task. Exactly one of them is set.
+type Reminder struct {
+	bun.BaseModel `bun:"reminders" swaggerignore:"true"`
+	ID            int        `bun:"id,pk,autoincrement" json:"id"`
+	TaskID        int        `bun:"task_id,notnull" json:"task_id"`
+	UserID        int        `bun:"user_id,notnull" json:"-"`
+	RemindAt      *time.Time `bun:"remind_at" json:"remind_at"`
+	OffsetMinutes *int       `bun:"offset_minutes" json:"offset_minutes"`
+	SentAt        *time.Time `bun:"sent_at" json:"sent_at"`
+	Attempts      int        `bun:"attempts,nullzero,notnull,default:0" json:"-"`
+	NextAttemptAt *time.Time `bun:"next_attempt_at" json:"-"`
+	Delivered     []string   `bun:"delivered,array,nullzero,notnull,default:'{}'" json:"-"`
+}
+
+// Notification is what a notifier delivers when a reminder fires.
+type Notification struct {
+	ReminderID  int        `json:"reminder_id"`
+	TaskID      int        `json:"task_id"`
+	UserID      int        `json:"user_id"`
+	Email       string     `json:"email"`
+	TimeZone    string     `json:"time_zone"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueAt       *time.Time `json:"due_at"`
+	FireAt      time.Time  `json:"fire_at"`
+	// Delivered names the notifiers that already delivered the reminder
+	Delivered []string `bun:",array" json:"-"`
+}
+
+type ReminderKey struct{}
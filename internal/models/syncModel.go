@@ -0,0 +1,139 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// Conflict strategies of a sync request
+const (
+	SyncStrategyReport         = "report"
+	SyncStrategyLastWriterWins = "last_writer_wins"
+)
+
+// SyncMaxChanges is the maximum number of changes in one sync request.
+const SyncMaxChanges = 500
+
+// Outcomes of a single change
+const (
+	ChangeApplied  = "applied"
+	ChangeConflict = "conflict"
+	ChangeNotFound = "not_found"
+	ChangeRejected = "rejected"
+)
+
+// TaskSyncFields lists the task fields a client may change through a sync or
+// a PATCH.
+// Their JSON names are also the names of their columns and change log entries.
+var TaskSyncFields = map[string]bool{
+	"title":       true,
+	"description": true,
+	"status":      true,
+	"start_at":    true,
+	"due_at":      true,
+	"priority":    true,
+	"tags":        true,
+	"list_id":     true,
+	"parent_id":   true,
+	"rrule":       true,
+}
+
+// SyncRequest is a batch of changes a client made while offline.
+type SyncRequest struct {
+	// SyncToken is the token of the previous sync; without it all tasks are
+	// returned.
+	SyncToken string       `json:"sync_token"`
+	Strategy  string       `json:"strategy"`
+	Changes   []TaskChange `json:"changes"`
+
+	// Since is the position decoded from SyncToken
+	Since uint64 `json:"-"`
+}
+
+// TaskChange creates (without ID), updates or deletes a task. Updates and
+// deletes name the version of the task the client started from.
+type TaskChange struct {
+	ClientID    string `json:"client_id,omitempty"`
+	ID          int    `json:"id,omitempty"`
+	BaseVersion int64  `json:"base_version,omitempty"`
+	Deleted     bool   `json:"deleted,omitempty"`
+
+	// ChangedAt is when the change was made on the client, used by the
+	// last_writer_wins strategy.
+	ChangedAt time.Time                  `json:"changed_at"`
+	Fields    map[string]json.RawMessage `json:"fields,omitempty" swaggertype:"object"`
+
+	// Task holds the decoded and validated Fields
+	Task Task `json:"-"`
+}
+
+// TaskChangeResult reports what became of a TaskChange.
+type TaskChangeResult struct {
+	ClientID  string          `json:"client_id,omitempty"`
+	ID        int             `json:"id,omitempty"`
+	Version   int64           `json:"version,omitempty"`
+	Status    string          `json:"status"`
+	Conflicts []FieldConflict `json:"conflicts,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// FieldConflict is a field changed both on the client and on the server
+// since the client's base version. Resolution is "client" when the client's
+// value was applied and "server" when it was dropped.
+type FieldConflict struct {
+	Field       string `json:"field"`
+	ServerValue any    `json:"server_value"`
+	ClientValue any    `json:"client_value"`
+	Resolution  string `json:"resolution"`
+}
+
+// SyncResult is the response to a sync: the outcome of every change, the
+// tasks changed on the server since the previous sync (deleted tasks carry
+// deleted_at) and the ids of tasks that no longer exist at all.
+type SyncResult struct {
+	SyncToken string             `json:"sync_token"`
+	Results   []TaskChangeResult `json:"results"`
+	Changes   []Task             `json:"changes"`
+	Deleted   []int              `json:"deleted"`
+}
+
+// SyncToken is the signed position of a client in the change log.
+type SyncToken struct {
+	XID uint64 `json:"x"`
+}
+
+type SyncKey struct{}
+
+// FieldValue returns the value of one of the TaskSyncFields, with tags as a
+// sorted list of names.
+func (t Task) FieldValue(field string) any {
+	switch field {
+	case "title":
+		return t.Title
+	case "description":
+		return t.Description
+	case "status":
+		return t.Status
+	case "start_at":
+		return t.StartAt
+	case "due_at":
+		return t.DueAt
+	case "priority":
+		return t.Priority
+	case "tags":
+		names := make([]string, len(t.Tags))
+		for i, tag := range t.Tags {
+			names[i] = tag.Name
+		}
+		slices.Sort(names)
+		return names
+	case "list_id":
+		return t.ListID
+	case "parent_id":
+		return t.ParentID
+	case "rrule":
+		return t.RRule
+	}
+	return nil
+}
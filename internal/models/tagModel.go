@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	TagModeAll = "all"
+	TagModeAny = "any"
+)
+
+const TagNameMaxLength = 64
+
+// NormalizeTagName trims a tag name and checks it is neither empty nor too
+// long.
+func NormalizeTagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	switch {
+	case name == "":
+		return name, errors.New("tag name is not specified")
+	case utf8.RuneCountInString(name) > TagNameMaxLength:
+		return name, errors.New("tag name is too long")
+	}
+
+	return name, nil
+}
+
+type Tag struct {
+	bun.BaseModel `bun:"tags" swaggerignore:"true"`
+	ID            int    `bun:"id,pk,autoincrement" json:"id"`
+	UserID        int    `bun:"user_id,notnull" json:"-"`
+	Name          string `bun:"name,notnull" json:"name"`
+}
+
+// TaskTag is the join model of the tasks <-> tags relation.
+type TaskTag struct {
+	bun.BaseModel `bun:"task_tags" swaggerignore:"true"`
+	TaskID        int   `bun:"task_id,pk"`
+	Task          *Task `bun:"rel:belongs-to,join:task_id=id"`
+	TagID         int   `bun:"tag_id,pk"`
+	Tag           *Tag  `bun:"rel:belongs-to,join:tag_id=id"`
+}
+
+type TagKey struct{}
@@ -1,13 +1,294 @@
 package models
 
-import "github.com/uptrace/bun"
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/utils"
+	"github.com/uptrace/bun"
+)
+
+const (
+	TaskStatusTodo       = "todo"
+	TaskStatusInProgress = "in_progress"
+	TaskStatusDone       = "done"
+)
+
+const (
+	TaskPriorityNone   = 0
+	TaskPriorityUrgent = 4
+)
+
+// TaskMaxDepth is the maximum number of nesting levels of a task hierarchy,
+// counting the top-level task.
+const TaskMaxDepth = 5
+
+const (
+	TaskDueToday    = "today"
+	TaskDueTomorrow = "tomorrow"
+	TaskDueWeek     = "week"
+	TaskDueOverdue  = "overdue"
+)
+
+// Which tasks a listing returns with regard to the archive
+const (
+	TaskArchivedExclude = ""
+	TaskArchivedInclude = "include"
+	TaskArchivedOnly    = "only"
+)
 
 type Task struct {
 	bun.BaseModel `bun:"tasks" swaggerignore:"true"`
-	ID            int    `bun:"id,pk,autoincrement" json:"id"`
-	UserID        int    `bun:"user_id,notnull" json:"-"`
-	Title         string `bun:"title,notnull" json:"title"`
-	Description   string `bun:"description" json:"description"`
+	ID            int        `bun:"id,pk,autoincrement" json:"id"`
+	UserID        int        `bun:"user_id,notnull" json:"-"`
+	Title         string     `bun:"title,notnull" json:"title"`
+	Description   string     `bun:"description" json:"description"`
+	Status        string     `bun:"status,nullzero,notnull,default:'todo'" json:"status"`
+	CompletedAt   *time.Time `bun:"completed_at" json:"completed_at"`
+	StartAt       *time.Time `bun:"start_at" json:"start_at"`
+	DueAt         *time.Time `bun:"due_at" json:"due_at"`
+	Priority      int        `bun:"priority,notnull,default:0" json:"priority"`
+	Tags          []Tag      `bun:"m2m:task_tags,join:Task=Tag" json:"tags"`
+	ListID        *int       `bun:"list_id" json:"list_id"`
+	ParentID      *int       `bun:"parent_id" json:"parent_id"`
+	RRule         string     `bun:"rrule,nullzero" json:"rrule"`
+	Version       int64      `bun:"version,nullzero,notnull,default:1" json:"version"`
+	CreatedAt     time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt     time.Time  `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+	ArchivedAt    *time.Time `bun:"archived_at,nullzero" json:"archived_at"`
+	DeletedAt     *time.Time `bun:"deleted_at,soft_delete,nullzero" json:"deleted_at,omitempty"`
+	SubtasksTotal int        `bun:"subtasks_total,scanonly" json:"subtasks_total"`
+	SubtasksDone  int        `bun:"subtasks_done,scanonly" json:"subtasks_done"`
+	Subtasks      []Task     `bun:"-" json:"subtasks,omitempty"`
+}
+
+// TaskSearchResult is a task matched by a full-text search, with its rank
+// and the matching fragments of its title and description highlighted.
+type TaskSearchResult struct {
+	Task                 `bun:",extend"`
+	Rank                 float64 `bun:"rank,scanonly" json:"rank"`
+	TitleHighlight       string  `bun:"title_highlight,scanonly" json:"title_highlight"`
+	DescriptionHighlight string  `bun:"description_highlight,scanonly" json:"description_highlight"`
+}
+
+// TaskSearchMaxLength is the maximum length of a search query.
+const TaskSearchMaxLength = 256
+
+// TaskFilter holds optional conditions applied when listing tasks.
+type TaskFilter struct {
+	Status string
+
+	// ListID restricts the result to one list, Inbox to tasks without a list
+	ListID *int
+	Inbox  bool
+
+	// ParentID restricts the result to direct subtasks of a task, TopLevel to
+	// tasks without a parent. Tree additionally nests all subtasks.
+	ParentID *int
+	TopLevel bool
+	Tree     bool
+
+	// Due is one of the TaskDue* modes; it is resolved into DueFrom/DueBefore
+	// using the user's time zone, except for overdue which sets Overdue.
+	Due       string
+	DueFrom   *time.Time
+	DueBefore *time.Time
+	Overdue   bool
+
+	// Tags restricts the result to tasks carrying all (TagModeAll) or any
+	// (TagModeAny) of the named tags.
+	Tags    []string
+	TagMode string
+
+	// Archived is one of the TaskArchived* modes
+	Archived string
+
+	// UpdatedSince restricts the result to tasks changed after the given
+	// time, including deleted tasks as tombstones.
+	UpdatedSince *time.Time
+
+	Sort []TaskSort
+
+	// After continues the listing after the task whose sort key values (see
+	// TaskSortKeys and Task.SortValues) are given, instead of using an offset.
+	After []*string
+}
+
+// TaskCursor is the position of the last task of a page in keyset pagination.
+type TaskCursor struct {
+	Sort   string    `json:"s"`
+	Values []*string `json:"v"`
+}
+
+// TaskSort is a single ORDER BY key; Field is one of TaskSortFields.
+type TaskSort struct {
+	Field string
+	Desc  bool
+}
+
+// TaskSortFields lists the task fields clients may sort by.
+var TaskSortFields = map[string]bool{
+	"id":           true,
+	"title":        true,
+	"status":       true,
+	"priority":     true,
+	"start_at":     true,
+	"due_at":       true,
+	"completed_at": true,
+	"created_at":   true,
+	"updated_at":   true,
 }
 
 type TaskKey struct{}
+
+func IsValidTaskStatus(status string) bool {
+	switch status {
+	case TaskStatusTodo, TaskStatusInProgress, TaskStatusDone:
+		return true
+	}
+	return false
+}
+
+func IsValidDueMode(mode string) bool {
+	switch mode {
+	case TaskDueToday, TaskDueTomorrow, TaskDueWeek, TaskDueOverdue:
+		return true
+	}
+	return false
+}
+
+func IsValidTaskPriority(priority int) bool {
+	return priority >= TaskPriorityNone && priority <= TaskPriorityUrgent
+}
+
+// Normalize validates and normalises the values a client may set on a task.
+func (t *Task) Normalize() error {
+	var err error
+	for i := range t.Tags {
+		t.Tags[i].Name, err = NormalizeTagName(t.Tags[i].Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if t.ParentID != nil && *t.ParentID <= 0 {
+		return errors.New("invalid parent id")
+	}
+
+	if t.RRule != "" {
+		rule, err := utils.ParseRRule(t.RRule)
+		if err != nil {
+			return fmt.Errorf("invalid recurrence rule: %w", err)
+		}
+		t.RRule = rule.String()
+	}
+
+	if t.ListID != nil && *t.ListID <= 0 {
+		return errors.New("invalid list id")
+	}
+
+	if !IsValidTaskPriority(t.Priority) {
+		return errors.New("task priority must be between 0 and 4")
+	}
+
+	if t.StartAt != nil && t.DueAt != nil && t.StartAt.After(*t.DueAt) {
+		return errors.New("start date must not be after due date")
+	}
+
+	if t.Status != "" && !IsValidTaskStatus(t.Status) {
+		return errors.New("invalid task status")
+	}
+
+	return nil
+}
+
+// TaskSortKeys returns the keys tasks are actually ordered by for sort, which
+// always end with id so that the order is total.
+func TaskSortKeys(sort []TaskSort) []TaskSort {
+	for _, key := range sort {
+		if key.Field == "id" {
+			return sort
+		}
+	}
+	return append(sort[:len(sort):len(sort)], TaskSort{Field: "id"})
+}
+
+// FormatTaskSort is the inverse of ParseTaskSort.
+func FormatTaskSort(sort []TaskSort) string {
+	keys := make([]string, len(sort))
+	for i, key := range sort {
+		keys[i] = key.Field
+		if key.Desc {
+			keys[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+// SortValues returns the values of the task for keys as SQL literals, with
+// nil standing for NULL.
+func (t Task) SortValues(keys []TaskSort) []*string {
+	values := make([]*string, len(keys))
+	for i, key := range keys {
+		var v string
+		switch key.Field {
+		case "id":
+			v = strconv.Itoa(t.ID)
+		case "title":
+			v = t.Title
+		case "status":
+			v = t.Status
+		case "priority":
+			v = strconv.Itoa(t.Priority)
+		case "start_at":
+			v = formatSortTime(t.StartAt)
+		case "due_at":
+			v = formatSortTime(t.DueAt)
+		case "completed_at":
+			v = formatSortTime(t.CompletedAt)
+		case "created_at":
+			v = formatSortTime(&t.CreatedAt)
+		case "updated_at":
+			v = formatSortTime(&t.UpdatedAt)
+		}
+		if v != "" || key.Field == "title" {
+			values[i] = &v
+		}
+	}
+	return values
+}
+
+func formatSortTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// ParseTaskSort parses a comma separated list of fields such as
+// "priority,-due_at", where a leading '-' sorts that field descending.
+func ParseTaskSort(s string) ([]TaskSort, error) {
+	var sort []TaskSort
+	seen := make(map[string]bool)
+
+	for _, key := range strings.Split(s, ",") {
+		key = strings.TrimSpace(key)
+		desc := strings.HasPrefix(key, "-")
+		field := strings.TrimPrefix(key, "-")
+
+		if !TaskSortFields[field] {
+			return nil, fmt.Errorf("cannot sort by %q", field)
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("duplicate sort field %q", field)
+		}
+		seen[field] = true
+
+		sort = append(sort, TaskSort{Field: field, Desc: desc})
+	}
+
+	return sort, nil
+}
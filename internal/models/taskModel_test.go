@@ -0,0 +1,98 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTaskSort(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         string
+		expected      []TaskSort
+		expectedError bool
+	}{
+		{
+			name:     "Single field",
+			input:    "priority",
+			expected: []TaskSort{{Field: "priority"}},
+		},
+		{
+			name:     "Multiple fields with direction",
+			input:    "-priority, due_at,-id",
+			expected: []TaskSort{{Field: "priority", Desc: true}, {Field: "due_at"}, {Field: "id", Desc: true}},
+		},
+		{
+			name:          "Unknown field",
+			input:         "priority,user_id",
+			expectedError: true,
+		},
+		{
+			name:          "Injection attempt",
+			input:         "id;DROP TABLE tasks",
+			expectedError: true,
+		},
+		{
+			name:          "Duplicate field",
+			input:         "priority,-priority",
+			expectedError: true,
+		},
+		{
+			name:          "Empty key",
+			input:         "priority,",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sort, err := ParseTaskSort(tc.input)
+
+			if (err != nil) != tc.expectedError {
+				t.Errorf("Expected error: %v, got: %v", tc.expectedError, err)
+			}
+			if !tc.expectedError && !reflect.DeepEqual(sort, tc.expected) {
+				t.Errorf("Expected: %v, got: %v", tc.expected, sort)
+			}
+		})
+	}
+}
+
+func TestTaskSortValues(t *testing.T) {
+	dueAt := time.Date(2024, 10, 5, 18, 0, 0, 500, time.UTC)
+	task := Task{ID: 7, Title: "Buy milk", Status: TaskStatusTodo, Priority: 3, DueAt: &dueAt}
+
+	sort := []TaskSort{{Field: "priority", Desc: true}, {Field: "due_at"}, {Field: "start_at"}}
+	keys := TaskSortKeys(sort)
+	if len(sort) != 3 || len(keys) != 4 || keys[3] != (TaskSort{Field: "id"}) {
+		t.Fatalf("Expected id to be appended to a copy of the sort, got: %v", keys)
+	}
+
+	values := task.SortValues(keys)
+	expected := []*string{ptr("3"), ptr("2024-10-05T18:00:00.0000005Z"), nil, ptr("7")}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %v, got: %v", expected, values)
+	}
+
+	task.UpdatedAt = dueAt
+	if values := task.SortValues([]TaskSort{{Field: "updated_at"}}); *values[0] != "2024-10-05T18:00:00.0000005Z" {
+		t.Errorf("Unexpected updated_at value: %s", *values[0])
+	}
+
+	withID := []TaskSort{{Field: "id", Desc: true}, {Field: "title"}}
+	if keys := TaskSortKeys(withID); !reflect.DeepEqual(keys, withID) {
+		t.Errorf("Expected sort with id to be kept, got: %v", keys)
+	}
+
+	if s := FormatTaskSort(sort); s != "-priority,due_at,start_at" {
+		t.Errorf("Unexpected formatted sort: %s", s)
+	}
+	if parsed, err := ParseTaskSort(FormatTaskSort(withID)); err != nil || !reflect.DeepEqual(parsed, withID) {
+		t.Errorf("Expected %v, got: %v (%v)", withID, parsed, err)
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
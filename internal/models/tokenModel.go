@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// RefreshToken is a stored refresh token. Only the hash of the token itself
+// is kept. Every refresh replaces a token by a new one of the same family and
+// marks it used; a used token presented again revokes its whole family.
+type RefreshToken struct {
+	bun.BaseModel `bun:"refresh_tokens" swaggerignore:"true"`
+	ID            int64      `bun:"id,pk,autoincrement"`
+	UserID        int        `bun:"user_id,notnull"`
+	FamilyID      string     `bun:"family_id,notnull"`
+	TokenHash     string     `bun:"token_hash,notnull"`
+	CreatedAt     time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	ExpiresAt     time.Time  `bun:"expires_at,notnull"`
+	UsedAt        *time.Time `bun:"used_at"`
+	RevokedAt     *time.Time `bun:"revoked_at"`
+}
+
+// RevokedToken is an access token revoked before it expired, kept until then.
+type RevokedToken struct {
+	bun.BaseModel `bun:"revoked_tokens" swaggerignore:"true"`
+	JTI           string    `bun:"jti,pk"`
+	UserID        int       `bun:"user_id,notnull"`
+	ExpiresAt     time.Time `bun:"expires_at,notnull"`
+}
+
+// AccessToken holds the claims of a validated access token. SessionID is the
+// family of the refresh token issued along with it, and Version the token
+// version of the user at the time it was issued.
+type AccessToken struct {
+	UserID    int
+	JTI       string
+	SessionID string
+	Version   int
+	ExpiresAt time.Time
+}
+
+type AccessTokenKey struct{}
+
+// ErrTokenRevoked is returned for access tokens that have been revoked by a
+// logout.
+var ErrTokenRevoked = errors.New("token revoked")
+
+// Session is a login of a user on a device. Its ID is the family of the
+// refresh tokens issued to it and the sid of its access tokens.
+type Session struct {
+	bun.BaseModel `bun:"sessions" swaggerignore:"true"`
+	ID            string    `bun:"id,pk" json:"id"`
+	UserID        int       `bun:"user_id,notnull" json:"-"`
+	DeviceName    string    `bun:"device_name,nullzero" json:"device_name,omitempty"`
+	UserAgent     string    `bun:"user_agent,nullzero" json:"user_agent,omitempty"`
+	IP            string    `bun:"ip,nullzero" json:"ip,omitempty"`
+	CreatedAt     time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	LastUsedAt    time.Time `bun:"last_used_at,nullzero,notnull,default:current_timestamp" json:"last_used_at"`
+
+	// Current marks the session of the request
+	Current bool `bun:"-" json:"current"`
+}
+
+// SessionDeviceNameMaxLength is the maximum length of the device name given
+// on login.
+const SessionDeviceNameMaxLength = 100
@@ -1,15 +1,24 @@
 package models
 
 import (
+	"time"
+
 	"github.com/uptrace/bun"
 )
 
 type User struct {
 	bun.BaseModel `bun:"users" swaggerignore:"true"`
-	ID            int    `bun:"id,pk,autoincrement" json:"id,omitempty"`
-	Username      string `bun:"name,notnull" json:"username,omitempty"`
-	Email         string `bun:"email,notnull,unique" json:"email"`
-	Password      string `bun:"password,notnull" json:"password"`
+	ID            int       `bun:"id,pk,autoincrement" json:"id,omitempty"`
+	Username      string    `bun:"name,notnull" json:"username,omitempty"`
+	Email         string    `bun:"email,notnull,unique" json:"email"`
+	Password      string    `bun:"password,notnull" json:"password"`
+	TimeZone      string    `bun:"time_zone,nullzero,notnull,default:'UTC'" json:"time_zone,omitempty"`
+	CreatedAt     time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt     time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+	TokenVersion  int       `bun:"token_version,notnull,default:0" json:"-"`
+
+	// DeviceName names the session started by a login
+	DeviceName string `bun:"-" json:"device_name,omitempty"`
 }
 
 type UserIDKey struct{}
@@ -0,0 +1,12 @@
+// Package notifier delivers fired reminders to users.
+package notifier
+
+import (
+	"context"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+)
+
+type Notifier interface {
+	Notify(ctx context.Context, n models.Notification) error
+}
@@ -0,0 +1,223 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+)
+
+func testNotification() models.Notification {
+	dueAt := time.Date(2024, 10, 8, 16, 0, 0, 0, time.UTC)
+	return models.Notification{
+		ReminderID:  1,
+		TaskID:      2,
+		UserID:      3,
+		Email:       "test@test.com",
+		TimeZone:    "Europe/Kyiv",
+		Title:       "Pay rent\r\nBcc: evil@example.com",
+		Description: "Before the 10th",
+		DueAt:       &dueAt,
+		FireAt:      dueAt.Add(-time.Hour),
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	testCases := []struct {
+		name          string
+		secret        string
+		status        int
+		expectedError bool
+	}{
+		{
+			name:   "Delivered",
+			status: http.StatusOK,
+		},
+		{
+			name:   "Signed",
+			secret: "secret",
+			status: http.StatusNoContent,
+		},
+		{
+			name:          "Rejected",
+			status:        http.StatusInternalServerError,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var received models.Notification
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &received); err != nil {
+					t.Error(err)
+				}
+
+				if tc.secret != "" {
+					mac := hmac.New(sha256.New, []byte(tc.secret))
+					mac.Write(body)
+					if r.Header.Get(SignatureHeader) != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+						t.Errorf("invalid signature: %q", r.Header.Get(SignatureHeader))
+					}
+				}
+
+				rw.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+
+			n := testNotification()
+			err := NewWebhookNotifier(server.URL, tc.secret).Notify(context.TODO(), n)
+
+			if (err != nil) != tc.expectedError {
+				t.Errorf("Expected error: %v, got: %v", tc.expectedError, err)
+			}
+			if received.ReminderID != n.ReminderID || received.Title != n.Title {
+				t.Errorf("Expected %+v, got: %+v", n, received)
+			}
+		})
+	}
+}
+
+// fakeSMTPServer accepts a single mail and returns its recipients and data.
+func fakeSMTPServer(t *testing.T) (string, <-chan []string, <-chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	rcpts := make(chan []string, 1)
+	data := make(chan string, 1)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+
+		var to []string
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			cmd := strings.ToUpper(line)
+
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				to = append(to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 Go ahead")
+				var msg strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					msg.WriteString(l)
+				}
+				rcpts <- to
+				data <- msg.String()
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return l.Addr().String(), rcpts, data
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, rcpts, data := fakeSMTPServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+
+	n := testNotification()
+	err := NewSMTPNotifier(host, port, "", "", "todo@example.com").Notify(context.TODO(), n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if to := <-rcpts; len(to) != 1 || to[0] != n.Email {
+		t.Errorf("Expected recipient %s, got: %v", n.Email, to)
+	}
+
+	msg := <-data
+	headers, _, _ := strings.Cut(msg, "\r\n\r\n")
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Errorf("Title injected a header: %q", msg)
+	}
+	if !strings.Contains(msg, "Subject: Reminder: Pay rent  Bcc: evil@example.com\r\n") {
+		t.Errorf("Missing subject: %q", msg)
+	}
+	// 16:00 UTC is 19:00 in Kyiv
+	if !strings.Contains(msg, "Due: Tue, 08 Oct 2024 19:00:00 EEST\r\n") {
+		t.Errorf("Missing due date in the user's time zone: %q", msg)
+	}
+}
+
+func TestSMTPNotifierWithoutEmail(t *testing.T) {
+	n := testNotification()
+	n.Email = ""
+
+	err := NewSMTPNotifier("127.0.0.1", "1", "", "", "todo@example.com").Notify(context.TODO(), n)
+	if err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestSMTPNotifierGivesUpWithContext(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// Accepts connections without ever greeting
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = NewSMTPNotifier(host, port, "", "", "todo@example.com").Notify(ctx, testNotification())
+	if err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected to give up with the context, took %v", elapsed)
+	}
+}
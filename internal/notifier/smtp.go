@@ -0,0 +1,121 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+)
+
+// SMTPTimeout bounds the delivery of a single email, from connecting to the
+// server until it has accepted the message.
+const SMTPTimeout = 30 * time.Second
+
+// SMTPNotifier emails notifications to the owner of the task.
+type SMTPNotifier struct {
+	host string
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPNotifier creates a notifier sending through the server at host:port.
+// Authentication is skipped when username is empty.
+func NewSMTPNotifier(host, port, username, password, from string) *SMTPNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPNotifier{host: host, addr: net.JoinHostPort(host, port), from: from, auth: auth}
+}
+
+func (sn *SMTPNotifier) Notify(ctx context.Context, n models.Notification) error {
+	if n.Email == "" {
+		return fmt.Errorf("user %d has no email", n.UserID)
+	}
+
+	// smtp.SendMail has no timeouts, so a server that stops responding
+	// would hold up every later reminder. This does the same within
+	// SMTPTimeout, and gives up as soon as ctx is done.
+	dialer := net.Dialer{Timeout: SMTPTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", sn.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(SMTPTimeout))
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, sn.host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: sn.host}); err != nil {
+			return err
+		}
+	}
+	if sn.auth != nil {
+		if err := c.Auth(sn.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(sn.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(n.Email); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(sn.message(n)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message builds the email for n. Header values are stripped of line breaks
+// so task titles cannot inject headers.
+func (sn *SMTPNotifier) message(n models.Notification) []byte {
+	header := strings.NewReplacer("\r", " ", "\n", " ")
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", header.Replace(sn.from))
+	fmt.Fprintf(&msg, "To: %s\r\n", header.Replace(n.Email))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "Reminder: "+header.Replace(n.Title)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+
+	fmt.Fprintf(&msg, "%s\r\n", n.Title)
+	if n.DueAt != nil {
+		loc, err := time.LoadLocation(n.TimeZone)
+		if err != nil {
+			loc = time.UTC
+		}
+		fmt.Fprintf(&msg, "Due: %s\r\n", n.DueAt.In(loc).Format(time.RFC1123))
+	}
+	if n.Description != "" {
+		fmt.Fprintf(&msg, "\r\n%s\r\n", strings.ReplaceAll(strings.ReplaceAll(n.Description, "\r\n", "\n"), "\n", "\r\n"))
+	}
+
+	return msg.Bytes()
+}
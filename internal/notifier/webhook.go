@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+)
+
+// SignatureHeader carries the hex encoded HMAC-SHA256 of the request body
+// when the webhook has a secret.
+const SignatureHeader = "X-Todolist-Signature"
+
+// WebhookNotifier posts notifications as JSON to a URL.
+type WebhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url, secret string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, secret: secret, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (wn *WebhookNotifier) Notify(ctx context.Context, n models.Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wn.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if wn.secret != "" {
+		mac := hmac.New(sha256.New, []byte(wn.secret))
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := wn.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/uptrace/bun"
+)
+
+type APIKeyRepositoryInterface interface {
+	AddAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error)
+	GetAPIKeys(ctx context.Context, user_id int) ([]models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, key_hash string) (models.APIKey, error)
+	TouchAPIKey(ctx context.Context, key_id int) error
+	DeleteAPIKey(ctx context.Context, user_id, key_id int) error
+}
+
+type APIKeyRepository struct {
+	db *bun.DB
+}
+
+func NewAPIKeyRepository(db *bun.DB) *APIKeyRepository {
+	return &APIKeyRepository{db}
+}
+
+func (ar *APIKeyRepository) AddAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	var retKey models.APIKey
+	err := idb(ctx, ar.db).NewInsert().Model(&key).Returning("*").Scan(ctx, &retKey)
+	return retKey, err
+}
+
+func (ar *APIKeyRepository) GetAPIKeys(ctx context.Context, user_id int) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := idb(ctx, ar.db).NewSelect().Model(&keys).Where("?0 = ?1", bun.Ident("user_id"), user_id).Order("id").Scan(ctx)
+	return keys, err
+}
+
+func (ar *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, key_hash string) (models.APIKey, error) {
+	var key models.APIKey
+	err := idb(ctx, ar.db).NewSelect().Model(&key).Where("?0 = ?1", bun.Ident("key_hash"), key_hash).Scan(ctx)
+	return key, err
+}
+
+// TouchAPIKey records that the key was used.
+func (ar *APIKeyRepository) TouchAPIKey(ctx context.Context, key_id int) error {
+	_, err := idb(ctx, ar.db).NewUpdate().Model((*models.APIKey)(nil)).
+		Set("?0 = now()", bun.Ident("last_used_at")).
+		Where("?0 = ?1", bun.Ident("id"), key_id).
+		Exec(ctx)
+	return err
+}
+
+// DeleteAPIKey revokes a key of the user. It returns sql.ErrNoRows if the
+// user has no such key.
+func (ar *APIKeyRepository) DeleteAPIKey(ctx context.Context, user_id, key_id int) error {
+	res, err := idb(ctx, ar.db).NewDelete().Model((*models.APIKey)(nil)).
+		Where("?0 = ?1 AND ?2 = ?3", bun.Ident("id"), key_id, bun.Ident("user_id"), user_id).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+// ErrDuplicate is returned when a write violates a unique constraint.
+var ErrDuplicate = errors.New("duplicate record")
+
+// ErrChangeLogPurged is returned when the change log no longer reaches back
+// far enough to tell what changed.
+var ErrChangeLogPurged = errors.New("change log purged")
+
+// ErrMissingReference is returned when a write refers to a record that does
+// not exist, e.g. because it was deleted meanwhile.
+var ErrMissingReference = errors.New("referenced record not found")
+
+// ErrInvalidRecord is returned when a write violates a check constraint.
+var ErrInvalidRecord = errors.New("invalid record")
+
+func isUniqueViolation(err error) bool {
+	return hasErrorCode(err, "23505")
+}
+
+func isForeignKeyViolation(err error) bool {
+	return hasErrorCode(err, "23503")
+}
+
+func isCheckViolation(err error) bool {
+	return hasErrorCode(err, "23514")
+}
+
+func hasErrorCode(err error, code string) bool {
+	var pgErr pgdriver.Error
+	return errors.As(err, &pgErr) && pgErr.Field('C') == code
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/uptrace/bun"
+)
+
+type IdempotencyRepositoryInterface interface {
+	ReserveKey(ctx context.Context, key models.IdempotencyKey) (models.IdempotencyKey, bool, error)
+	SaveResponse(ctx context.Context, user_id int, key string, status int, header http.Header, body []byte) error
+	DeleteKey(ctx context.Context, user_id int, key string) error
+	PurgeExpiredKeys(ctx context.Context, now time.Time) (int, error)
+}
+
+type IdempotencyRepository struct {
+	db *bun.DB
+}
+
+func NewIdempotencyRepository(db *bun.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db}
+}
+
+// ReserveKey stores key for a new request and reports true, unless the user
+// already has an unexpired key of that name, which is returned instead. An
+// expired key is replaced, as is one whose request is still unanswered past
+// its locked_until, e.g. because the server crashed while handling it.
+func (ir *IdempotencyRepository) ReserveKey(ctx context.Context, key models.IdempotencyKey) (models.IdempotencyKey, bool, error) {
+	var reserved models.IdempotencyKey
+	err := idb(ctx, ir.db).NewInsert().Model(&key).
+		On("CONFLICT (user_id, key) DO UPDATE").
+		Set("request_hash = EXCLUDED.request_hash").
+		Set("status_code = NULL, response_header = NULL, response_body = NULL").
+		Set("created_at = now()").
+		Set("expires_at = EXCLUDED.expires_at").
+		Set("locked_until = EXCLUDED.locked_until").
+		Where("?0.?1 <= now() OR (?0.?2 IS NULL AND ?0.?3 <= now())",
+			bun.Ident("idempotency_key"), bun.Ident("expires_at"), bun.Ident("status_code"), bun.Ident("locked_until")).
+		Returning("*").Scan(ctx, &reserved)
+	if err == nil {
+		return reserved, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return reserved, false, err
+	}
+
+	err = idb(ctx, ir.db).NewSelect().Model(&reserved).
+		Where("?0 = ?1 AND ?2 = ?3", bun.Ident("user_id"), key.UserID, bun.Ident("key"), key.Key).
+		Scan(ctx)
+	return reserved, false, err
+}
+
+// SaveResponse stores the response to the request of a reserved key.
+func (ir *IdempotencyRepository) SaveResponse(ctx context.Context, user_id int, key string, status int, header http.Header, body []byte) error {
+	_, err := idb(ctx, ir.db).NewUpdate().Model(&models.IdempotencyKey{StatusCode: status, ResponseHeader: header, ResponseBody: body}).
+		Column("status_code", "response_header", "response_body").
+		Where("?0 = ?1 AND ?2 = ?3", bun.Ident("user_id"), user_id, bun.Ident("key"), key).
+		Exec(ctx)
+	return err
+}
+
+// DeleteKey releases a key, so that the request can be retried with it.
+func (ir *IdempotencyRepository) DeleteKey(ctx context.Context, user_id int, key string) error {
+	_, err := idb(ctx, ir.db).NewDelete().Model((*models.IdempotencyKey)(nil)).
+		Where("?0 = ?1 AND ?2 = ?3", bun.Ident("user_id"), user_id, bun.Ident("key"), key).
+		Exec(ctx)
+	return err
+}
+
+// PurgeExpiredKeys deletes the keys expired at now and returns their number.
+func (ir *IdempotencyRepository) PurgeExpiredKeys(ctx context.Context, now time.Time) (int, error) {
+	res, err := idb(ctx, ir.db).NewDelete().Model((*models.IdempotencyKey)(nil)).
+		Where("?0 <= ?1", bun.Ident("expires_at"), now).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := res.RowsAffected()
+	return int(purged), err
+}
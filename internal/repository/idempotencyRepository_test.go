@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+)
+
+func TestReserveKeyReplacesStuckKey(t *testing.T) {
+	conn := &recordingConn{returning: func(query string) ([]string, [][]driver.Value) {
+		return []string{"user_id", "key"}, [][]driver.Value{{int64(1), "a"}}
+	}}
+	ir := NewIdempotencyRepository(newRecordingDB(conn))
+
+	_, reserved, err := ir.ReserveKey(context.TODO(), models.IdempotencyKey{
+		UserID:      1,
+		Key:         "a",
+		RequestHash: "hash",
+		ExpiresAt:   time.Now().Add(24 * time.Hour),
+		LockedUntil: time.Now().Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reserved {
+		t.Error("Expected the key to be reserved")
+	}
+
+	stuck := `"idempotency_key"."status_code" IS NULL AND "idempotency_key"."locked_until" <= now()`
+	if n := conn.countQueries(`INSERT INTO "idempotency_keys"`, stuck); n != 1 {
+		t.Errorf("Expected keys stuck in progress to be replaced, got queries: %v", conn.queries)
+	}
+}
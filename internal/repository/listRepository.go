@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/uptrace/bun"
+)
+
+type ListRepositoryInterface interface {
+	GetLists(ctx context.Context, user_id int) ([]models.List, error)
+	GetListByID(ctx context.Context, list_id, user_id int) (models.List, error)
+	AddList(ctx context.Context, list models.List) (models.List, error)
+	UpdateList(ctx context.Context, list models.List, list_id, user_id int) (models.List, error)
+	DeleteList(ctx context.Context, list_id, user_id int, deleteTasks bool) error
+}
+
+type ListRepository struct {
+	db *bun.DB
+}
+
+func NewListRepository(db *bun.DB) *ListRepository {
+	return &ListRepository{db}
+}
+
+func (lr *ListRepository) GetLists(ctx context.Context, user_id int) ([]models.List, error) {
+	var lists []models.List
+	err := idb(ctx, lr.db).NewSelect().Model(&lists).Where("?0 = ?1", bun.Ident("user_id"), user_id).Order("id").Scan(ctx)
+	return lists, err
+}
+
+func (lr *ListRepository) GetListByID(ctx context.Context, list_id, user_id int) (models.List, error) {
+	var list models.List
+	err := idb(ctx, lr.db).NewSelect().Model(&list).Where("?0 = ?1 AND ?2 = ?3", bun.Ident("id"), list_id, bun.Ident("user_id"), user_id).Scan(ctx)
+	return list, err
+}
+
+func (lr *ListRepository) AddList(ctx context.Context, list models.List) (models.List, error) {
+	var retList models.List
+	err := idb(ctx, lr.db).NewInsert().Model(&list).Returning("*").Scan(ctx, &retList)
+	return retList, err
+}
+
+func (lr *ListRepository) UpdateList(ctx context.Context, list models.List, list_id, user_id int) (models.List, error) {
+	var retList models.List
+	err := idb(ctx, lr.db).NewUpdate().Model(&list).Column("name").Where("?0 = ?1 AND ?2 = ?3", bun.Ident("user_id"), user_id, bun.Ident("id"), list_id).Returning("*").Scan(ctx, &retList)
+	return retList, err
+}
+
+// DeleteList removes a list and either moves its tasks, with their subtasks,
+// to the trash or moves them to the inbox (no list).
+func (lr *ListRepository) DeleteList(ctx context.Context, list_id, user_id int, deleteTasks bool) error {
+	return idb(ctx, lr.db).RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var err error
+		if deleteTasks {
+			// Subtasks go along with their parents, whatever list they are in
+			listTasks := tx.NewSelect().Model((*models.Task)(nil)).Column("id").
+				Where("?0 = ?1 AND ?2 = ?3", bun.Ident("list_id"), list_id, bun.Ident("user_id"), user_id)
+			err = trashSubtrees(ctx, tx, listTasks, user_id)
+		} else {
+			_, err = tx.NewUpdate().Model((*models.Task)(nil)).Set("?0 = NULL", bun.Ident("list_id")).Where("?0 = ?1 AND ?2 = ?3", bun.Ident("list_id"), list_id, bun.Ident("user_id"), user_id).Exec(ctx)
+		}
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.NewDelete().Model((*models.List)(nil)).Where("?0 = ?1 AND ?2 = ?3", bun.Ident("id"), list_id, bun.Ident("user_id"), user_id).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+}
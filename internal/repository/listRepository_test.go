@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDeleteListTrashesSubtasks(t *testing.T) {
+	conn := &recordingConn{}
+	lr := NewListRepository(newRecordingDB(conn))
+
+	if err := lr.DeleteList(context.TODO(), 7, 1, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// The tasks of the list are the roots of the subtrees that are trashed
+	if n := conn.countQueries(`UPDATE "tasks"`, `FROM tasks WHERE id IN (SELECT "task"."id" FROM "tasks" AS "task" WHERE ("list_id" = 7 AND "user_id" = 1)`); n != 1 {
+		t.Errorf("Expected the subtrees of the list's tasks to be trashed, got queries: %v", conn.queries)
+	}
+	if n := conn.countQueries(`DELETE FROM "tasks"`, ""); n != 0 {
+		t.Errorf("Expected no tasks to be deleted for good, got queries: %v", conn.queries)
+	}
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/uptrace/bun"
+)
+
+type ReminderRepositoryInterface interface {
+	GetReminders(ctx context.Context, task_id, user_id int) ([]models.Reminder, error)
+	AddReminder(ctx context.Context, reminder models.Reminder) (models.Reminder, error)
+	DeleteReminder(ctx context.Context, reminder_id, task_id, user_id int) error
+	ClaimDueReminders(ctx context.Context, limit int, retryDelay time.Duration) ([]models.Notification, error)
+	MarkReminderDelivered(ctx context.Context, reminder_id int, channel string) error
+	MarkReminderSent(ctx context.Context, reminder_id int) error
+}
+
+type ReminderRepository struct {
+	db *bun.DB
+}
+
+func NewReminderRepository(db *bun.DB) *ReminderRepository {
+	return &ReminderRepository{db}
+}
+
+func (rr *ReminderRepository) GetReminders(ctx context.Context, task_id, user_id int) ([]models.Reminder, error) {
+	reminders := []models.Reminder{}
+	err := idb(ctx, rr.db).NewSelect().Model(&reminders).Where("?0 = ?1 AND ?2 = ?3", bun.Ident("task_id"), task_id, bun.Ident("user_id"), user_id).Order("id").Scan(ctx)
+	return reminders, err
+}
+
+func (rr *ReminderRepository) AddReminder(ctx context.Context, reminder models.Reminder) (models.Reminder, error) {
+	var retReminder models.Reminder
+	err := idb(ctx, rr.db).NewInsert().Model(&reminder).Returning("*").Scan(ctx, &retReminder)
+	return retReminder, err
+}
+
+func (rr *ReminderRepository) DeleteReminder(ctx context.Context, reminder_id, task_id, user_id int) error {
+	res, err := idb(ctx, rr.db).NewDelete().Model((*models.Reminder)(nil)).Where("?0 = ?1 AND ?2 = ?3 AND ?4 = ?5", bun.Ident("id"), reminder_id, bun.Ident("task_id"), task_id, bun.Ident("user_id"), user_id).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ClaimDueReminders claims up to limit reminders that are due and returns
+// them for delivery. The claim is committed right away and counts as an
+// attempt: the reminders are not due again before retryDelay has passed, so
+// concurrent callers (e.g. several API instances) never get the same reminder
+// and no lock is held during delivery. Reminders are given up after
+// models.ReminderMaxAttempts.
+func (rr *ReminderRepository) ClaimDueReminders(ctx context.Context, limit int, retryDelay time.Duration) ([]models.Notification, error) {
+	fireAt := "COALESCE(r.remind_at, t.due_at - make_interval(mins => r.offset_minutes))"
+
+	due := []models.Notification{}
+	err := idb(ctx, rr.db).NewRaw(`
+		WITH due AS (
+			SELECT r.id, `+fireAt+` AS fire_at
+			FROM reminders AS r
+			JOIN tasks AS t ON t.id = r.task_id
+			WHERE r.sent_at IS NULL AND r.attempts < ?0
+				AND (r.next_attempt_at IS NULL OR r.next_attempt_at <= now())
+				AND t.status <> ?1 AND t.deleted_at IS NULL
+				AND `+fireAt+` <= now()
+			ORDER BY fire_at
+			LIMIT ?2
+			FOR UPDATE OF r SKIP LOCKED
+		)
+		UPDATE reminders AS r
+		SET attempts = r.attempts + 1, next_attempt_at = now() + make_interval(secs => ?3)
+		FROM due, tasks AS t, users AS u
+		WHERE r.id = due.id AND t.id = r.task_id AND u.id = r.user_id
+		RETURNING r.id AS reminder_id, r.task_id, r.user_id, u.email, u.time_zone, t.title, t.description, t.due_at, due.fire_at, r.delivered`,
+		models.ReminderMaxAttempts, models.TaskStatusDone, limit, retryDelay.Seconds()).
+		Scan(ctx, &due)
+	return due, err
+}
+
+// MarkReminderDelivered records that the notifier named channel delivered a
+// reminder, so that retries skip it.
+func (rr *ReminderRepository) MarkReminderDelivered(ctx context.Context, reminder_id int, channel string) error {
+	_, err := idb(ctx, rr.db).NewUpdate().Model((*models.Reminder)(nil)).
+		Set("?0 = array_append(?0, ?1)", bun.Ident("delivered"), channel).
+		Where("?0 = ?1", bun.Ident("id"), reminder_id).
+		Exec(ctx)
+	return err
+}
+
+// MarkReminderSent marks a reminder as delivered through every notifier.
+func (rr *ReminderRepository) MarkReminderSent(ctx context.Context, reminder_id int) error {
+	_, err := idb(ctx, rr.db).NewUpdate().Model((*models.Reminder)(nil)).
+		Set("?0 = now()", bun.Ident("sent_at")).
+		Where("?0 = ?1", bun.Ident("id"), reminder_id).
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/uptrace/bun"
+)
+
+type TagRepositoryInterface interface {
+	GetTags(ctx context.Context, user_id int) ([]models.Tag, error)
+	AddTag(ctx context.Context, tag models.Tag) (models.Tag, error)
+	UpdateTag(ctx context.Context, tag models.Tag, tag_id, user_id int) (models.Tag, error)
+	DeleteTag(ctx context.Context, tag_id, user_id int) error
+}
+
+type TagRepository struct {
+	db *bun.DB
+}
+
+func NewTagRepository(db *bun.DB) *TagRepository {
+	return &TagRepository{db}
+}
+
+func (tr *TagRepository) GetTags(ctx context.Context, user_id int) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := idb(ctx, tr.db).NewSelect().Model(&tags).Where("?0 = ?1", bun.Ident("user_id"), user_id).Order("name").Scan(ctx)
+	return tags, err
+}
+
+func (tr *TagRepository) AddTag(ctx context.Context, tag models.Tag) (models.Tag, error) {
+	var retTag models.Tag
+	err := idb(ctx, tr.db).NewInsert().Model(&tag).Returning("*").Scan(ctx, &retTag)
+	if isUniqueViolation(err) {
+		return retTag, ErrDuplicate
+	}
+	return retTag, err
+}
+
+// UpdateTag renames a tag. The tasks carrying it count as updated, so that
+// clients syncing changes pick up the new name.
+func (tr *TagRepository) UpdateTag(ctx context.Context, tag models.Tag, tag_id, user_id int) (models.Tag, error) {
+	var retTag models.Tag
+	err := idb(ctx, tr.db).RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewUpdate().Model(&tag).Column("name").Where("?0 = ?1 AND ?2 = ?3", bun.Ident("user_id"), user_id, bun.Ident("id"), tag_id).Returning("*").Scan(ctx, &retTag)
+		if err != nil {
+			return err
+		}
+		return touchTaggedTasks(ctx, tx, tag_id)
+	})
+	if isUniqueViolation(err) {
+		return retTag, ErrDuplicate
+	}
+	return retTag, err
+}
+
+// DeleteTag deletes a tag, which the tasks carrying it lose. Those count as
+// updated, like on a rename.
+func (tr *TagRepository) DeleteTag(ctx context.Context, tag_id, user_id int) error {
+	return idb(ctx, tr.db).RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		// The tasks are looked up before the delete cascades to task_tags, and
+		// are left alone if the tag turns out to be missing or foreign
+		if err := touchTaggedTasks(ctx, tx, tag_id); err != nil {
+			return err
+		}
+
+		res, err := tx.NewDelete().Model((*models.Tag)(nil)).Where("?0 = ?1 AND ?2 = ?3", bun.Ident("id"), tag_id, bun.Ident("user_id"), user_id).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+}
+
+// touchTaggedTasks bumps the version and updated_at of the tasks carrying a
+// tag and logs their tags as changed.
+func touchTaggedTasks(ctx context.Context, db bun.IDB, tag_id int) error {
+	var tasks []models.Task
+	err := db.NewUpdate().Model((*models.Task)(nil)).Set("?0 = now()", bun.Ident("updated_at")).
+		Where("?0 IN (SELECT task_id FROM task_tags WHERE tag_id = ?1)", bun.Ident("id"), tag_id).
+		Returning("?0, ?1", bun.Ident("id"), bun.Ident("version")).Scan(ctx, &tasks)
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if err := logTagsChange(ctx, db, task.ID, task.Version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
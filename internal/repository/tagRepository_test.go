@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+)
+
+func TestUpdateTagTouchesTasks(t *testing.T) {
+	conn := &recordingConn{returning: func(query string) ([]string, [][]driver.Value) {
+		switch {
+		case strings.HasPrefix(query, `UPDATE "tags"`):
+			return []string{"id", "user_id", "name"}, [][]driver.Value{{int64(3), int64(1), "errands"}}
+		case strings.HasPrefix(query, `UPDATE "tasks"`):
+			return []string{"id", "version"}, [][]driver.Value{{int64(5), int64(3)}, {int64(6), int64(2)}}
+		}
+		return []string{"id"}, nil
+	}}
+	tr := NewTagRepository(newRecordingDB(conn))
+
+	tag, err := tr.UpdateTag(context.TODO(), models.Tag{Name: "errands"}, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.Name != "errands" {
+		t.Errorf("Unexpected tag: %+v", tag)
+	}
+
+	if n := conn.countQueries(`UPDATE "tasks"`, `"updated_at" = now()`); n != 1 {
+		t.Errorf("Expected the tagged tasks to be touched once, got queries: %v", conn.queries)
+	}
+	if n := conn.countQueries(`UPDATE task_changes`, `'tags'`); n != 2 {
+		t.Errorf("Expected the tags of 2 tasks to be logged, got queries: %v", conn.queries)
+	}
+}
@@ -2,17 +2,45 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/NeGat1FF/todolist-api/internal/models"
 	"github.com/uptrace/bun"
 )
 
 type TaskRepositoryInterface interface {
-	GetTasks(ctx context.Context, user_id int, page int, limit int) ([]models.Task, error)
+	RunInTx(ctx context.Context, fn func(ctx context.Context) error) error
+	GetTasks(ctx context.Context, user_id int, filter models.TaskFilter, page int, limit int) ([]models.Task, int, error)
+	SearchTasks(ctx context.Context, user_id int, query string, archived string, page int, limit int) ([]models.TaskSearchResult, int, error)
 	GetTaskByID(ctx context.Context, task_id int) (models.Task, error)
+	LockTask(ctx context.Context, task_id int) (models.Task, error)
 	AddTask(ctx context.Context, task models.Task) (models.Task, error)
-	UpdateTask(ctx context.Context, task models.Task, task_id, user_id int) (models.Task, error)
-	DeleteTask(ctx context.Context, task_id, user_id int) error
+	UpdateTask(ctx context.Context, task models.Task, task_id, user_id int, version int64) (models.Task, error)
+	UpdateTaskFields(ctx context.Context, task models.Task, fields []string, task_id, user_id int, version int64) (models.Task, error)
+	DeleteTask(ctx context.Context, task_id, user_id int, version int64) error
+	ArchiveTask(ctx context.Context, task_id, user_id int) (models.Task, error)
+	UnarchiveTask(ctx context.Context, task_id, user_id int) (models.Task, error)
+	ArchiveCompleted(ctx context.Context, user_id int, list_id *int, inbox bool) (int, error)
+	GetTrash(ctx context.Context, user_id int, page int, limit int) ([]models.Task, int, error)
+	RestoreTask(ctx context.Context, task_id, user_id int) (models.Task, error)
+	PurgeTask(ctx context.Context, task_id, user_id int) error
+	EmptyTrash(ctx context.Context, user_id int) (int, error)
+	PurgeTrash(ctx context.Context, before time.Time) (int, error)
+	SetTaskStatus(ctx context.Context, task_id, user_id int, status string) (models.Task, error)
+	MoveTask(ctx context.Context, task_id, user_id int, list_id *int) (models.Task, error)
+	GetTaskDescendants(ctx context.Context, user_id int, root_ids []int, archived string) ([]models.Task, error)
+	GetAncestorIDs(ctx context.Context, task_id int) ([]int, error)
+	GetSubtreeDepth(ctx context.Context, task_id int) (int, error)
+	GetFieldChanges(ctx context.Context, task_id int, since_version int64) (map[string]time.Time, error)
+	GetChangesSince(ctx context.Context, user_id int, since uint64) ([]models.Task, []int, uint64, error)
+	GetChangeLogHorizon(ctx context.Context) (uint64, time.Time, error)
+	GetPurgedTaskIDs(ctx context.Context, user_id int, since time.Time) ([]int, error)
+	PurgeChanges(ctx context.Context, before time.Time) (int, error)
 }
 
 type TaskRepository struct {
@@ -23,31 +51,782 @@ func NewTaskRepository(db *bun.DB) *TaskRepository {
 	return &TaskRepository{db}
 }
 
-func (tr *TaskRepository) GetTasks(ctx context.Context, user_id int, page int, limit int) ([]models.Task, error) {
+// RunInTx runs fn in a transaction that all repository calls made with its
+// context take part in. Nested calls run in a savepoint.
+func (tr *TaskRepository) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return runInTx(ctx, tr.db, fn)
+}
+
+// GetTasks returns a page of the user's tasks matching filter together with
+// the number of matching tasks on all pages. In cursor mode (filter.After
+// set) nothing is counted: total is the number of tasks on the page, plus one
+// if more tasks follow.
+func (tr *TaskRepository) GetTasks(ctx context.Context, user_id int, filter models.TaskFilter, page int, limit int) ([]models.Task, int, error) {
 	var tasks []models.Task
-	err := tr.db.NewSelect().Model((*models.Task)(nil)).Where("?0 = ?1", bun.Ident("user_id"), user_id).Order("id").Limit(limit).Offset((page-1)*limit).Scan(ctx, &tasks)
-	return tasks, err
+	q := withProgress(idb(ctx, tr.db).NewSelect().Model(&tasks).Relation("Tags", orderTags)).Where("?0 = ?1", bun.Ident("user_id"), user_id)
+	if filter.Status != "" {
+		q = q.Where("?0 = ?1", bun.Ident("status"), filter.Status)
+	}
+	if filter.Inbox {
+		q = q.Where("?0 IS NULL", bun.Ident("list_id"))
+	} else if filter.ListID != nil {
+		q = q.Where("?0 = ?1", bun.Ident("list_id"), *filter.ListID)
+	}
+	if filter.TopLevel {
+		q = q.Where("?0 IS NULL", bun.Ident("parent_id"))
+	} else if filter.ParentID != nil {
+		q = q.Where("?0 = ?1", bun.Ident("parent_id"), *filter.ParentID)
+	}
+	if filter.DueFrom != nil {
+		q = q.Where("?0 >= ?1", bun.Ident("due_at"), *filter.DueFrom)
+	}
+	if filter.DueBefore != nil {
+		q = q.Where("?0 < ?1", bun.Ident("due_at"), *filter.DueBefore)
+	}
+	q = whereArchived(q, filter.Archived)
+	if filter.UpdatedSince != nil {
+		// Deleted tasks are returned as tombstones carrying deleted_at
+		q = q.WhereAllWithDeleted().Where("?0 > ?1", bun.Ident("updated_at"), *filter.UpdatedSince)
+	}
+	if filter.Overdue {
+		q = q.Where("?0 < now() AND ?1 <> ?2", bun.Ident("due_at"), bun.Ident("status"), models.TaskStatusDone)
+	}
+	if len(filter.Tags) > 0 {
+		tagged := idb(ctx, tr.db).NewSelect().TableExpr("task_tags AS tt").Column("tt.task_id").
+			Join("JOIN tags AS t ON t.id = tt.tag_id").
+			Where("t.user_id = ? AND t.name IN (?)", user_id, bun.In(filter.Tags))
+		if filter.TagMode != models.TagModeAny {
+			tagged = tagged.Group("tt.task_id").Having("COUNT(DISTINCT t.id) = ?", len(filter.Tags))
+		}
+		q = q.Where("?0 IN (?1)", bun.Ident("id"), tagged)
+	}
+	q, err := orderTasks(q, filter.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+	if filter.After != nil {
+		q, err = afterTask(q, filter.Sort, filter.After)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// Counting would read every task after the cursor, while one task
+		// past the limit tells whether there is a next page
+		err = q.Limit(limit + 1).Scan(ctx)
+		total := len(tasks)
+		if len(tasks) > limit {
+			tasks = tasks[:limit]
+		}
+		return tasks, total, err
+	}
+	total, err := q.Offset((page - 1) * limit).Limit(limit).ScanAndCount(ctx)
+	return tasks, total, err
+}
+
+// whereArchived restricts q according to one of the models.TaskArchived* modes.
+func whereArchived(q *bun.SelectQuery, archived string) *bun.SelectQuery {
+	switch archived {
+	case models.TaskArchivedInclude:
+		return q
+	case models.TaskArchivedOnly:
+		return q.Where("?0 IS NOT NULL", bun.Ident("archived_at"))
+	}
+	return q.Where("?0 IS NULL", bun.Ident("archived_at"))
+}
+
+// searchHeadline configures the snippets returned by ts_headline.
+const searchHeadline = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+// SearchTasks finds tasks whose title or description match query (in
+// websearch_to_tsquery syntax), best matches first, among the tasks selected
+// by one of the models.TaskArchived* modes. Like GetTasks it also returns the
+// number of matches on all pages.
+func (tr *TaskRepository) SearchTasks(ctx context.Context, user_id int, query string, archived string, page int, limit int) ([]models.TaskSearchResult, int, error) {
+	var results []models.TaskSearchResult
+	tsquery := "websearch_to_tsquery('english', ?)"
+	q := withProgress(idb(ctx, tr.db).NewSelect().Model(&results).Relation("Tags", orderTags)).
+		ColumnExpr("ts_rank(?TableAlias.search_vector, "+tsquery+") AS rank", query).
+		ColumnExpr("ts_headline('english', ?TableAlias.title, "+tsquery+", ?) AS title_highlight", query, searchHeadline).
+		ColumnExpr("ts_headline('english', coalesce(?TableAlias.description, ''), "+tsquery+", ?) AS description_highlight", query, searchHeadline).
+		Where("?0 = ?1", bun.Ident("user_id"), user_id).
+		Where("?TableAlias.search_vector @@ "+tsquery, query)
+	total, err := whereArchived(q, archived).
+		OrderExpr("rank DESC").
+		OrderExpr("?0 ASC", bun.Ident("id")).
+		Limit(limit).Offset((page - 1) * limit).
+		ScanAndCount(ctx)
+	return results, total, err
+}
+
+// withProgress selects the task columns together with the done/total counts
+// of its direct subtasks.
+func withProgress(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.ColumnExpr("?TableAlias.*").
+		ColumnExpr("(SELECT count(*) FROM tasks AS sub WHERE sub.parent_id = ?TableAlias.id AND sub.deleted_at IS NULL) AS subtasks_total").
+		ColumnExpr("(SELECT count(*) FROM tasks AS sub WHERE sub.parent_id = ?TableAlias.id AND sub.deleted_at IS NULL AND sub.status = ?) AS subtasks_done", models.TaskStatusDone)
+}
+
+// orderTasks applies the requested sort keys, always ending with id so that
+// pages are stable. Only whitelisted fields are accepted.
+func orderTasks(q *bun.SelectQuery, sort []models.TaskSort) (*bun.SelectQuery, error) {
+	for _, s := range models.TaskSortKeys(sort) {
+		if !models.TaskSortFields[s.Field] {
+			return nil, fmt.Errorf("cannot sort by %q", s.Field)
+		}
+		if s.Desc {
+			q = q.OrderExpr("?0 DESC NULLS LAST", bun.Ident(s.Field))
+		} else {
+			q = q.OrderExpr("?0 ASC NULLS LAST", bun.Ident(s.Field))
+		}
+	}
+	return q, nil
+}
+
+// afterTask restricts q to the tasks ordered after the task with the given
+// sort key values in the order applied by orderTasks. For keys k1..kn it
+// matches rows where, for some i, k1..k(i-1) equal the values and ki comes
+// later; NULL sorts last, so nothing comes after a NULL value.
+func afterTask(q *bun.SelectQuery, sort []models.TaskSort, values []*string) (*bun.SelectQuery, error) {
+	keys := models.TaskSortKeys(sort)
+	if len(values) != len(keys) {
+		return nil, fmt.Errorf("expected %d cursor values, got %d", len(keys), len(values))
+	}
+
+	return q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+		for i, key := range keys {
+			if values[i] == nil {
+				continue
+			}
+
+			var conds []string
+			var args []any
+			for j := range i {
+				conds = append(conds, "? IS NOT DISTINCT FROM ?")
+				args = append(args, bun.Ident(keys[j].Field), values[j])
+			}
+
+			op := ">"
+			if key.Desc {
+				op = "<"
+			}
+			conds = append(conds, "(? "+op+" ? OR ? IS NULL)")
+			args = append(args, bun.Ident(key.Field), *values[i], bun.Ident(key.Field))
+
+			q = q.WhereOr(strings.Join(conds, " AND "), args...)
+		}
+		return q
+	}), nil
 }
 
 func (tr *TaskRepository) GetTaskByID(ctx context.Context, task_id int) (models.Task, error) {
 	var task models.Task
-	err := tr.db.NewSelect().Model(&task).Where("?0 = ?1", bun.Ident("id"), task_id).Scan(ctx, &task)
+	err := withProgress(idb(ctx, tr.db).NewSelect().Model(&task).Relation("Tags", orderTags)).Where("?0 = ?1", bun.Ident("id"), task_id).Scan(ctx)
+	return task, err
+}
+
+// LockTask returns a task without its tags and progress, locked until the end
+// of the transaction of ctx.
+func (tr *TaskRepository) LockTask(ctx context.Context, task_id int) (models.Task, error) {
+	var task models.Task
+	err := idb(ctx, tr.db).NewSelect().Model(&task).Where("?0 = ?1", bun.Ident("id"), task_id).For("UPDATE").Scan(ctx)
 	return task, err
 }
 
+// AddTask inserts a task with its tags. It returns ErrMissingReference when
+// the list or parent task does not exist, and ErrInvalidRecord when the task
+// violates a check constraint.
 func (tr *TaskRepository) AddTask(ctx context.Context, task models.Task) (models.Task, error) {
 	var retTask models.Task
-	err := tr.db.NewInsert().Model(&task).Returning("*").Scan(ctx, &retTask)
+	err := idb(ctx, tr.db).RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewInsert().Model(&task).Returning("*").Scan(ctx, &retTask)
+		if err != nil {
+			return err
+		}
+
+		retTask.Tags, err = setTaskTags(ctx, tx, retTask.ID, retTask.UserID, task.Tags)
+		return err
+	})
+	switch {
+	case isForeignKeyViolation(err):
+		return retTask, ErrMissingReference
+	case isCheckViolation(err):
+		return retTask, ErrInvalidRecord
+	}
 	return retTask, err
 }
 
-func (tr *TaskRepository) UpdateTask(ctx context.Context, task models.Task, task_id, user_id int) (models.Task, error) {
+// UpdateTask updates the fields set in task. A version other than 0 makes the
+// update conditional on the task still being at that version; sql.ErrNoRows
+// is returned otherwise.
+func (tr *TaskRepository) UpdateTask(ctx context.Context, task models.Task, task_id, user_id int, version int64) (models.Task, error) {
 	var retTask models.Task
-	err := tr.db.NewUpdate().Model(&task).OmitZero().Where("?0 = ?1 AND ?2 = ?3", bun.Ident("user_id"), user_id, bun.Ident("id"), task_id).Returning("*").Scan(ctx, &retTask)
+	err := idb(ctx, tr.db).RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var err error
+		if hasColumnUpdates(task) {
+			q := tx.NewUpdate().Model(&task).OmitZero()
+			if task.Status != "" {
+				// Keep completed_at in step with the new status
+				q = q.Value("completed_at", "CASE WHEN ?0 = ?1 THEN COALESCE(?2, now()) ELSE NULL END", task.Status, models.TaskStatusDone, bun.Ident("completed_at"))
+			}
+			err = whereVersion(q.Where("?0 = ?1 AND ?2 = ?3", bun.Ident("user_id"), user_id, bun.Ident("id"), task_id), version).Returning("*").Scan(ctx, &retTask)
+		} else {
+			// Only the tags change, but the task still has to exist and be
+			// owned by the user, and counts as updated
+			q := tx.NewUpdate().Model(&retTask).Set("?0 = now()", bun.Ident("updated_at")).
+				Where("?0 = ?1 AND ?2 = ?3", bun.Ident("user_id"), user_id, bun.Ident("id"), task_id)
+			err = whereVersion(q, version).Returning("*").Scan(ctx)
+		}
+		if err != nil {
+			return err
+		}
+
+		if task.DueAt != nil {
+			if err := rearmReminders(ctx, tx, task_id); err != nil {
+				return err
+			}
+		}
+
+		if task.Tags != nil {
+			retTask.Tags, err = setTaskTags(ctx, tx, task_id, user_id, task.Tags)
+			if err != nil {
+				return err
+			}
+			return logTagsChange(ctx, tx, task_id, retTask.Version)
+		}
+
+		retTask.Tags, err = getTaskTags(ctx, tx, task_id)
+		return err
+	})
 	return retTask, err
 }
 
-func (tr *TaskRepository) DeleteTask(ctx context.Context, task_id, user_id int) error {
-	_, err := tr.db.NewDelete().Model((*models.Task)(nil)).Where("?0 = ?1 AND ?2 = ?3", bun.Ident("id"), task_id, bun.Ident("user_id"), user_id).Exec(ctx)
+// UpdateTaskFields sets the named fields (see models.TaskSyncFields) of a
+// task to their values in task, NULLs included, provided the task is still
+// at version. It returns sql.ErrNoRows when the task is gone or was changed
+// in the meantime.
+func (tr *TaskRepository) UpdateTaskFields(ctx context.Context, task models.Task, fields []string, task_id, user_id int, version int64) (models.Task, error) {
+	var retTask models.Task
+	err := idb(ctx, tr.db).RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		columns := make([]string, 0, len(fields))
+		tags, dueAt := false, false
+		for _, field := range fields {
+			switch field {
+			case "tags":
+				tags = true
+			case "due_at":
+				dueAt = true
+				columns = append(columns, field)
+			default:
+				columns = append(columns, field)
+			}
+		}
+
+		q := tx.NewUpdate().Model(&task)
+		if task.Status != "" {
+			// Keep completed_at in step with the new status
+			columns = append(columns, "completed_at")
+			q = q.Value("completed_at", "CASE WHEN ?0 = ?1 THEN COALESCE(?2, now()) ELSE NULL END", task.Status, models.TaskStatusDone, bun.Ident("completed_at"))
+		}
+		if len(columns) > 0 {
+			q = q.Column(columns...)
+		} else {
+			q = q.Set("?0 = now()", bun.Ident("updated_at"))
+		}
+		err := q.Where("?0 = ?1 AND ?2 = ?3 AND ?4 = ?5", bun.Ident("user_id"), user_id, bun.Ident("id"), task_id, bun.Ident("version"), version).
+			Returning("*").Scan(ctx, &retTask)
+		if err != nil {
+			return err
+		}
+
+		if dueAt {
+			if err := rearmReminders(ctx, tx, task_id); err != nil {
+				return err
+			}
+		}
+
+		if tags {
+			retTask.Tags, err = setTaskTags(ctx, tx, task_id, user_id, task.Tags)
+			if err != nil {
+				return err
+			}
+			return logTagsChange(ctx, tx, task_id, retTask.Version)
+		}
+
+		retTask.Tags, err = getTaskTags(ctx, tx, task_id)
+		return err
+	})
+	return retTask, err
+}
+
+// rearmReminders makes the reminders relative to the due date of a task fire
+// again, for when the due date has changed.
+func rearmReminders(ctx context.Context, db bun.IDB, task_id int) error {
+	_, err := db.NewUpdate().Model((*models.Reminder)(nil)).
+		Set("?0 = NULL, ?1 = 0, ?2 = NULL, ?3 = '{}'", bun.Ident("sent_at"), bun.Ident("attempts"), bun.Ident("next_attempt_at"), bun.Ident("delivered")).
+		Where("?0 = ?1 AND ?2 IS NOT NULL", bun.Ident("task_id"), task_id, bun.Ident("offset_minutes")).
+		Exec(ctx)
+	return err
+}
+
+// logTagsChange adds the tags to the change log entry of a task version. The
+// entry itself is written by a trigger, which cannot see the task_tags
+// changes made afterwards.
+func logTagsChange(ctx context.Context, db bun.IDB, task_id int, version int64) error {
+	_, err := db.NewUpdate().TableExpr("task_changes").
+		Set("fields = array_append(fields, 'tags')").
+		Where("task_id = ? AND version = ?", task_id, version).
+		Exec(ctx)
+	return err
+}
+
+// whereVersion restricts an update to a task at version, unless version is 0.
+func whereVersion(q *bun.UpdateQuery, version int64) *bun.UpdateQuery {
+	if version == 0 {
+		return q
+	}
+	return q.Where("?0 = ?1", bun.Ident("version"), version)
+}
+
+// hasColumnUpdates reports whether task sets any column besides its relations.
+func hasColumnUpdates(task models.Task) bool {
+	task.Tags = nil
+	return !reflect.DeepEqual(task, models.Task{})
+}
+
+// setTaskTags replaces the tags of a task, creating any of the user's tags
+// that do not exist yet.
+func setTaskTags(ctx context.Context, db bun.IDB, task_id, user_id int, tags []models.Tag) ([]models.Tag, error) {
+	_, err := db.NewDelete().Model((*models.TaskTag)(nil)).Where("?0 = ?1", bun.Ident("task_id"), task_id).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userTags := make([]models.Tag, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		if seen[tag.Name] {
+			continue
+		}
+		seen[tag.Name] = true
+		userTags = append(userTags, models.Tag{UserID: user_id, Name: tag.Name})
+	}
+
+	if len(userTags) == 0 {
+		return []models.Tag{}, nil
+	}
+
+	err = db.NewInsert().Model(&userTags).
+		On("CONFLICT (user_id, name) DO UPDATE").Set("name = EXCLUDED.name").
+		Returning("*").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]models.TaskTag, len(userTags))
+	for i, tag := range userTags {
+		links[i] = models.TaskTag{TaskID: task_id, TagID: tag.ID}
+	}
+	_, err = db.NewInsert().Model(&links).Exec(ctx)
+	return userTags, err
+}
+
+func getTaskTags(ctx context.Context, db bun.IDB, task_id int) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	err := db.NewSelect().Model(&tags).
+		Where("?TableAlias.id IN (SELECT tag_id FROM task_tags WHERE task_id = ?)", task_id).
+		OrderExpr("?TableAlias.name").Scan(ctx)
+	return tags, err
+}
+
+func orderTags(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.OrderExpr("?TableAlias.name")
+}
+
+// DeleteTask moves a task and all its subtasks to the trash. It returns
+// sql.ErrNoRows when the task does not exist or, with a version other than 0,
+// is no longer at that version.
+func (tr *TaskRepository) DeleteTask(ctx context.Context, task_id, user_id int, version int64) error {
+	return idb(ctx, tr.db).RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		// The task itself has to exist, and be at version unless that is 0
+		q := tx.NewSelect().Model((*models.Task)(nil)).Column("id").
+			Where("?0 = ?1 AND ?2 = ?3", bun.Ident("id"), task_id, bun.Ident("user_id"), user_id)
+		if version != 0 {
+			q = q.Where("?0 = ?1", bun.Ident("version"), version)
+		}
+		var id int
+		if err := q.For("UPDATE").Scan(ctx, &id); err != nil {
+			return err
+		}
+
+		return trashSubtrees(ctx, tx, task_id, user_id)
+	})
+}
+
+// trashSubtrees moves task roots, or the tasks selected by the query roots,
+// to the trash together with all their subtasks.
+func trashSubtrees(ctx context.Context, db bun.IDB, roots any, user_id int) error {
+	_, err := db.NewUpdate().Model((*models.Task)(nil)).
+		Set("?0 = now()", bun.Ident("deleted_at")).
+		Where("?0 = ?1", bun.Ident("user_id"), user_id).
+		Where(inSubtree, bun.Ident("id"), roots, models.TaskMaxDepth).
+		Exec(ctx)
 	return err
 }
+
+// inSubtree matches the id column ?0 against task ?1, or the tasks selected
+// by the query ?1, and all their subtasks up to ?2 levels deep.
+const inSubtree = `?0 IN (
+	WITH RECURSIVE tree AS (
+		SELECT id, 0 AS depth FROM tasks WHERE id IN (?1)
+		UNION ALL
+		SELECT t.id, tree.depth + 1 FROM tasks AS t JOIN tree ON t.parent_id = tree.id WHERE tree.depth < ?2
+	) SELECT id FROM tree)`
+
+// ArchiveTask archives a task together with its subtasks. Archiving an
+// archived task leaves it as it is.
+func (tr *TaskRepository) ArchiveTask(ctx context.Context, task_id, user_id int) (models.Task, error) {
+	var retTask models.Task
+	err := idb(ctx, tr.db).RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().Model((*models.Task)(nil)).
+			Set("?0 = now()", bun.Ident("archived_at")).
+			Where("?0 = ?1 AND ?2 IS NULL", bun.Ident("user_id"), user_id, bun.Ident("archived_at")).
+			Where(inSubtree, bun.Ident("id"), task_id, models.TaskMaxDepth).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return withProgress(tx.NewSelect().Model(&retTask).Relation("Tags", orderTags)).Where("?0 = ?1 AND ?2 = ?3", bun.Ident("id"), task_id, bun.Ident("user_id"), user_id).Scan(ctx)
+	})
+	return retTask, err
+}
+
+// UnarchiveTask takes a task out of the archive together with the subtasks
+// that were archived along with it.
+func (tr *TaskRepository) UnarchiveTask(ctx context.Context, task_id, user_id int) (models.Task, error) {
+	var retTask models.Task
+	err := idb(ctx, tr.db).RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var task models.Task
+		err := tx.NewSelect().Model(&task).
+			Where("?0 = ?1 AND ?2 = ?3", bun.Ident("id"), task_id, bun.Ident("user_id"), user_id).
+			For("UPDATE").Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		if task.ArchivedAt != nil {
+			_, err = tx.NewUpdate().Model((*models.Task)(nil)).
+				Set("?0 = NULL", bun.Ident("archived_at")).
+				Where("?0 = ?1 AND ?2 = ?3", bun.Ident("user_id"), user_id, bun.Ident("archived_at"), *task.ArchivedAt).
+				Where(inSubtree, bun.Ident("id"), task_id, models.TaskMaxDepth).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return withProgress(tx.NewSelect().Model(&retTask).Relation("Tags", orderTags)).Where("?0 = ?1", bun.Ident("id"), task_id).Scan(ctx)
+	})
+	return retTask, err
+}
+
+// ArchiveCompleted archives all done tasks of the user, with their subtasks,
+// in one list, in the inbox or (with neither) everywhere. It returns the
+// number of archived tasks.
+func (tr *TaskRepository) ArchiveCompleted(ctx context.Context, user_id int, list_id *int, inbox bool) (int, error) {
+	done := idb(ctx, tr.db).NewSelect().Model((*models.Task)(nil)).Column("id").
+		Where("?0 = ?1 AND ?2 = ?3", bun.Ident("user_id"), user_id, bun.Ident("status"), models.TaskStatusDone)
+	if inbox {
+		done = done.Where("?0 IS NULL", bun.Ident("list_id"))
+	} else if list_id != nil {
+		done = done.Where("?0 = ?1", bun.Ident("list_id"), *list_id)
+	}
+
+	res, err := idb(ctx, tr.db).NewUpdate().Model((*models.Task)(nil)).
+		Set("?0 = now()", bun.Ident("archived_at")).
+		Where("?0 = ?1 AND ?2 IS NULL", bun.Ident("user_id"), user_id, bun.Ident("archived_at")).
+		Where(inSubtree, bun.Ident("id"), done, models.TaskMaxDepth).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// GetTrash returns a page of the user's trashed tasks, most recently deleted first.
+func (tr *TaskRepository) GetTrash(ctx context.Context, user_id int, page int, limit int) ([]models.Task, int, error) {
+	var tasks []models.Task
+	total, err := idb(ctx, tr.db).NewSelect().Model(&tasks).Relation("Tags", orderTags).WhereDeleted().
+		Where("?0 = ?1", bun.Ident("user_id"), user_id).
+		OrderExpr("?0 DESC, ?1 ASC", bun.Ident("deleted_at"), bun.Ident("id")).
+		Limit(limit).Offset((page - 1) * limit).
+		ScanAndCount(ctx)
+	return tasks, total, err
+}
+
+// RestoreTask takes a task out of the trash together with the subtasks that
+// were deleted along with it. A task whose parent is still in the trash is
+// restored as a top-level task.
+func (tr *TaskRepository) RestoreTask(ctx context.Context, task_id, user_id int) (models.Task, error) {
+	var retTask models.Task
+	err := idb(ctx, tr.db).RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var task models.Task
+		err := tx.NewSelect().Model(&task).WhereDeleted().
+			Where("?0 = ?1 AND ?2 = ?3", bun.Ident("id"), task_id, bun.Ident("user_id"), user_id).
+			For("UPDATE").Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().Model((*models.Task)(nil)).WhereDeleted().
+			Set("?0 = NULL", bun.Ident("deleted_at")).
+			Where("?0 = ?1", bun.Ident("user_id"), user_id).
+			Where(`?0 IN (
+				WITH RECURSIVE tree AS (
+					SELECT id, 0 AS depth FROM tasks WHERE id = ?1
+					UNION ALL
+					SELECT t.id, tree.depth + 1 FROM tasks AS t JOIN tree ON t.parent_id = tree.id WHERE t.deleted_at = ?2 AND tree.depth < ?3
+				) SELECT id FROM tree)`, bun.Ident("id"), task_id, *task.DeletedAt, models.TaskMaxDepth).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().Model((*models.Task)(nil)).
+			Set("?0 = NULL", bun.Ident("parent_id")).
+			Where("?0 = ?1", bun.Ident("id"), task_id).
+			Where("?0 IN (SELECT id FROM tasks WHERE deleted_at IS NOT NULL)", bun.Ident("parent_id")).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return withProgress(tx.NewSelect().Model(&retTask).Relation("Tags", orderTags)).Where("?0 = ?1", bun.Ident("id"), task_id).Scan(ctx)
+	})
+	return retTask, err
+}
+
+// PurgeTask permanently deletes a trashed task.
+func (tr *TaskRepository) PurgeTask(ctx context.Context, task_id, user_id int) error {
+	res, err := idb(ctx, tr.db).NewDelete().Model((*models.Task)(nil)).WhereDeleted().ForceDelete().
+		Where("?0 = ?1 AND ?2 = ?3", bun.Ident("id"), task_id, bun.Ident("user_id"), user_id).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// EmptyTrash permanently deletes all trashed tasks of the user.
+func (tr *TaskRepository) EmptyTrash(ctx context.Context, user_id int) (int, error) {
+	res, err := idb(ctx, tr.db).NewDelete().Model((*models.Task)(nil)).WhereDeleted().ForceDelete().
+		Where("?0 = ?1", bun.Ident("user_id"), user_id).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// PurgeTrash permanently deletes the tasks of all users that were trashed
+// before the given time.
+func (tr *TaskRepository) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	res, err := idb(ctx, tr.db).NewDelete().Model((*models.Task)(nil)).WhereDeleted().ForceDelete().
+		Where("?0 < ?1", bun.Ident("deleted_at"), before).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func (tr *TaskRepository) SetTaskStatus(ctx context.Context, task_id, user_id int, status string) (models.Task, error) {
+	var retTask models.Task
+	err := idb(ctx, tr.db).NewUpdate().Model((*models.Task)(nil)).
+		Set("?0 = ?1", bun.Ident("status"), status).
+		Set("?0 = CASE WHEN ?1 = ?2 THEN COALESCE(?0, now()) ELSE NULL END", bun.Ident("completed_at"), status, models.TaskStatusDone).
+		Where("?0 = ?1 AND ?2 = ?3", bun.Ident("user_id"), user_id, bun.Ident("id"), task_id).
+		Returning("*").Scan(ctx, &retTask)
+	if err != nil {
+		return retTask, err
+	}
+
+	retTask.Tags, err = getTaskTags(ctx, idb(ctx, tr.db), task_id)
+	return retTask, err
+}
+
+// MoveTask puts a task into another list, or into the inbox when list_id is nil.
+func (tr *TaskRepository) MoveTask(ctx context.Context, task_id, user_id int, list_id *int) (models.Task, error) {
+	var retTask models.Task
+	err := idb(ctx, tr.db).NewUpdate().Model((*models.Task)(nil)).
+		Set("?0 = ?1", bun.Ident("list_id"), list_id).
+		Where("?0 = ?1 AND ?2 = ?3", bun.Ident("user_id"), user_id, bun.Ident("id"), task_id).
+		Returning("*").Scan(ctx, &retTask)
+	if err != nil {
+		return retTask, err
+	}
+
+	retTask.Tags, err = getTaskTags(ctx, idb(ctx, tr.db), task_id)
+	return retTask, err
+}
+
+// GetTaskDescendants returns every task below the given roots, flattened.
+func (tr *TaskRepository) GetTaskDescendants(ctx context.Context, user_id int, root_ids []int, archived string) ([]models.Task, error) {
+	tasks := []models.Task{}
+	if len(root_ids) == 0 {
+		return tasks, nil
+	}
+
+	q := withProgress(idb(ctx, tr.db).NewSelect().Model(&tasks).Relation("Tags", orderTags)).
+		Where("?0 = ?1", bun.Ident("user_id"), user_id).
+		Where(`?0 IN (
+			WITH RECURSIVE tree AS (
+				SELECT id, 1 AS depth FROM tasks WHERE parent_id IN (?1)
+				UNION ALL
+				SELECT t.id, tree.depth + 1 FROM tasks AS t JOIN tree ON t.parent_id = tree.id WHERE tree.depth < ?2
+			) SELECT id FROM tree)`, bun.Ident("id"), bun.In(root_ids), models.TaskMaxDepth)
+	err := whereArchived(q, archived).OrderExpr("?0 ASC", bun.Ident("id")).Scan(ctx)
+	return tasks, err
+}
+
+// GetAncestorIDs returns the ids of the parent chain of a task, nearest first.
+func (tr *TaskRepository) GetAncestorIDs(ctx context.Context, task_id int) ([]int, error) {
+	ids := []int{}
+	err := idb(ctx, tr.db).NewRaw(`
+		WITH RECURSIVE chain AS (
+			SELECT parent_id AS id, 1 AS depth FROM tasks WHERE id = ?0
+			UNION ALL
+			SELECT t.parent_id, chain.depth + 1 FROM tasks AS t JOIN chain ON t.id = chain.id WHERE chain.depth <= ?1
+		) SELECT id FROM chain WHERE id IS NOT NULL ORDER BY depth`, task_id, models.TaskMaxDepth).Scan(ctx, &ids)
+	return ids, err
+}
+
+// GetSubtreeDepth returns how many levels of subtasks are below a task, 0 for a leaf.
+func (tr *TaskRepository) GetSubtreeDepth(ctx context.Context, task_id int) (int, error) {
+	var depth int
+	err := idb(ctx, tr.db).NewRaw(`
+		WITH RECURSIVE tree AS (
+			SELECT id, 1 AS depth FROM tasks WHERE parent_id = ?0 AND deleted_at IS NULL
+			UNION ALL
+			SELECT t.id, tree.depth + 1 FROM tasks AS t JOIN tree ON t.parent_id = tree.id WHERE t.deleted_at IS NULL AND tree.depth <= ?1
+		) SELECT COALESCE(MAX(depth), 0) FROM tree`, task_id, models.TaskMaxDepth).Scan(ctx, &depth)
+	return depth, err
+}
+
+// GetFieldChanges returns the fields of a task changed after since_version,
+// each with the time of its latest change. It returns ErrChangeLogPurged when
+// the change right after since_version has been purged from the log.
+func (tr *TaskRepository) GetFieldChanges(ctx context.Context, task_id int, since_version int64) (map[string]time.Time, error) {
+	logged, err := idb(ctx, tr.db).NewSelect().TableExpr("task_changes").
+		Where("task_id = ? AND version = ?", task_id, since_version+1).
+		Exists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !logged {
+		return nil, ErrChangeLogPurged
+	}
+
+	var rows []struct {
+		Field     string    `bun:"field"`
+		ChangedAt time.Time `bun:"changed_at"`
+	}
+	err = idb(ctx, tr.db).NewSelect().TableExpr("task_changes").
+		ColumnExpr("unnest(fields) AS field").
+		ColumnExpr("changed_at").
+		Where("task_id = ? AND version > ?", task_id, since_version).
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]time.Time)
+	for _, row := range rows {
+		if row.ChangedAt.After(changes[row.Field]) {
+			changes[row.Field] = row.ChangedAt
+		}
+	}
+	return changes, nil
+}
+
+// GetChangesSince returns the user's tasks changed since the change log
+// position since, deleted ones included, and the ids of changed tasks that no
+// longer exist. Position 0 returns all tasks. The returned position is where
+// the next call should continue.
+func (tr *TaskRepository) GetChangesSince(ctx context.Context, user_id int, since uint64) ([]models.Task, []int, uint64, error) {
+	tasks := []models.Task{}
+	deleted := []int{}
+	var next int64
+	err := idb(ctx, tr.db).RunInTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+		// Every transaction older than the snapshot's xmin has finished, so
+		// continuing from there also picks up changes still in flight now
+		err := tx.NewSelect().ColumnExpr("pg_snapshot_xmin(pg_current_snapshot())::text::bigint").Scan(ctx, &next)
+		if err != nil {
+			return err
+		}
+
+		q := withProgress(tx.NewSelect().Model(&tasks).Relation("Tags", orderTags)).
+			Where("?0 = ?1", bun.Ident("user_id"), user_id)
+		if since > 0 {
+			changed := tx.NewSelect().TableExpr("task_changes").Column("task_id").
+				Where("user_id = ? AND xid >= ?::xid8", user_id, strconv.FormatUint(since, 10))
+			q = q.WhereAllWithDeleted().Where("?0 IN (?1)", bun.Ident("id"), changed)
+		}
+		err = q.OrderExpr("?0 ASC", bun.Ident("id")).Scan(ctx)
+		if err != nil || since == 0 {
+			return err
+		}
+
+		return tx.NewSelect().TableExpr("task_changes AS c").ColumnExpr("DISTINCT c.task_id").
+			Where("c.user_id = ? AND c.xid >= ?::xid8", user_id, strconv.FormatUint(since, 10)).
+			Where("NOT EXISTS (SELECT 1 FROM tasks AS t WHERE t.id = c.task_id)").
+			OrderExpr("c.task_id").
+			Scan(ctx, &deleted)
+	})
+	return tasks, deleted, uint64(next), err
+}
+
+// GetChangeLogHorizon returns the newest position and time purged from the
+// change log. Changes since a position or time before them are no longer
+// fully known.
+func (tr *TaskRepository) GetChangeLogHorizon(ctx context.Context) (uint64, time.Time, error) {
+	var horizon struct {
+		XID       int64     `bun:"xid"`
+		ChangedAt time.Time `bun:"changed_at"`
+	}
+	err := idb(ctx, tr.db).NewSelect().TableExpr("task_changes_horizon").Column("xid", "changed_at").Scan(ctx, &horizon)
+	return uint64(horizon.XID), horizon.ChangedAt, err
+}
+
+// GetPurgedTaskIDs returns the ids of the tasks of a user that changed after
+// since and have been purged since, which only the change log still knows of.
+func (tr *TaskRepository) GetPurgedTaskIDs(ctx context.Context, user_id int, since time.Time) ([]int, error) {
+	ids := []int{}
+	err := idb(ctx, tr.db).NewSelect().TableExpr("task_changes AS c").ColumnExpr("DISTINCT c.task_id").
+		Where("c.user_id = ? AND c.changed_at > ?", user_id, since).
+		Where("NOT EXISTS (SELECT 1 FROM tasks AS t WHERE t.id = c.task_id)").
+		OrderExpr("c.task_id").
+		Scan(ctx, &ids)
+	return ids, err
+}
+
+// PurgeChanges deletes the change log entries of all users made before the
+// given time, and moves the horizon up to the newest of them.
+func (tr *TaskRepository) PurgeChanges(ctx context.Context, before time.Time) (int, error) {
+	var purged int
+	err := idb(ctx, tr.db).NewRaw(`
+		WITH purged AS (
+			DELETE FROM task_changes WHERE changed_at < ?0 RETURNING xid, changed_at
+		)
+		UPDATE task_changes_horizon SET
+			xid = GREATEST(xid, (SELECT MAX(xid::text::bigint) FROM purged)),
+			changed_at = GREATEST(changed_at, (SELECT MAX(changed_at) FROM purged))
+		RETURNING (SELECT COUNT(*) FROM purged)`, before).Scan(ctx, &purged)
+	return purged, err
+}
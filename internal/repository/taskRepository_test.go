@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+// recordingConn is a database connection that records the statements run on
+// it. Queries return the columns and rows given by returning, or nothing.
+type recordingConn struct {
+	mu        sync.Mutex
+	queries   []string
+	returning func(query string) ([]string, [][]driver.Value)
+}
+
+func (c *recordingConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c *recordingConn) Driver() driver.Driver                        { return nil }
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *recordingConn) Close() error                        { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)           { return c, nil }
+func (c *recordingConn) Commit() error                       { return nil }
+func (c *recordingConn) Rollback() error                     { return nil }
+
+func (c *recordingConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = append(c.queries, query)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *recordingConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = append(c.queries, query)
+	rows := &recordingRows{columns: []string{"id"}}
+	if c.returning != nil {
+		rows.columns, rows.values = c.returning(query)
+	}
+	return rows, nil
+}
+
+// countQueries returns the number of recorded statements starting with
+// prefix and containing substr.
+func (c *recordingConn) countQueries(prefix, substr string) int {
+	n := 0
+	for _, query := range c.queries {
+		if strings.HasPrefix(query, prefix) && strings.Contains(query, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+func newRecordingDB(conn *recordingConn) *bun.DB {
+	db := bun.NewDB(sql.OpenDB(conn), pgdialect.New())
+	db.RegisterModel((*models.TaskTag)(nil))
+	return db
+}
+
+type recordingRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *recordingRows) Columns() []string { return r.columns }
+func (r *recordingRows) Close() error      { return nil }
+
+func (r *recordingRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestUpdateTaskFieldsRearmsReminders(t *testing.T) {
+	dueAt := time.Date(2024, 10, 20, 17, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name   string
+		fields []string
+		rearm  bool
+	}{
+		{
+			name:   "Due date changed",
+			fields: []string{"title", "due_at"},
+			rearm:  true,
+		},
+		{
+			name:   "Due date unchanged",
+			fields: []string{"title"},
+			rearm:  false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := &recordingConn{returning: func(query string) ([]string, [][]driver.Value) {
+				if strings.HasPrefix(query, `UPDATE "tasks"`) {
+					return []string{"id", "version"}, [][]driver.Value{{int64(1), int64(2)}}
+				}
+				return []string{"id"}, nil
+			}}
+			tr := NewTaskRepository(newRecordingDB(conn))
+
+			_, err := tr.UpdateTaskFields(context.TODO(), models.Task{Title: "Test Task", DueAt: &dueAt}, tc.fields, 1, 1, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rearmed := conn.countQueries(`UPDATE "reminders"`, `"sent_at" = NULL`) > 0
+			if rearmed != tc.rearm {
+				t.Errorf("Expected reminders rearmed: %v, got queries: %v", tc.rearm, conn.queries)
+			}
+		})
+	}
+}
+
+func TestGetFieldChangesPurged(t *testing.T) {
+	conn := &recordingConn{returning: func(query string) ([]string, [][]driver.Value) {
+		return []string{"exists"}, [][]driver.Value{{false}}
+	}}
+	tr := NewTaskRepository(newRecordingDB(conn))
+
+	if _, err := tr.GetFieldChanges(context.TODO(), 1, 3); err != ErrChangeLogPurged {
+		t.Errorf("Expected ErrChangeLogPurged, got: %v", err)
+	}
+	if n := conn.countQueries("SELECT EXISTS", "task_id = 1 AND version = 4"); n != 1 {
+		t.Errorf("Expected the change after the base version to be looked up, got queries: %v", conn.queries)
+	}
+}
+
+func TestGetPurgedTaskIDs(t *testing.T) {
+	conn := &recordingConn{returning: func(query string) ([]string, [][]driver.Value) {
+		return []string{"task_id"}, [][]driver.Value{{int64(5)}}
+	}}
+	tr := NewTaskRepository(newRecordingDB(conn))
+
+	since := time.Date(2024, 10, 10, 0, 0, 0, 0, time.UTC)
+	ids, err := tr.GetPurgedTaskIDs(context.TODO(), 1, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != 5 {
+		t.Errorf("Expected [5], got: %v", ids)
+	}
+	if n := conn.countQueries("SELECT DISTINCT c.task_id FROM task_changes", "NOT EXISTS (SELECT 1 FROM tasks AS t WHERE t.id = c.task_id)"); n != 1 {
+		t.Errorf("Expected the change log to be searched for tasks that no longer exist, got queries: %v", conn.queries)
+	}
+}
+
+func TestGetTasksCursorSkipsCount(t *testing.T) {
+	conn := &recordingConn{returning: func(query string) ([]string, [][]driver.Value) {
+		if strings.HasSuffix(query, "LIMIT 3") {
+			return []string{"id"}, [][]driver.Value{{int64(4)}, {int64(5)}, {int64(6)}}
+		}
+		return []string{"id"}, nil
+	}}
+	tr := NewTaskRepository(newRecordingDB(conn))
+
+	after := "3"
+	tasks, total, err := tr.GetTasks(context.TODO(), 1, models.TaskFilter{After: []*string{&after}}, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 || total != 3 {
+		t.Errorf("Expected 2 tasks and a total of 3, got %d tasks and a total of %d", len(tasks), total)
+	}
+	if n := conn.countQueries("SELECT count(*)", ""); n != 0 {
+		t.Errorf("Expected no count, got queries: %v", conn.queries)
+	}
+}
+
+func TestSearchTasks(t *testing.T) {
+	testCases := []struct {
+		name      string
+		archived  string
+		condition string
+	}{
+		{name: "Archived tasks left out", archived: models.TaskArchivedExclude, condition: `"archived_at" IS NULL`},
+		{name: "Only archived tasks", archived: models.TaskArchivedOnly, condition: `"archived_at" IS NOT NULL`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := &recordingConn{returning: func(query string) ([]string, [][]driver.Value) {
+				switch {
+				case strings.HasPrefix(query, "SELECT count(*)"):
+					return []string{"count"}, [][]driver.Value{{int64(2)}}
+				case strings.Contains(query, "AS rank"):
+					return []string{"id", "title", "rank", "title_highlight", "description_highlight"}, [][]driver.Value{
+						{int64(2), "Buy milk", 0.6, "Buy <mark>milk</mark>", ""},
+						{int64(1), "Milk the cow", 0.3, "<mark>Milk</mark> the cow", ""},
+					}
+				}
+				return []string{"id"}, nil
+			}}
+			tr := NewTaskRepository(newRecordingDB(conn))
+
+			results, total, err := tr.SearchTasks(context.TODO(), 1, "milk", tc.archived, 1, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 2 || len(results) != 2 {
+				t.Fatalf("Expected 2 results, got %d of %d", len(results), total)
+			}
+			if results[0].ID != 2 || results[0].Rank != 0.6 || results[0].TitleHighlight != "Buy <mark>milk</mark>" {
+				t.Errorf("Unexpected first result: %+v", results[0])
+			}
+
+			if n := conn.countQueries("SELECT", "websearch_to_tsquery('english', 'milk')"); n != 2 {
+				t.Errorf("Expected the search and its count to match the query, got queries: %v", conn.queries)
+			}
+			if n := conn.countQueries("SELECT", tc.condition); n != 2 {
+				t.Errorf("Expected %s, got queries: %v", tc.condition, conn.queries)
+			}
+			if n := conn.countQueries("SELECT", `ORDER BY rank DESC, "id" ASC LIMIT 10`); n != 1 {
+				t.Errorf("Expected the best matches first, got queries: %v", conn.queries)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/uptrace/bun"
+)
+
+type TokenRepositoryInterface interface {
+	AddRefreshToken(ctx context.Context, token models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, token_hash string) (models.RefreshToken, error)
+	UseRefreshToken(ctx context.Context, token_id int64) (bool, error)
+	RevokeTokenFamily(ctx context.Context, family_id string) error
+	RevokeUserTokens(ctx context.Context, user_id int) error
+	RevokeAccessToken(ctx context.Context, token models.RevokedToken) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	PurgeExpiredTokens(ctx context.Context, now time.Time) (int, error)
+	AddSession(ctx context.Context, session models.Session) error
+	GetSessions(ctx context.Context, user_id int) ([]models.Session, error)
+	TouchSession(ctx context.Context, session_id string) (bool, error)
+	DeleteSession(ctx context.Context, user_id int, session_id string) error
+}
+
+type TokenRepository struct {
+	db *bun.DB
+}
+
+func NewTokenRepository(db *bun.DB) *TokenRepository {
+	return &TokenRepository{db}
+}
+
+func (tr *TokenRepository) AddRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	_, err := idb(ctx, tr.db).NewInsert().Model(&token).Exec(ctx)
+	return err
+}
+
+func (tr *TokenRepository) GetRefreshToken(ctx context.Context, token_hash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := idb(ctx, tr.db).NewSelect().Model(&token).Where("?0 = ?1", bun.Ident("token_hash"), token_hash).Scan(ctx)
+	return token, err
+}
+
+// UseRefreshToken marks a token used and reports false if it already was or
+// has been revoked, so that only one refresh can succeed with it.
+func (tr *TokenRepository) UseRefreshToken(ctx context.Context, token_id int64) (bool, error) {
+	res, err := idb(ctx, tr.db).NewUpdate().Model((*models.RefreshToken)(nil)).
+		Set("?0 = now()", bun.Ident("used_at")).
+		Where("?0 = ?1 AND ?2 IS NULL AND ?3 IS NULL", bun.Ident("id"), token_id, bun.Ident("used_at"), bun.Ident("revoked_at")).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	used, err := res.RowsAffected()
+	return used == 1, err
+}
+
+// RevokeTokenFamily revokes all tokens descending from the same login and
+// ends its session.
+func (tr *TokenRepository) RevokeTokenFamily(ctx context.Context, family_id string) error {
+	return runInTx(ctx, tr.db, func(ctx context.Context) error {
+		_, err := idb(ctx, tr.db).NewUpdate().Model((*models.RefreshToken)(nil)).
+			Set("?0 = now()", bun.Ident("revoked_at")).
+			Where("?0 = ?1 AND ?2 IS NULL", bun.Ident("family_id"), family_id, bun.Ident("revoked_at")).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = idb(ctx, tr.db).NewDelete().Model((*models.Session)(nil)).Where("?0 = ?1", bun.Ident("id"), family_id).Exec(ctx)
+		return err
+	})
+}
+
+// RevokeUserTokens revokes all refresh tokens of the user and ends all of
+// its sessions.
+func (tr *TokenRepository) RevokeUserTokens(ctx context.Context, user_id int) error {
+	return runInTx(ctx, tr.db, func(ctx context.Context) error {
+		_, err := idb(ctx, tr.db).NewUpdate().Model((*models.RefreshToken)(nil)).
+			Set("?0 = now()", bun.Ident("revoked_at")).
+			Where("?0 = ?1 AND ?2 IS NULL", bun.Ident("user_id"), user_id, bun.Ident("revoked_at")).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = idb(ctx, tr.db).NewDelete().Model((*models.Session)(nil)).Where("?0 = ?1", bun.Ident("user_id"), user_id).Exec(ctx)
+		return err
+	})
+}
+
+func (tr *TokenRepository) RevokeAccessToken(ctx context.Context, token models.RevokedToken) error {
+	_, err := idb(ctx, tr.db).NewInsert().Model(&token).On("CONFLICT (jti) DO NOTHING").Exec(ctx)
+	return err
+}
+
+func (tr *TokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return idb(ctx, tr.db).NewSelect().Model((*models.RevokedToken)(nil)).Where("?0 = ?1", bun.Ident("jti"), jti).Exists(ctx)
+}
+
+// PurgeExpiredTokens deletes the refresh tokens and revoked access tokens
+// expired at now, and the sessions left without refresh tokens, and returns
+// the number of tokens.
+func (tr *TokenRepository) PurgeExpiredTokens(ctx context.Context, now time.Time) (int, error) {
+	purged := 0
+	for _, model := range []any{(*models.RefreshToken)(nil), (*models.RevokedToken)(nil)} {
+		res, err := idb(ctx, tr.db).NewDelete().Model(model).
+			Where("?0 <= ?1", bun.Ident("expires_at"), now).
+			Exec(ctx)
+		if err != nil {
+			return purged, err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return purged, err
+		}
+		purged += int(n)
+	}
+
+	_, err := idb(ctx, tr.db).NewDelete().Model((*models.Session)(nil)).
+		Where("NOT EXISTS (SELECT 1 FROM ?0 WHERE ?1 = ?2)", bun.Ident("refresh_tokens"), bun.Ident("family_id"), bun.Ident("session.id")).
+		Exec(ctx)
+	return purged, err
+}
+
+func (tr *TokenRepository) AddSession(ctx context.Context, session models.Session) error {
+	_, err := idb(ctx, tr.db).NewInsert().Model(&session).Exec(ctx)
+	return err
+}
+
+// GetSessions returns the sessions of the user that still hold a valid
+// refresh token, most recently used first.
+func (tr *TokenRepository) GetSessions(ctx context.Context, user_id int) ([]models.Session, error) {
+	var sessions []models.Session
+	err := idb(ctx, tr.db).NewSelect().Model(&sessions).
+		Where("?0 = ?1", bun.Ident("user_id"), user_id).
+		Where("EXISTS (SELECT 1 FROM ?0 WHERE ?1 = ?2 AND ?3 IS NULL AND ?4 IS NULL AND ?5 > now())",
+			bun.Ident("refresh_tokens"), bun.Ident("family_id"), bun.Ident("session.id"), bun.Ident("used_at"), bun.Ident("revoked_at"), bun.Ident("expires_at")).
+		Order("last_used_at DESC").
+		Scan(ctx)
+	return sessions, err
+}
+
+// TouchSession records that the session was used and reports false if it
+// has ended.
+func (tr *TokenRepository) TouchSession(ctx context.Context, session_id string) (bool, error) {
+	res, err := idb(ctx, tr.db).NewUpdate().Model((*models.Session)(nil)).
+		Set("?0 = now()", bun.Ident("last_used_at")).
+		Where("?0 = ?1", bun.Ident("id"), session_id).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	touched, err := res.RowsAffected()
+	return touched == 1, err
+}
+
+// DeleteSession ends a session of the user and revokes its refresh tokens.
+// It returns sql.ErrNoRows if the user has no such session.
+func (tr *TokenRepository) DeleteSession(ctx context.Context, user_id int, session_id string) error {
+	return runInTx(ctx, tr.db, func(ctx context.Context) error {
+		res, err := idb(ctx, tr.db).NewDelete().Model((*models.Session)(nil)).
+			Where("?0 = ?1 AND ?2 = ?3", bun.Ident("id"), session_id, bun.Ident("user_id"), user_id).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return sql.ErrNoRows
+		}
+
+		_, err = idb(ctx, tr.db).NewUpdate().Model((*models.RefreshToken)(nil)).
+			Set("?0 = now()", bun.Ident("revoked_at")).
+			Where("?0 = ?1 AND ?2 IS NULL", bun.Ident("family_id"), session_id, bun.Ident("revoked_at")).
+			Exec(ctx)
+		return err
+	})
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+type txKey struct{}
+
+// runInTx runs fn in a transaction whose context makes the queries of all
+// repositories part of it. Inside another transaction fn runs in a savepoint,
+// so its failure only undoes its own changes.
+func runInTx(ctx context.Context, db *bun.DB, fn func(ctx context.Context) error) error {
+	return idb(ctx, db).RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// idb returns the transaction of ctx started by runInTx, or db outside of one.
+func idb(ctx context.Context, db *bun.DB) bun.IDB {
+	if tx, ok := ctx.Value(txKey{}).(bun.Tx); ok {
+		return tx
+	}
+	return db
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/NeGat1FF/todolist-api/internal/models"
 	"github.com/uptrace/bun"
@@ -10,6 +11,10 @@ import (
 type UserRepositoryInterface interface {
 	AddUser(ctx context.Context, user models.User) (int, error)
 	GetUserByEmail(ctx context.Context, email string) (models.User, error)
+	GetUserByID(ctx context.Context, user_id int) (models.User, error)
+	UpdateTimeZone(ctx context.Context, user_id int, timeZone string) error
+	GetTokenVersion(ctx context.Context, user_id int) (int, error)
+	IncrementTokenVersion(ctx context.Context, user_id int) (int, error)
 }
 
 type UserRepository struct {
@@ -22,12 +27,47 @@ func NewUserRepository(db *bun.DB) *UserRepository {
 
 func (ur *UserRepository) AddUser(ctx context.Context, user models.User) (int, error) {
 	var user_id int
-	err := ur.db.NewInsert().Model(&user).Returning("id").Scan(ctx, &user_id)
+	err := idb(ctx, ur.db).NewInsert().Model(&user).Returning("id").Scan(ctx, &user_id)
 	return user_id, err
 }
 
 func (ur *UserRepository) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
 	var user models.User
-	err := ur.db.NewSelect().Model(&user).Where("?0 = ?1", bun.Ident("email"), email).Scan(ctx, &user)
+	err := idb(ctx, ur.db).NewSelect().Model(&user).Where("?0 = ?1", bun.Ident("email"), email).Scan(ctx, &user)
 	return user, err
 }
+
+func (ur *UserRepository) GetUserByID(ctx context.Context, user_id int) (models.User, error) {
+	var user models.User
+	err := idb(ctx, ur.db).NewSelect().Model(&user).Where("?0 = ?1", bun.Ident("id"), user_id).Scan(ctx, &user)
+	return user, err
+}
+
+func (ur *UserRepository) UpdateTimeZone(ctx context.Context, user_id int, timeZone string) error {
+	res, err := idb(ctx, ur.db).NewUpdate().Model((*models.User)(nil)).Set("?0 = ?1", bun.Ident("time_zone"), timeZone).Where("?0 = ?1", bun.Ident("id"), user_id).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (ur *UserRepository) GetTokenVersion(ctx context.Context, user_id int) (int, error) {
+	var version int
+	err := idb(ctx, ur.db).NewSelect().Model((*models.User)(nil)).Column("token_version").Where("?0 = ?1", bun.Ident("id"), user_id).Scan(ctx, &version)
+	return version, err
+}
+
+// IncrementTokenVersion invalidates all access tokens issued to the user so
+// far and returns the new token version.
+func (ur *UserRepository) IncrementTokenVersion(ctx context.Context, user_id int) (int, error) {
+	var version int
+	err := idb(ctx, ur.db).NewUpdate().Model((*models.User)(nil)).
+		Set("?0 = ?0 + 1", bun.Ident("token_version")).
+		Where("?0 = ?1", bun.Ident("id"), user_id).
+		Returning("?0", bun.Ident("token_version")).
+		Scan(ctx, &version)
+	return version, err
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// revocationCacheTTL is how long token versions, unrevoked access tokens and
+// live sessions are cached. Revocations made by another instance take effect
+// after it.
+const revocationCacheTTL = 30 * time.Second
+
+type cachedVersion struct {
+	version int
+	until   time.Time
+}
+
+type cachedRevocation struct {
+	revoked bool
+	until   time.Time
+}
+
+// revocationCache keeps the token versions of users and the revocation state
+// of access tokens and sessions, so that not every request has to look them
+// up. Revoked tokens and ended sessions stay cached until the token expires,
+// as they cannot become valid again.
+type revocationCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	versions  map[int]cachedVersion
+	tokens    map[string]cachedRevocation
+	sessions  map[string]cachedRevocation
+	lastPrune time.Time
+}
+
+func newRevocationCache(ttl time.Duration) *revocationCache {
+	return &revocationCache{
+		ttl:       ttl,
+		versions:  make(map[int]cachedVersion),
+		tokens:    make(map[string]cachedRevocation),
+		sessions:  make(map[string]cachedRevocation),
+		lastPrune: time.Now(),
+	}
+}
+
+func (c *revocationCache) version(user_id int) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.versions[user_id]
+	if !ok || time.Now().After(v.until) {
+		return 0, false
+	}
+	return v.version, true
+}
+
+func (c *revocationCache) setVersion(user_id, version int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.versions[user_id] = cachedVersion{version, now.Add(c.ttl)}
+	c.prune(now)
+}
+
+// revoked reports whether the token is revoked and whether that is known.
+func (c *revocationCache) revoked(jti string) (bool, bool) {
+	return c.lookup(c.tokens, jti)
+}
+
+func (c *revocationCache) setRevoked(jti string, revoked bool, expiresAt time.Time) {
+	c.store(c.tokens, jti, revoked, expiresAt)
+}
+
+// sessionEnded reports whether the session has ended and whether that is
+// known.
+func (c *revocationCache) sessionEnded(session_id string) (bool, bool) {
+	return c.lookup(c.sessions, session_id)
+}
+
+func (c *revocationCache) setSessionEnded(session_id string, ended bool, expiresAt time.Time) {
+	c.store(c.sessions, session_id, ended, expiresAt)
+}
+
+func (c *revocationCache) lookup(m map[string]cachedRevocation, key string) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, ok := m[key]
+	if !ok || time.Now().After(r.until) {
+		return false, false
+	}
+	return r.revoked, true
+}
+
+func (c *revocationCache) store(m map[string]cachedRevocation, key string, revoked bool, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	until := expiresAt
+	if !revoked {
+		until = now.Add(c.ttl)
+	}
+	m[key] = cachedRevocation{revoked, until}
+	c.prune(now)
+}
+
+// prune drops the stale entries at most once every ttl.
+func (c *revocationCache) prune(now time.Time) {
+	if now.Sub(c.lastPrune) < c.ttl {
+		return
+	}
+	c.lastPrune = now
+
+	for user_id, v := range c.versions {
+		if now.After(v.until) {
+			delete(c.versions, user_id)
+		}
+	}
+	for _, m := range []map[string]cachedRevocation{c.tokens, c.sessions} {
+		for key, r := range m {
+			if now.After(r.until) {
+				delete(m, key)
+			}
+		}
+	}
+}
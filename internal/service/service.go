@@ -3,9 +3,12 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"reflect"
+	"slices"
 	"time"
 
 	"github.com/NeGat1FF/todolist-api/internal/models"
@@ -27,46 +30,382 @@ func (s ServerError) Error() string {
 type Service struct {
 	taskRep repository.TaskRepositoryInterface
 	usrRep  repository.UserRepositoryInterface
+	tagRep  repository.TagRepositoryInterface
+	listRep repository.ListRepositoryInterface
+	remRep  repository.ReminderRepositoryInterface
+	tknRep  repository.TokenRepositoryInterface
+	keyRep  repository.APIKeyRepositoryInterface
 	logger  *log.Logger
+
+	revocations *revocationCache
 }
 
-func NewService(usr repository.UserRepositoryInterface, task repository.TaskRepositoryInterface, logger *log.Logger) *Service {
-	return &Service{taskRep: task, usrRep: usr, logger: logger}
+func NewService(usr repository.UserRepositoryInterface, task repository.TaskRepositoryInterface, tag repository.TagRepositoryInterface, list repository.ListRepositoryInterface, reminder repository.ReminderRepositoryInterface, token repository.TokenRepositoryInterface, apiKey repository.APIKeyRepositoryInterface, logger *log.Logger) *Service {
+	return &Service{taskRep: task, usrRep: usr, tagRep: tag, listRep: list, remRep: reminder, tknRep: token, keyRep: apiKey, logger: logger, revocations: newRevocationCache(revocationCacheTTL)}
 }
 
-func (s *Service) IssueAccessToken(user_id int) string {
+// accessTokenTTL is how long an access token is valid.
+const accessTokenTTL = 12 * time.Hour
+
+// IssueAccessToken returns an access token of the session, which carries a
+// jti to revoke it by and the current token version of the user.
+func (s *Service) IssueAccessToken(ctx context.Context, user_id int, session_id string) (string, error) {
+	version, err := s.tokenVersion(ctx, user_id)
+	if err != nil {
+		s.logger.Print(err)
+		return "", ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	jti, err := utils.NewOpaqueToken(16)
+	if err != nil {
+		s.logger.Print(err)
+		return "", ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
 	tokenString, err := utils.GenerateJWT(jwt.MapClaims{
 		"iss":  "todolistApp",
 		"uid":  user_id,
 		"type": "access",
-		"exp":  time.Now().Add(time.Hour * 12).Unix()})
+		"jti":  jti,
+		"sid":  session_id,
+		"ver":  version,
+		"exp":  time.Now().Add(accessTokenTTL).Unix()})
 	if err != nil {
 		s.logger.Print(err)
-		return ""
+		return "", ServerError{http.StatusInternalServerError, "internal server error"}
 	}
 
 	s.logger.Printf("Issued token succsessfully for %d", user_id)
-	return tokenString
+	return tokenString, nil
 }
 
-func (s *Service) IssueRefreshToken(user_id int) string {
+// tokenVersion returns the token version of the user, cached for
+// revocationCacheTTL.
+func (s *Service) tokenVersion(ctx context.Context, user_id int) (int, error) {
+	if version, ok := s.revocations.version(user_id); ok {
+		return version, nil
+	}
 
-	refreshTokenString, err := utils.GenerateJWT(jwt.MapClaims{
-		"iss":  "todolistApp",
-		"uid":  user_id,
-		"type": "refresh",
-		"exp":  time.Now().Add(time.Hour * 12).Unix(),
+	version, err := s.usrRep.GetTokenVersion(ctx, user_id)
+	if err != nil {
+		return 0, err
+	}
+	s.revocations.setVersion(user_id, version)
+	return version, nil
+}
+
+// CheckAccessToken returns models.ErrTokenRevoked if the token was revoked by
+// a logout, issued before the last logout from all sessions, or belongs to a
+// session that has ended.
+func (s *Service) CheckAccessToken(ctx context.Context, token models.AccessToken) error {
+	version, err := s.tokenVersion(ctx, token.UserID)
+	if err == sql.ErrNoRows {
+		return models.ErrTokenRevoked
+	}
+	if err != nil {
+		s.logger.Print(err)
+		return err
+	}
+	if token.Version < version {
+		return models.ErrTokenRevoked
+	}
+
+	if token.JTI != "" {
+		revoked, ok := s.revocations.revoked(token.JTI)
+		if !ok {
+			revoked, err = s.tknRep.IsAccessTokenRevoked(ctx, token.JTI)
+			if err != nil {
+				s.logger.Print(err)
+				return err
+			}
+			s.revocations.setRevoked(token.JTI, revoked, token.ExpiresAt)
+		}
+		if revoked {
+			return models.ErrTokenRevoked
+		}
+	}
+
+	// Looking the session up records its use, at most once every
+	// revocationCacheTTL
+	if token.SessionID != "" {
+		ended, ok := s.revocations.sessionEnded(token.SessionID)
+		if !ok {
+			alive, err := s.tknRep.TouchSession(ctx, token.SessionID)
+			if err != nil {
+				s.logger.Print(err)
+				return err
+			}
+			ended = !alive
+			s.revocations.setSessionEnded(token.SessionID, ended, token.ExpiresAt)
+		}
+		if ended {
+			return models.ErrTokenRevoked
+		}
+	}
+	return nil
+}
+
+// Logout ends the session of the access token: the token itself is revoked
+// and so are the refresh tokens of its session.
+func (s *Service) Logout(ctx context.Context, token models.AccessToken) error {
+	if token.SessionID != "" {
+		if err := s.tknRep.RevokeTokenFamily(ctx, token.SessionID); err != nil {
+			s.logger.Print(err)
+			return ServerError{http.StatusInternalServerError, "internal server error"}
+		}
+		s.revocations.setSessionEnded(token.SessionID, true, token.ExpiresAt)
+	}
+
+	if token.JTI != "" {
+		err := s.tknRep.RevokeAccessToken(ctx, models.RevokedToken{JTI: token.JTI, UserID: token.UserID, ExpiresAt: token.ExpiresAt})
+		if err != nil {
+			s.logger.Print(err)
+			return ServerError{http.StatusInternalServerError, "internal server error"}
+		}
+		s.revocations.setRevoked(token.JTI, true, token.ExpiresAt)
+	}
+
+	s.logger.Printf("User %d logged out", token.UserID)
+	return nil
+}
+
+// LogoutAll ends every session of the user by revoking all refresh tokens
+// and bumping the token version, which invalidates all access tokens issued
+// before.
+func (s *Service) LogoutAll(ctx context.Context, user_id int) error {
+	if err := s.tknRep.RevokeUserTokens(ctx, user_id); err != nil {
+		s.logger.Print(err)
+		return ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	version, err := s.usrRep.IncrementTokenVersion(ctx, user_id)
+	if err != nil {
+		s.logger.Print(err)
+		return ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+	s.revocations.setVersion(user_id, version)
+
+	s.logger.Printf("User %d logged out of all sessions", user_id)
+	return nil
+}
+
+// apiKeyBytes is the number of random bytes in an API key.
+const apiKeyBytes = 32
+
+// apiKeyTouchInterval is how often the last use of an API key is recorded.
+const apiKeyTouchInterval = time.Minute
+
+// CreateAPIKey creates an API key for the user and returns it together with
+// the key itself, which cannot be retrieved later.
+func (s *Service) CreateAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	secret, err := utils.NewOpaqueToken(apiKeyBytes)
+	if err != nil {
+		s.logger.Print(err)
+		return models.APIKey{}, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+	secret = models.APIKeyPrefix + secret
+
+	key.KeyHash = utils.HashToken(secret)
+	key.Prefix = secret[:len(models.APIKeyPrefix)+6]
+	key, err = s.keyRep.AddAPIKey(ctx, key)
+	if err != nil {
+		s.logger.Print(err)
+		return models.APIKey{}, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	key.Key = secret
+	s.logger.Printf("User %d created API key %d", key.UserID, key.ID)
+	return key, nil
+}
+
+func (s *Service) GetAPIKeys(ctx context.Context, user_id int) ([]models.APIKey, error) {
+	keys, err := s.keyRep.GetAPIKeys(ctx, user_id)
+	if err != nil {
+		s.logger.Print(err)
+		return nil, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+	return keys, nil
+}
+
+func (s *Service) DeleteAPIKey(ctx context.Context, user_id, key_id int) error {
+	err := s.keyRep.DeleteAPIKey(ctx, user_id, key_id)
+	if err == sql.ErrNoRows {
+		return ServerError{http.StatusNotFound, "API key not found"}
+	}
+	if err != nil {
+		s.logger.Print(err)
+		return ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+	return nil
+}
+
+// CheckAPIKey returns the API key a request is authenticated with, or
+// models.ErrInvalidAPIKey if it is unknown or expired.
+func (s *Service) CheckAPIKey(ctx context.Context, secret string) (models.APIKey, error) {
+	key, err := s.keyRep.GetAPIKeyByHash(ctx, utils.HashToken(secret))
+	if err == sql.ErrNoRows {
+		return models.APIKey{}, models.ErrInvalidAPIKey
+	}
+	if err != nil {
+		s.logger.Print(err)
+		return models.APIKey{}, err
+	}
+
+	now := time.Now()
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return models.APIKey{}, models.ErrInvalidAPIKey
+	}
+
+	// A failure to record the use does not fail the request
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.keyRep.TouchAPIKey(ctx, key.ID); err != nil {
+			s.logger.Print(err)
+		}
+	}
+	return key, nil
+}
+
+// GetSessions returns the active sessions of the user, marking the one of
+// the access token as current.
+func (s *Service) GetSessions(ctx context.Context, token models.AccessToken) ([]models.Session, error) {
+	sessions, err := s.tknRep.GetSessions(ctx, token.UserID)
+	if err != nil {
+		s.logger.Print(err)
+		return nil, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == token.SessionID
+	}
+	return sessions, nil
+}
+
+// EndSession logs the user out of one of its sessions.
+func (s *Service) EndSession(ctx context.Context, user_id int, session_id string) error {
+	err := s.tknRep.DeleteSession(ctx, user_id, session_id)
+	if err == sql.ErrNoRows {
+		return ServerError{http.StatusNotFound, "session not found"}
+	}
+	if err != nil {
+		s.logger.Print(err)
+		return ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	s.revocations.setSessionEnded(session_id, true, time.Now().Add(accessTokenTTL))
+
+	s.logger.Printf("User %d ended session %s", user_id, session_id)
+	return nil
+}
+
+// DefaultRefreshTokenTTL is how long a refresh token stays valid unless
+// REFRESH_TOKEN_TTL says otherwise. Every refresh starts the period anew.
+const DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+// refreshTokenBytes is the number of random bytes in a refresh token.
+const refreshTokenBytes = 32
+
+var errInvalidRefreshToken = ServerError{http.StatusUnauthorized, "invalid refresh token"}
+
+// IssueRefreshToken stores a new refresh token of the token family, which
+// starts with a login, and returns it.
+func (s *Service) IssueRefreshToken(ctx context.Context, user_id int, family_id string) (string, error) {
+	token, err := utils.NewOpaqueToken(refreshTokenBytes)
+	if err != nil {
+		s.logger.Print(err)
+		return "", ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	err = s.tknRep.AddRefreshToken(ctx, models.RefreshToken{
+		UserID:    user_id,
+		FamilyID:  family_id,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(utils.EnvDuration("REFRESH_TOKEN_TTL", DefaultRefreshTokenTTL)),
 	})
 	if err != nil {
 		s.logger.Print(err)
-		return ""
+		return "", ServerError{http.StatusInternalServerError, "internal server error"}
 	}
 
 	s.logger.Printf("Issued refresh token succsessfully for %d", user_id)
-	return refreshTokenString
+	return token, nil
+}
+
+// issueTokens starts a new session and returns an access token and the first
+// refresh token of it.
+func (s *Service) issueTokens(ctx context.Context, user_id int, session models.Session) (string, string, error) {
+	family_id, err := utils.NewOpaqueToken(16)
+	if err != nil {
+		s.logger.Print(err)
+		return "", "", ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	session.ID, session.UserID = family_id, user_id
+	if err := s.tknRep.AddSession(ctx, session); err != nil {
+		s.logger.Print(err)
+		return "", "", ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	refreshToken, err := s.IssueRefreshToken(ctx, user_id, family_id)
+	if err != nil {
+		return "", "", err
+	}
+	accessToken, err := s.IssueAccessToken(ctx, user_id, family_id)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new access token and a new
+// refresh token of the same family. A refresh token can only be used once;
+// presenting it again means it was stolen, so its whole family is revoked.
+func (s *Service) RefreshTokens(ctx context.Context, refreshToken string) (string, string, error) {
+	token, err := s.tknRep.GetRefreshToken(ctx, utils.HashToken(refreshToken))
+	if err == sql.ErrNoRows {
+		return "", "", errInvalidRefreshToken
+	}
+	if err != nil {
+		s.logger.Print(err)
+		return "", "", ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	if token.RevokedAt != nil {
+		return "", "", errInvalidRefreshToken
+	}
+	if !time.Now().Before(token.ExpiresAt) {
+		return "", "", ServerError{http.StatusUnauthorized, "refresh token expired"}
+	}
+
+	// Of concurrent refreshes with the same token only one marks it used
+	rotated := false
+	if token.UsedAt == nil {
+		rotated, err = s.tknRep.UseRefreshToken(ctx, token.ID)
+		if err != nil {
+			s.logger.Print(err)
+			return "", "", ServerError{http.StatusInternalServerError, "internal server error"}
+		}
+	}
+	if !rotated {
+		s.logger.Printf("Refresh token reused for %d, revoking its family", token.UserID)
+		if err := s.tknRep.RevokeTokenFamily(ctx, token.FamilyID); err != nil {
+			s.logger.Print(err)
+			return "", "", ServerError{http.StatusInternalServerError, "internal server error"}
+		}
+		return "", "", errInvalidRefreshToken
+	}
+
+	newToken, err := s.IssueRefreshToken(ctx, token.UserID, token.FamilyID)
+	if err != nil {
+		return "", "", err
+	}
+	accessToken, err := s.IssueAccessToken(ctx, token.UserID, token.FamilyID)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, newToken, nil
 }
 
-func (s *Service) RegisterUser(ctx context.Context, user models.User) (string, string, error) {
+func (s *Service) RegisterUser(ctx context.Context, user models.User, session models.Session) (string, string, error) {
 	usr, err := s.usrRep.GetUserByEmail(ctx, user.Email)
 	if err != nil && err != sql.ErrNoRows {
 		s.logger.Print(err)
@@ -94,10 +433,10 @@ func (s *Service) RegisterUser(ctx context.Context, user models.User) (string, s
 	}
 
 	s.logger.Print("New user registered")
-	return s.IssueAccessToken(user_id), s.IssueRefreshToken(user_id), nil
+	return s.issueTokens(ctx, user_id, session)
 }
 
-func (s *Service) LoginUser(ctx context.Context, user models.User) (string, string, error) {
+func (s *Service) LoginUser(ctx context.Context, user models.User, session models.Session) (string, string, error) {
 	usr, err := s.usrRep.GetUserByEmail(ctx, user.Email)
 	if err == sql.ErrNoRows {
 		return "", "", ServerError{http.StatusUnauthorized, "Invalid email or password"}
@@ -113,56 +452,1124 @@ func (s *Service) LoginUser(ctx context.Context, user models.User) (string, stri
 	}
 
 	s.logger.Print("User loged in succsessfully")
-	return s.IssueAccessToken(usr.ID), s.IssueRefreshToken(usr.ID), nil
+	return s.issueTokens(ctx, usr.ID, session)
 }
 
+// CheckUserAuthority reports a missing task, and a task of another user, as
+// not found.
 func (s *Service) CheckUserAuthority(ctx context.Context, user_id, task_id int) error {
-	task, err := s.taskRep.GetTaskByID(ctx, task_id)
+	_, err := s.getOwnedTask(ctx, task_id, user_id)
+	return err
+}
+
+func (s *Service) AddTask(ctx context.Context, task models.Task) (models.Task, error) {
+	if task.ParentID != nil {
+		if err := s.checkParent(ctx, 0, *task.ParentID, task.UserID); err != nil {
+			return task, err
+		}
+	}
+	if task.ListID != nil {
+		if err := s.checkListOwnership(ctx, *task.ListID, task.UserID); err != nil {
+			return task, err
+		}
+	}
+
+	task.CompletedAt = nil
+	if task.Status == models.TaskStatusDone {
+		now := time.Now()
+		task.CompletedAt = &now
+	}
+
+	task, err := s.taskRep.AddTask(ctx, task)
+	if err != nil {
+		switch err {
+		case repository.ErrMissingReference:
+			// The list or parent was deleted after it was checked
+			return task, ServerError{http.StatusNotFound, "list or parent task not found"}
+		case repository.ErrInvalidRecord:
+			return task, ServerError{http.StatusBadRequest, "invalid task"}
+		}
+		s.logger.Print(err)
+		return task, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return task, nil
+}
+
+// errTaskChanged reports a failed precondition on the version of a task.
+var errTaskChanged = ServerError{http.StatusPreconditionFailed, "task was changed in the meantime"}
+
+// GetTask returns a task of the user.
+func (s *Service) GetTask(ctx context.Context, task_id, user_id int) (models.Task, error) {
+	return s.getOwnedTask(ctx, task_id, user_id)
+}
+
+// UpdateTask updates a task. A version other than 0 is the version the
+// client expects the task to be at.
+func (s *Service) UpdateTask(ctx context.Context, task models.Task, task_id, user_id int, version int64) (models.Task, error) {
+	if task.ParentID != nil {
+		if err := s.checkParent(ctx, task_id, *task.ParentID, user_id); err != nil {
+			return task, err
+		}
+	}
+	if task.ListID != nil {
+		if err := s.checkListOwnership(ctx, *task.ListID, user_id); err != nil {
+			return task, err
+		}
+	}
+
+	// completed_at is derived from status by the repository
+	task.CompletedAt = nil
+
+	var updated models.Task
+	err := s.taskRep.RunInTx(ctx, func(ctx context.Context) error {
+		// Completing the task locks it first, so that only one of concurrent
+		// completions schedules the next occurrence
+		var before models.Task
+		if task.Status == models.TaskStatusDone {
+			var err error
+			before, err = s.lockOwnedTask(ctx, task_id, user_id)
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		updated, err = s.taskRep.UpdateTask(ctx, task, task_id, user_id, version)
+		if err == sql.ErrNoRows {
+			return s.explainNoRows(ctx, task_id, user_id, version)
+		}
+		if err != nil {
+			return err
+		}
+
+		if updated.Status == models.TaskStatusDone && before.Status != models.TaskStatusDone {
+			return s.scheduleNextOccurrence(ctx, updated)
+		}
+		return nil
+	})
+	return updated, s.txError(err)
+}
+
+// PatchTask applies a merge patch or JSON patch to the current state of a
+// task. A version other than 0 is the version the client expects the task to
+// be at; without one the patch is applied again if the task changes while it
+// is being written.
+func (s *Service) PatchTask(ctx context.Context, patch models.TaskPatch, task_id, user_id int, version int64) (models.Task, error) {
+	for range syncRetries {
+		current, err := s.getOwnedTask(ctx, task_id, user_id)
+		if err != nil {
+			return current, err
+		}
+		if version != 0 && current.Version != version {
+			return current, errTaskChanged
+		}
+
+		task, fields, err := patch.Apply(current)
+		if errors.Is(err, models.ErrPatchConflict) {
+			return current, ServerError{http.StatusConflict, err.Error()}
+		}
+		if err != nil {
+			return current, ServerError{http.StatusUnprocessableEntity, err.Error()}
+		}
+		if len(fields) == 0 {
+			return current, nil
+		}
+
+		if slices.Contains(fields, "parent_id") && task.ParentID != nil {
+			if err := s.checkParent(ctx, task_id, *task.ParentID, user_id); err != nil {
+				return current, err
+			}
+		}
+		if slices.Contains(fields, "list_id") && task.ListID != nil {
+			if err := s.checkListOwnership(ctx, *task.ListID, user_id); err != nil {
+				return current, err
+			}
+		}
+
+		// The write only succeeds at the version read, so concurrent
+		// completions cannot both schedule the next occurrence
+		err = s.taskRep.RunInTx(ctx, func(ctx context.Context) error {
+			var err error
+			task, err = s.taskRep.UpdateTaskFields(ctx, task, fields, task_id, user_id, current.Version)
+			if err != nil {
+				return err
+			}
+
+			if task.Status == models.TaskStatusDone && current.Status != models.TaskStatusDone {
+				return s.scheduleNextOccurrence(ctx, task)
+			}
+			return nil
+		})
+		if err == sql.ErrNoRows {
+			if version != 0 {
+				return current, errTaskChanged
+			}
+			// Changed or deleted since it was read, patch again
+			continue
+		}
+		if err != nil {
+			return current, s.txError(err)
+		}
+
+		return task, nil
+	}
+
+	return models.Task{}, ServerError{http.StatusConflict, "task keeps changing, try again"}
+}
+
+// DeleteTask moves a task to the trash. A version other than 0 is the version
+// the client expects the task to be at.
+func (s *Service) DeleteTask(ctx context.Context, task_id, user_id int, version int64) error {
+	err := s.taskRep.DeleteTask(ctx, task_id, user_id, version)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return ServerError{http.StatusNotFound, "task with this id not found"}
+			return s.explainNoRows(ctx, task_id, user_id, version)
 		}
 		s.logger.Print(err)
 		return ServerError{http.StatusInternalServerError, "internal server error"}
 	}
+	return nil
+}
 
-	if task.UserID != user_id {
-		return ServerError{http.StatusUnauthorized, "user is unauthorized"}
+// explainNoRows turns a write to a task that matched nothing into the
+// reason: the task is missing or belongs to another user or, with a version
+// other than 0, was changed in the meantime.
+func (s *Service) explainNoRows(ctx context.Context, task_id, user_id int, version int64) error {
+	if err := s.CheckUserAuthority(ctx, user_id, task_id); err != nil {
+		return err
+	}
+	if version != 0 {
+		return errTaskChanged
+	}
+	return ServerError{http.StatusNotFound, "task with this id not found"}
+}
+
+func (s *Service) GetTrash(ctx context.Context, user_id, page, limit int) ([]models.Task, int, error) {
+	tasks, total, err := s.taskRep.GetTrash(ctx, user_id, page, limit)
+	if err != nil {
+		s.logger.Print(err)
+		return tasks, 0, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return tasks, total, nil
+}
+
+func (s *Service) RestoreTask(ctx context.Context, task_id, user_id int) (models.Task, error) {
+	task, err := s.taskRep.RestoreTask(ctx, task_id, user_id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return task, ServerError{http.StatusNotFound, "task with this id not found in trash"}
+		}
+		s.logger.Print(err)
+		return task, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return task, nil
+}
+
+func (s *Service) PurgeTask(ctx context.Context, task_id, user_id int) error {
+	err := s.taskRep.PurgeTask(ctx, task_id, user_id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ServerError{http.StatusNotFound, "task with this id not found in trash"}
+		}
+		s.logger.Print(err)
+		return ServerError{http.StatusInternalServerError, "internal server error"}
 	}
 
 	return nil
 }
 
-func (s *Service) AddTask(ctx context.Context, task models.Task) (models.Task, error) {
-	task, err := s.taskRep.AddTask(ctx, task)
+func (s *Service) EmptyTrash(ctx context.Context, user_id int) error {
+	_, err := s.taskRep.EmptyTrash(ctx, user_id)
 	if err != nil {
 		s.logger.Print(err)
+		return ServerError{http.StatusInternalServerError, "internal server error"}
 	}
 
-	return task, err
+	return nil
 }
 
-func (s *Service) UpdateTask(ctx context.Context, task models.Task, task_id, user_id int) (models.Task, error) {
-	task, err := s.taskRep.UpdateTask(ctx, task, task_id, user_id)
+func (s *Service) ArchiveTask(ctx context.Context, task_id, user_id int) (models.Task, error) {
+	task, err := s.taskRep.ArchiveTask(ctx, task_id, user_id)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return task, ServerError{http.StatusNotFound, "task with this id not found"}
+		}
 		s.logger.Print(err)
+		return task, ServerError{http.StatusInternalServerError, "internal server error"}
 	}
 
-	return task, err
+	return task, nil
 }
 
-func (s *Service) DeleteTask(ctx context.Context, task_id, user_id int) error {
-	err := s.taskRep.DeleteTask(ctx, task_id, user_id)
+func (s *Service) UnarchiveTask(ctx context.Context, task_id, user_id int) (models.Task, error) {
+	task, err := s.taskRep.UnarchiveTask(ctx, task_id, user_id)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return task, ServerError{http.StatusNotFound, "task with this id not found"}
+		}
 		s.logger.Print(err)
+		return task, ServerError{http.StatusInternalServerError, "internal server error"}
 	}
-	return err
+
+	return task, nil
 }
 
-func (s *Service) GetTasks(ctx context.Context, user_id, page, limit int) ([]models.Task, error) {
-	tasks, err := s.taskRep.GetTasks(ctx, user_id, page, limit)
+// ArchiveCompleted archives the done tasks of a list, of the inbox or, with
+// neither given, of all lists and returns how many tasks were archived.
+func (s *Service) ArchiveCompleted(ctx context.Context, user_id int, list_id *int, inbox bool) (int, error) {
+	if list_id != nil {
+		if err := s.checkListOwnership(ctx, *list_id, user_id); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := s.taskRep.ArchiveCompleted(ctx, user_id, list_id, inbox)
 	if err != nil {
 		s.logger.Print(err)
+		return 0, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return n, nil
+}
+
+func (s *Service) GetTasks(ctx context.Context, user_id int, filter models.TaskFilter, page, limit int) ([]models.Task, int, error) {
+	switch filter.Due {
+	case "":
+	case models.TaskDueOverdue:
+		filter.Overdue = true
+	default:
+		loc, err := s.userLocation(ctx, user_id)
+		if err != nil {
+			return nil, 0, err
+		}
+		from, before := dueRange(filter.Due, time.Now(), loc)
+		if filter.DueFrom == nil || from.After(*filter.DueFrom) {
+			filter.DueFrom = &from
+		}
+		if filter.DueBefore == nil || before.Before(*filter.DueBefore) {
+			filter.DueBefore = &before
+		}
+	}
+
+	if filter.Tree && filter.ParentID == nil {
+		filter.TopLevel = true
+	}
+
+	tasks, total, err := s.taskRep.GetTasks(ctx, user_id, filter, page, limit)
+	if err != nil {
+		s.logger.Print(err)
+		return tasks, 0, err
+	}
+
+	if filter.Tree {
+		root_ids := make([]int, len(tasks))
+		for i, task := range tasks {
+			root_ids[i] = task.ID
+		}
+
+		descendants, err := s.taskRep.GetTaskDescendants(ctx, user_id, root_ids, filter.Archived)
+		if err != nil {
+			s.logger.Print(err)
+			return nil, 0, err
+		}
+		nestSubtasks(tasks, descendants)
+	}
+
+	return tasks, total, nil
+}
+
+// GetPurgedTasks returns the ids of the tasks of a user that changed after
+// since and have been purged from the trash since, so they are no longer
+// returned as tombstones. It fails with 410 Gone when the change log has been
+// purged past since.
+func (s *Service) GetPurgedTasks(ctx context.Context, user_id int, since time.Time) ([]int, error) {
+	_, horizon, err := s.taskRep.GetChangeLogHorizon(ctx)
+	if err != nil {
+		s.logger.Print(err)
+		return nil, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+	if !since.After(horizon) {
+		return nil, ServerError{http.StatusGone, "updated_since expired, fetch all tasks again"}
+	}
+
+	ids, err := s.taskRep.GetPurgedTaskIDs(ctx, user_id, since)
+	if err != nil {
+		s.logger.Print(err)
+		return nil, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return ids, nil
+}
+
+func (s *Service) SearchTasks(ctx context.Context, user_id int, query string, archived string, page, limit int) ([]models.TaskSearchResult, int, error) {
+	results, total, err := s.taskRep.SearchTasks(ctx, user_id, query, archived, page, limit)
+	if err != nil {
+		s.logger.Print(err)
+		return results, 0, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return results, total, nil
+}
+
+func (s *Service) GetSubtasks(ctx context.Context, task_id, user_id int, filter models.TaskFilter, page, limit int) ([]models.Task, int, error) {
+	if _, err := s.getOwnedTask(ctx, task_id, user_id); err != nil {
+		return nil, 0, err
+	}
+
+	filter.ParentID = &task_id
+	filter.TopLevel = false
+	return s.GetTasks(ctx, user_id, filter, page, limit)
+}
+
+// getOwnedTask fetches a task, reporting tasks of other users as not found.
+func (s *Service) getOwnedTask(ctx context.Context, task_id, user_id int) (models.Task, error) {
+	task, err := s.taskRep.GetTaskByID(ctx, task_id)
+	return s.checkOwnedTask(task, err, user_id)
+}
+
+// lockOwnedTask is getOwnedTask for the status checks of a transaction: the
+// task, without tags and progress, stays locked until the transaction ends.
+func (s *Service) lockOwnedTask(ctx context.Context, task_id, user_id int) (models.Task, error) {
+	task, err := s.taskRep.LockTask(ctx, task_id)
+	return s.checkOwnedTask(task, err, user_id)
+}
+
+func (s *Service) checkOwnedTask(task models.Task, err error, user_id int) (models.Task, error) {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return task, ServerError{http.StatusNotFound, "task with this id not found"}
+		}
+		s.logger.Print(err)
+		return task, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	if task.UserID != user_id {
+		return models.Task{}, ServerError{http.StatusNotFound, "task with this id not found"}
+	}
+
+	return task, nil
+}
+
+// checkParent verifies that task_id (0 for a new task) can be placed under
+// parent_id without creating a cycle or exceeding models.TaskMaxDepth.
+func (s *Service) checkParent(ctx context.Context, task_id, parent_id, user_id int) error {
+	if parent_id == task_id {
+		return ServerError{http.StatusBadRequest, "task cannot be its own parent"}
+	}
+
+	if _, err := s.getOwnedTask(ctx, parent_id, user_id); err != nil {
+		if serr, ok := err.(ServerError); ok && serr.Code == http.StatusNotFound {
+			return ServerError{http.StatusNotFound, "parent task with this id not found"}
+		}
+		return err
+	}
+
+	ancestors, err := s.taskRep.GetAncestorIDs(ctx, parent_id)
+	if err != nil {
+		s.logger.Print(err)
+		return ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	height := 0
+	if task_id != 0 {
+		for _, id := range ancestors {
+			if id == task_id {
+				return ServerError{http.StatusBadRequest, "task cannot be moved under its own subtask"}
+			}
+		}
+
+		height, err = s.taskRep.GetSubtreeDepth(ctx, task_id)
+		if err != nil {
+			s.logger.Print(err)
+			return ServerError{http.StatusInternalServerError, "internal server error"}
+		}
+	}
+
+	// parent and its ancestors, the task itself and the levels below it
+	if len(ancestors)+1+1+height > models.TaskMaxDepth {
+		return ServerError{http.StatusBadRequest, fmt.Sprintf("tasks cannot be nested more than %d levels deep", models.TaskMaxDepth)}
+	}
+
+	return nil
+}
+
+// nestSubtasks attaches the flattened descendants to their parents.
+func nestSubtasks(roots []models.Task, descendants []models.Task) {
+	children := make(map[int][]models.Task)
+	for _, task := range descendants {
+		if task.ParentID != nil {
+			children[*task.ParentID] = append(children[*task.ParentID], task)
+		}
+	}
+
+	var attach func(task *models.Task, depth int)
+	attach = func(task *models.Task, depth int) {
+		if depth >= models.TaskMaxDepth {
+			return
+		}
+		task.Subtasks = children[task.ID]
+		for i := range task.Subtasks {
+			attach(&task.Subtasks[i], depth+1)
+		}
+	}
+
+	for i := range roots {
+		attach(&roots[i], 1)
+	}
+}
+
+func (s *Service) CompleteTask(ctx context.Context, task_id, user_id int) (models.Task, error) {
+	var task models.Task
+	err := s.taskRep.RunInTx(ctx, func(ctx context.Context) error {
+		// Concurrent completions wait for the lock, so only the first one
+		// sees the task open and schedules its next occurrence
+		before, err := s.lockOwnedTask(ctx, task_id, user_id)
+		if err != nil {
+			return err
+		}
+
+		task, err = s.setTaskStatus(ctx, task_id, user_id, models.TaskStatusDone)
+		if err != nil {
+			return err
+		}
+
+		if before.Status != models.TaskStatusDone {
+			return s.scheduleNextOccurrence(ctx, task)
+		}
+		return nil
+	})
+	return task, s.txError(err)
+}
+
+// txError passes on the ServerError a transaction failed with, and reports
+// any other failure, such as a failed commit, as an internal error.
+func (s *Service) txError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(ServerError); ok {
+		return err
+	}
+	s.logger.Print(err)
+	return ServerError{http.StatusInternalServerError, "internal server error"}
+}
+
+// scheduleNextOccurrence adds the next occurrence of a recurring task that has
+// just been completed, due at the next date of its rule after the current
+// due date (or after now when the task has none).
+func (s *Service) scheduleNextOccurrence(ctx context.Context, task models.Task) error {
+	if task.RRule == "" {
+		return nil
+	}
+
+	rule, err := utils.ParseRRule(task.RRule)
+	if err != nil {
+		// Rules are validated on input, so this is only logged
+		s.logger.Print(err)
+		return nil
+	}
+
+	loc, err := s.userLocation(ctx, task.UserID)
+	if err != nil {
+		return err
+	}
+
+	prev := time.Now()
+	if task.DueAt != nil {
+		prev = *task.DueAt
+	}
+
+	next, ok := rule.Next(prev.In(loc))
+	if !ok {
+		return nil
+	}
+
+	occurrence := models.Task{
+		UserID:      task.UserID,
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		Tags:        task.Tags,
+		ListID:      task.ListID,
+		ParentID:    task.ParentID,
+		RRule:       rule.Advance().String(),
+		DueAt:       &next,
+	}
+	if task.StartAt != nil && task.DueAt != nil {
+		startAt := next.Add(task.StartAt.Sub(*task.DueAt))
+		occurrence.StartAt = &startAt
+	}
+
+	_, err = s.taskRep.AddTask(ctx, occurrence)
+	if err != nil {
+		s.logger.Print(err)
+		return ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return nil
+}
+
+func (s *Service) ReopenTask(ctx context.Context, task_id, user_id int) (models.Task, error) {
+	return s.setTaskStatus(ctx, task_id, user_id, models.TaskStatusTodo)
+}
+
+func (s *Service) setTaskStatus(ctx context.Context, task_id, user_id int, status string) (models.Task, error) {
+	task, err := s.taskRep.SetTaskStatus(ctx, task_id, user_id, status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return task, ServerError{http.StatusNotFound, "task with this id not found"}
+		}
+		s.logger.Print(err)
+		return task, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return task, nil
+}
+
+func (s *Service) UpdateTimeZone(ctx context.Context, user_id int, timeZone string) error {
+	err := s.usrRep.UpdateTimeZone(ctx, user_id, timeZone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ServerError{http.StatusNotFound, "user not found"}
+		}
+		s.logger.Print(err)
+		return ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return nil
+}
+
+// userLocation returns the time zone the user's calendar days are computed in.
+func (s *Service) userLocation(ctx context.Context, user_id int) (*time.Location, error) {
+	usr, err := s.usrRep.GetUserByID(ctx, user_id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ServerError{http.StatusNotFound, "user not found"}
+		}
+		s.logger.Print(err)
+		return nil, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	if usr.TimeZone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(usr.TimeZone)
+	if err != nil {
+		s.logger.Print(err)
+		return time.UTC, nil
+	}
+
+	return loc, nil
+}
+
+// dueRange returns the [from, before) window of a due mode in loc. Days are
+// computed on the local calendar so they stay correct across DST changes.
+func dueRange(mode string, now time.Time, loc *time.Location) (time.Time, time.Time) {
+	y, m, d := now.In(loc).Date()
+	startOfDay := time.Date(y, m, d, 0, 0, 0, 0, loc)
+
+	switch mode {
+	case models.TaskDueTomorrow:
+		return startOfDay.AddDate(0, 0, 1), startOfDay.AddDate(0, 0, 2)
+	case models.TaskDueWeek:
+		return startOfDay, startOfDay.AddDate(0, 0, 7)
+	default:
+		return startOfDay, startOfDay.AddDate(0, 0, 1)
+	}
+}
+
+func (s *Service) GetTags(ctx context.Context, user_id int) ([]models.Tag, error) {
+	tags, err := s.tagRep.GetTags(ctx, user_id)
+	if err != nil {
+		s.logger.Print(err)
+	}
+	return tags, err
+}
+
+func (s *Service) AddTag(ctx context.Context, tag models.Tag) (models.Tag, error) {
+	tag, err := s.tagRep.AddTag(ctx, tag)
+	if err != nil {
+		if err == repository.ErrDuplicate {
+			return tag, ServerError{http.StatusConflict, "tag with this name already exists"}
+		}
+		s.logger.Print(err)
+		return tag, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return tag, nil
+}
+
+func (s *Service) UpdateTag(ctx context.Context, tag models.Tag, tag_id, user_id int) (models.Tag, error) {
+	tag, err := s.tagRep.UpdateTag(ctx, tag, tag_id, user_id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return tag, ServerError{http.StatusNotFound, "tag with this id not found"}
+		case repository.ErrDuplicate:
+			return tag, ServerError{http.StatusConflict, "tag with this name already exists"}
+		}
+		s.logger.Print(err)
+		return tag, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return tag, nil
+}
+
+func (s *Service) DeleteTag(ctx context.Context, tag_id, user_id int) error {
+	err := s.tagRep.DeleteTag(ctx, tag_id, user_id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ServerError{http.StatusNotFound, "tag with this id not found"}
+		}
+		s.logger.Print(err)
+		return ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return nil
+}
+
+func (s *Service) MoveTask(ctx context.Context, task_id, user_id int, list_id *int) (models.Task, error) {
+	if list_id != nil {
+		if err := s.checkListOwnership(ctx, *list_id, user_id); err != nil {
+			return models.Task{}, err
+		}
+	}
+
+	task, err := s.taskRep.MoveTask(ctx, task_id, user_id, list_id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return task, ServerError{http.StatusNotFound, "task with this id not found"}
+		}
+		s.logger.Print(err)
+		return task, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return task, nil
+}
+
+func (s *Service) checkListOwnership(ctx context.Context, list_id, user_id int) error {
+	_, err := s.listRep.GetListByID(ctx, list_id, user_id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ServerError{http.StatusNotFound, "list with this id not found"}
+		}
+		s.logger.Print(err)
+		return ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return nil
+}
+
+func (s *Service) GetLists(ctx context.Context, user_id int) ([]models.List, error) {
+	lists, err := s.listRep.GetLists(ctx, user_id)
+	if err != nil {
+		s.logger.Print(err)
+	}
+	return lists, err
+}
+
+func (s *Service) GetListTasks(ctx context.Context, list_id, user_id int, filter models.TaskFilter, page, limit int) ([]models.Task, int, error) {
+	if err := s.checkListOwnership(ctx, list_id, user_id); err != nil {
+		return nil, 0, err
+	}
+
+	filter.ListID = &list_id
+	filter.Inbox = false
+	return s.GetTasks(ctx, user_id, filter, page, limit)
+}
+
+func (s *Service) AddList(ctx context.Context, list models.List) (models.List, error) {
+	list, err := s.listRep.AddList(ctx, list)
+	if err != nil {
+		s.logger.Print(err)
+	}
+	return list, err
+}
+
+func (s *Service) UpdateList(ctx context.Context, list models.List, list_id, user_id int) (models.List, error) {
+	list, err := s.listRep.UpdateList(ctx, list, list_id, user_id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return list, ServerError{http.StatusNotFound, "list with this id not found"}
+		}
+		s.logger.Print(err)
+		return list, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return list, nil
+}
+
+func (s *Service) DeleteList(ctx context.Context, list_id, user_id int, deleteTasks bool) error {
+	err := s.listRep.DeleteList(ctx, list_id, user_id, deleteTasks)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ServerError{http.StatusNotFound, "list with this id not found"}
+		}
+		s.logger.Print(err)
+		return ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return nil
+}
+
+func (s *Service) GetReminders(ctx context.Context, task_id, user_id int) ([]models.Reminder, error) {
+	if _, err := s.getOwnedTask(ctx, task_id, user_id); err != nil {
+		return nil, err
+	}
+
+	reminders, err := s.remRep.GetReminders(ctx, task_id, user_id)
+	if err != nil {
+		s.logger.Print(err)
+		return reminders, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return reminders, nil
+}
+
+func (s *Service) AddReminder(ctx context.Context, reminder models.Reminder) (models.Reminder, error) {
+	task, err := s.getOwnedTask(ctx, reminder.TaskID, reminder.UserID)
+	if err != nil {
+		return reminder, err
+	}
+
+	if reminder.OffsetMinutes != nil && task.DueAt == nil {
+		return reminder, ServerError{http.StatusBadRequest, "task has no due date"}
+	}
+
+	reminder, err = s.remRep.AddReminder(ctx, reminder)
+	if err != nil {
+		s.logger.Print(err)
+		return reminder, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return reminder, nil
+}
+
+func (s *Service) DeleteReminder(ctx context.Context, reminder_id, task_id, user_id int) error {
+	err := s.remRep.DeleteReminder(ctx, reminder_id, task_id, user_id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ServerError{http.StatusNotFound, "reminder with this id not found"}
+		}
+		s.logger.Print(err)
+		return ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return nil
+}
+
+// syncRetries bounds how often a change is merged again when its task is
+// changed concurrently.
+const syncRetries = 3
+
+// Sync applies a batch of offline changes in order and returns their outcomes
+// together with the tasks changed since req.Since, and the change log
+// position the next sync continues from. The batch runs in one transaction,
+// each change in a savepoint of its own: a change that fails with a client
+// error is reported in its result, any other failure undoes the whole batch.
+func (s *Service) Sync(ctx context.Context, user_id int, req models.SyncRequest) (models.SyncResult, uint64, error) {
+	if req.Since > 0 {
+		horizon, _, err := s.taskRep.GetChangeLogHorizon(ctx)
+		if err != nil {
+			s.logger.Print(err)
+			return models.SyncResult{}, 0, ServerError{http.StatusInternalServerError, "internal server error"}
+		}
+		if req.Since <= horizon {
+			return models.SyncResult{}, 0, ServerError{http.StatusGone, "sync token expired, sync again without it"}
+		}
+	}
+
+	result := models.SyncResult{Results: make([]models.TaskChangeResult, 0, len(req.Changes))}
+	var next uint64
+	err := s.taskRep.RunInTx(ctx, func(ctx context.Context) error {
+		for _, change := range req.Changes {
+			var res models.TaskChangeResult
+			err := s.taskRep.RunInTx(ctx, func(ctx context.Context) error {
+				var err error
+				res, err = s.applyChange(ctx, user_id, change, req.Strategy)
+				return err
+			})
+			if err != nil {
+				serr, ok := err.(ServerError)
+				if !ok || serr.Code >= http.StatusInternalServerError {
+					return err
+				}
+				res = models.TaskChangeResult{Status: models.ChangeRejected, Error: serr.Message}
+			}
+			res.ClientID = change.ClientID
+			if res.ID == 0 {
+				res.ID = change.ID
+			}
+			result.Results = append(result.Results, res)
+		}
+
+		// The position returned from within the transaction lies before its
+		// own changes, which the next sync therefore returns once more
+		tasks, deleted, pos, err := s.taskRep.GetChangesSince(ctx, user_id, req.Since)
+		if err != nil {
+			return err
+		}
+		result.Changes, result.Deleted, next = tasks, deleted, pos
+		return nil
+	})
+	if err != nil {
+		s.logger.Print(err)
+		return models.SyncResult{}, 0, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	return result, next, nil
+}
+
+// applyChange creates, updates or deletes a task according to change,
+// merging it with what changed on the server since its base version.
+func (s *Service) applyChange(ctx context.Context, user_id int, change models.TaskChange, strategy string) (models.TaskChangeResult, error) {
+	if change.ID == 0 {
+		change.Task.UserID = user_id
+		task, err := s.AddTask(ctx, change.Task)
+		if err != nil {
+			return models.TaskChangeResult{}, err
+		}
+		return models.TaskChangeResult{ID: task.ID, Version: task.Version, Status: models.ChangeApplied}, nil
+	}
+
+	for range syncRetries {
+		current, err := s.taskRep.GetTaskByID(ctx, change.ID)
+		if err == sql.ErrNoRows || (err == nil && current.UserID != user_id) {
+			return models.TaskChangeResult{Status: models.ChangeNotFound}, nil
+		}
+		if err != nil {
+			s.logger.Print(err)
+			return models.TaskChangeResult{}, err
+		}
+
+		fields, conflicts, err := s.mergeChange(ctx, current, change, strategy)
+		if err != nil {
+			return models.TaskChangeResult{}, err
+		}
+
+		res := models.TaskChangeResult{Version: current.Version, Status: models.ChangeApplied, Conflicts: conflicts}
+		for _, c := range conflicts {
+			if c.Resolution == "server" {
+				res.Status = models.ChangeConflict
+			}
+		}
+		if len(fields) == 0 {
+			return res, nil
+		}
+
+		if change.Deleted {
+			err := s.DeleteTask(ctx, change.ID, user_id, current.Version)
+			if err == errTaskChanged {
+				// Changed or deleted since it was read, merge again
+				continue
+			}
+			if err != nil {
+				return res, err
+			}
+			res.Version = 0
+			return res, nil
+		}
+
+		if slices.Contains(fields, "parent_id") && change.Task.ParentID != nil {
+			if err := s.checkParent(ctx, change.ID, *change.Task.ParentID, user_id); err != nil {
+				return res, err
+			}
+		}
+		if slices.Contains(fields, "list_id") && change.Task.ListID != nil {
+			if err := s.checkListOwnership(ctx, *change.Task.ListID, user_id); err != nil {
+				return res, err
+			}
+		}
+
+		task, err := s.taskRep.UpdateTaskFields(ctx, change.Task, fields, change.ID, user_id, current.Version)
+		if err == sql.ErrNoRows {
+			// Changed or deleted since it was read, merge again
+			continue
+		}
+		if err != nil {
+			s.logger.Print(err)
+			return res, err
+		}
+
+		if task.Status == models.TaskStatusDone && current.Status != models.TaskStatusDone {
+			if err := s.scheduleNextOccurrence(ctx, task); err != nil {
+				return res, err
+			}
+		}
+
+		res.Version = task.Version
+		return res, nil
+	}
+
+	return models.TaskChangeResult{}, ServerError{http.StatusConflict, "task keeps changing, try again"}
+}
+
+// mergeChange returns the fields of change to apply on top of current, or
+// "deleted" for a deletion, and the fields changed both by the client and on
+// the server since the change's base version. With the last_writer_wins
+// strategy a conflicting field goes to whichever side changed it last,
+// otherwise the server keeps its value.
+func (s *Service) mergeChange(ctx context.Context, current models.Task, change models.TaskChange, strategy string) ([]string, []models.FieldConflict, error) {
+	fields := make([]string, 0, len(change.Fields))
+	for field := range change.Fields {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	if change.Deleted {
+		fields = []string{"deleted"}
+	}
+
+	if current.Version == change.BaseVersion {
+		return fields, nil, nil
+	}
+
+	serverChanges, err := s.taskRep.GetFieldChanges(ctx, current.ID, change.BaseVersion)
+	if err == repository.ErrChangeLogPurged {
+		// What changed since the base version is no longer known, so any
+		// field may have been changed on the server
+		serverChanges = make(map[string]time.Time, len(fields))
+		for _, field := range fields {
+			serverChanges[field] = current.UpdatedAt
+		}
+	} else if err != nil {
+		s.logger.Print(err)
+		return nil, nil, err
+	}
+
+	clientWins := func(changedAt time.Time) bool {
+		return strategy == models.SyncStrategyLastWriterWins && !change.ChangedAt.Before(changedAt)
+	}
+
+	if change.Deleted {
+		if len(serverChanges) == 0 {
+			return fields, nil, nil
+		}
+
+		// Any change on the server conflicts with deleting the task
+		var latest time.Time
+		for _, changedAt := range serverChanges {
+			if changedAt.After(latest) {
+				latest = changedAt
+			}
+		}
+		conflict := models.FieldConflict{Field: "deleted", ServerValue: false, ClientValue: true, Resolution: "server"}
+		if clientWins(latest) {
+			conflict.Resolution = "client"
+			return fields, []models.FieldConflict{conflict}, nil
+		}
+		return nil, []models.FieldConflict{conflict}, nil
+	}
+
+	var apply []string
+	var conflicts []models.FieldConflict
+	for _, field := range fields {
+		serverValue, clientValue := current.FieldValue(field), change.Task.FieldValue(field)
+		changedAt, changed := serverChanges[field]
+		if !changed || sameValue(serverValue, clientValue) {
+			apply = append(apply, field)
+			continue
+		}
+
+		conflict := models.FieldConflict{Field: field, ServerValue: serverValue, ClientValue: clientValue, Resolution: "server"}
+		if clientWins(changedAt) {
+			conflict.Resolution = "client"
+			apply = append(apply, field)
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	return apply, conflicts, nil
+}
+
+// sameValue compares two values returned by Task.FieldValue.
+func sameValue(a, b any) bool {
+	if ta, ok := a.(*time.Time); ok {
+		tb := b.(*time.Time)
+		if ta == nil || tb == nil {
+			return ta == tb
+		}
+		return ta.Equal(*tb)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// errBulkRolledBack ends the transaction of an atomic bulk request after an
+// operation failed.
+var errBulkRolledBack = errors.New("bulk request rolled back")
+
+// BulkTasks runs the operations of req in one transaction, each in a
+// savepoint of its own. An operation that fails with a client error is
+// reported in its result; any other failure fails the whole request.
+func (s *Service) BulkTasks(ctx context.Context, user_id int, req models.BulkRequest) (models.BulkResult, error) {
+	result := models.BulkResult{Results: make([]models.BulkOperationResult, len(req.Operations))}
+	for i, op := range req.Operations {
+		result.Results[i] = models.BulkOperationResult{ID: op.ID, Status: models.BulkSkipped}
+	}
+
+	err := s.taskRep.RunInTx(ctx, func(ctx context.Context) error {
+		for i, op := range req.Operations {
+			var task models.Task
+			err := s.taskRep.RunInTx(ctx, func(ctx context.Context) error {
+				var err error
+				task, err = s.runBulkOperation(ctx, user_id, op)
+				return err
+			})
+
+			res := &result.Results[i]
+			if err != nil {
+				serr, ok := err.(ServerError)
+				if !ok || serr.Code >= http.StatusInternalServerError {
+					return err
+				}
+				res.Status, res.Code, res.Error = models.BulkFailed, serr.Code, serr.Message
+				if req.Atomic {
+					return errBulkRolledBack
+				}
+				continue
+			}
+
+			res.Status = models.BulkOK
+			if op.Op != models.BulkDelete {
+				res.ID, res.Task = task.ID, &task
+			}
+		}
+		return nil
+	})
+
+	if err == errBulkRolledBack {
+		for i := range result.Results {
+			if res := &result.Results[i]; res.Status == models.BulkOK {
+				res.Status, res.Task = models.BulkRolledBack, nil
+				res.ID = req.Operations[i].ID
+			}
+		}
+		return result, nil
+	}
+	if err != nil {
+		s.logger.Print(err)
+		return models.BulkResult{}, ServerError{http.StatusInternalServerError, "internal server error"}
+	}
+
+	result.Committed = true
+	return result, nil
+}
+
+// runBulkOperation runs a single operation of a bulk request.
+func (s *Service) runBulkOperation(ctx context.Context, user_id int, op models.BulkOperation) (models.Task, error) {
+	switch op.Op {
+	case models.BulkCreate:
+		op.Task.UserID = user_id
+		return s.AddTask(ctx, op.Task)
+	case models.BulkUpdate:
+		return s.UpdateTask(ctx, op.Task, op.ID, user_id, op.Version)
+	case models.BulkDelete:
+		return models.Task{}, s.DeleteTask(ctx, op.ID, user_id, op.Version)
+	case models.BulkComplete:
+		return s.CompleteTask(ctx, op.ID, user_id)
 	}
-	return tasks, err
+	return models.Task{}, ServerError{http.StatusBadRequest, "unknown operation"}
 }
@@ -3,11 +3,18 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"log"
+	"net/http"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/NeGat1FF/todolist-api/internal/repository"
+	"github.com/NeGat1FF/todolist-api/internal/utils"
 	"github.com/NeGat1FF/todolist-api/mocks"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
@@ -45,16 +52,24 @@ func TestRegisterUser(t *testing.T) {
 			// Initialize mocks using the new syntax
 			UserRepoMock := mocks.NewUserRepositoryInterface(t)
 			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			TokenRepoMock := mocks.NewTokenRepositoryInterface(t)
 			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
 
 			// Initialize service with mocks and logger
-			s := NewService(UserRepoMock, TaskRepoMock, logger)
+			s := NewService(UserRepoMock, TaskRepoMock, nil, nil, nil, TokenRepoMock, nil, logger)
 
 			// Set up mock expectations
 			tc.mockSetup(UserRepoMock)
+			TokenRepoMock.On("AddRefreshToken", mock.Anything, mock.MatchedBy(func(token models.RefreshToken) bool {
+				return token.UserID == 1 && token.FamilyID != "" && len(token.TokenHash) == 64
+			})).Return(nil).Maybe()
+			UserRepoMock.On("GetTokenVersion", mock.Anything, 1).Return(0, nil).Maybe()
+			TokenRepoMock.On("AddSession", mock.Anything, mock.MatchedBy(func(session models.Session) bool {
+				return session.UserID == 1 && session.ID != "" && session.DeviceName == "Laptop"
+			})).Return(nil).Maybe()
 
 			// Call the service method
-			_, _, err := s.RegisterUser(context.TODO(), tc.inputUser)
+			_, _, err := s.RegisterUser(context.TODO(), tc.inputUser, models.Session{DeviceName: "Laptop"})
 
 			// Check for errors
 			if (err != nil) != tc.expectedError {
@@ -103,13 +118,21 @@ func TestLoginUser(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			UserRepoMock := mocks.NewUserRepositoryInterface(t)
 			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			TokenRepoMock := mocks.NewTokenRepositoryInterface(t)
 			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
 
-			s := NewService(UserRepoMock, TaskRepoMock, logger)
+			s := NewService(UserRepoMock, TaskRepoMock, nil, nil, nil, TokenRepoMock, nil, logger)
 
 			tc.mockSetup(UserRepoMock)
+			TokenRepoMock.On("AddRefreshToken", mock.Anything, mock.MatchedBy(func(token models.RefreshToken) bool {
+				return token.UserID == 1 && token.FamilyID != "" && len(token.TokenHash) == 64
+			})).Return(nil).Maybe()
+			UserRepoMock.On("GetTokenVersion", mock.Anything, 1).Return(0, nil).Maybe()
+			TokenRepoMock.On("AddSession", mock.Anything, mock.MatchedBy(func(session models.Session) bool {
+				return session.UserID == 1 && session.ID != "" && session.DeviceName == "Laptop"
+			})).Return(nil).Maybe()
 
-			_, _, err := s.LoginUser(context.TODO(), tc.inputUser)
+			_, _, err := s.LoginUser(context.TODO(), tc.inputUser, models.Session{DeviceName: "Laptop"})
 
 			if (err != nil) != tc.expectedError {
 				t.Errorf("Expected error: %v, got: %v", tc.expectedError, err)
@@ -125,6 +148,7 @@ func TestCheckUserAuthority(t *testing.T) {
 		taskID        int
 		taskUserID    int
 		mockSetup     func(taskRepoMock *mocks.TaskRepositoryInterface)
+		expectedCode  int
 		expectedError bool
 	}{
 		{
@@ -145,6 +169,7 @@ func TestCheckUserAuthority(t *testing.T) {
 			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
 				taskRepoMock.On("GetTaskByID", context.TODO(), mock.AnythingOfType("int")).Return(models.Task{UserID: 2, Title: "Test task", ID: 5}, nil)
 			},
+			expectedCode:  http.StatusNotFound,
 			expectedError: true,
 		},
 		{
@@ -155,6 +180,7 @@ func TestCheckUserAuthority(t *testing.T) {
 			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
 				taskRepoMock.On("GetTaskByID", context.TODO(), mock.AnythingOfType("int")).Return(models.Task{}, sql.ErrNoRows)
 			},
+			expectedCode:  http.StatusNotFound,
 			expectedError: true,
 		},
 	}
@@ -165,7 +191,7 @@ func TestCheckUserAuthority(t *testing.T) {
 			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
 			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
 
-			s := NewService(UserRepoMock, TaskRepoMock, logger)
+			s := NewService(UserRepoMock, TaskRepoMock, nil, nil, nil, nil, nil, logger)
 
 			tc.mockSetup(TaskRepoMock)
 
@@ -174,6 +200,9 @@ func TestCheckUserAuthority(t *testing.T) {
 			if (err != nil) != tc.expectedError {
 				t.Errorf("Expected error: %v, got: %v", tc.expectedError, err)
 			}
+			if err != nil && err.(ServerError).Code != tc.expectedCode {
+				t.Errorf("Expected code: %d, got: %d", tc.expectedCode, err.(ServerError).Code)
+			}
 		})
 	}
 }
@@ -183,6 +212,7 @@ func TestAddTask(t *testing.T) {
 		name          string
 		inputTask     models.Task
 		mockSetup     func(taskRepoMock *mocks.TaskRepositoryInterface)
+		expectedCode  int
 		expectedError bool
 	}{
 		{
@@ -199,6 +229,25 @@ func TestAddTask(t *testing.T) {
 			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
 				taskRepoMock.On("AddTask", mock.Anything, mock.Anything).Return(models.Task{}, sql.ErrConnDone)
 			},
+			expectedCode:  http.StatusInternalServerError,
+			expectedError: true,
+		},
+		{
+			name:      "Parent deleted meanwhile",
+			inputTask: models.Task{Title: "Test Task", UserID: 1},
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("AddTask", mock.Anything, mock.Anything).Return(models.Task{}, repository.ErrMissingReference)
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: true,
+		},
+		{
+			name:      "Constraint violated",
+			inputTask: models.Task{Title: "Test Task", UserID: 1},
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("AddTask", mock.Anything, mock.Anything).Return(models.Task{}, repository.ErrInvalidRecord)
+			},
+			expectedCode:  http.StatusBadRequest,
 			expectedError: true,
 		},
 	}
@@ -209,7 +258,7 @@ func TestAddTask(t *testing.T) {
 			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
 			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
 
-			s := NewService(UserRepoMock, TaskRepoMock, logger)
+			s := NewService(UserRepoMock, TaskRepoMock, nil, nil, nil, nil, nil, logger)
 
 			tc.mockSetup(TaskRepoMock)
 
@@ -218,6 +267,9 @@ func TestAddTask(t *testing.T) {
 			if (err != nil) != tc.expectedError {
 				t.Errorf("Expected error: %v, got: %v", tc.expectedError, err)
 			}
+			if err != nil && err.(ServerError).Code != tc.expectedCode {
+				t.Errorf("Expected code: %d, got: %d", tc.expectedCode, err.(ServerError).Code)
+			}
 		})
 	}
 }
@@ -236,7 +288,7 @@ func TestUpdateTask(t *testing.T) {
 			taskID:    1,
 			userID:    1,
 			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
-				taskRepoMock.On("UpdateTask", mock.Anything, mock.Anything, 1, 1).Return(models.Task{Title: "Updated Task", UserID: 1, ID: 1}, nil)
+				taskRepoMock.On("UpdateTask", mock.Anything, mock.Anything, 1, 1, int64(0)).Return(models.Task{Title: "Updated Task", UserID: 1, ID: 1}, nil)
 			},
 			expectedError: false,
 		},
@@ -246,8 +298,82 @@ func TestUpdateTask(t *testing.T) {
 			taskID:    1,
 			userID:    1,
 			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
-				taskRepoMock.On("UpdateTask", mock.Anything, mock.Anything, 1, 1).Return(models.Task{}, sql.ErrConnDone)
+				taskRepoMock.On("UpdateTask", mock.Anything, mock.Anything, 1, 1, int64(0)).Return(models.Task{}, sql.ErrConnDone)
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			UserRepoMock := mocks.NewUserRepositoryInterface(t)
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(UserRepoMock, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+			TaskRepoMock.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
+			tc.mockSetup(TaskRepoMock)
+
+			_, err := s.UpdateTask(context.TODO(), tc.inputTask, tc.taskID, tc.userID, 0)
+
+			if (err != nil) != tc.expectedError {
+				t.Errorf("Expected error: %v, got: %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestCompleteTask(t *testing.T) {
+	testCases := []struct {
+		name          string
+		taskID        int
+		userID        int
+		mockSetup     func(taskRepoMock *mocks.TaskRepositoryInterface)
+		expectedCode  int
+		expectedError bool
+	}{
+		{
+			name:   "Complete task successfully",
+			taskID: 1,
+			userID: 1,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("LockTask", mock.Anything, 1).Return(models.Task{ID: 1, UserID: 1, Status: models.TaskStatusTodo}, nil)
+				taskRepoMock.On("SetTaskStatus", mock.Anything, 1, 1, models.TaskStatusDone).Return(models.Task{ID: 1, UserID: 1, Status: models.TaskStatusDone}, nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:   "Task not found",
+			taskID: 2,
+			userID: 1,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("LockTask", mock.Anything, 2).Return(models.Task{}, sql.ErrNoRows)
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: true,
+		},
+		{
+			name:   "Task of another user",
+			taskID: 3,
+			userID: 1,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("LockTask", mock.Anything, 3).Return(models.Task{ID: 3, UserID: 2}, nil)
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: true,
+		},
+		{
+			name:   "Repository error",
+			taskID: 1,
+			userID: 1,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("LockTask", mock.Anything, 1).Return(models.Task{ID: 1, UserID: 1}, nil)
+				taskRepoMock.On("SetTaskStatus", mock.Anything, 1, 1, models.TaskStatusDone).Return(models.Task{}, sql.ErrConnDone)
 			},
+			expectedCode:  http.StatusInternalServerError,
 			expectedError: true,
 		},
 	}
@@ -258,15 +384,1563 @@ func TestUpdateTask(t *testing.T) {
 			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
 			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
 
-			s := NewService(UserRepoMock, TaskRepoMock, logger)
+			s := NewService(UserRepoMock, TaskRepoMock, nil, nil, nil, nil, nil, logger)
 
+			TaskRepoMock.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
 			tc.mockSetup(TaskRepoMock)
 
-			_, err := s.UpdateTask(context.TODO(), tc.inputTask, tc.taskID, tc.userID)
+			_, err := s.CompleteTask(context.TODO(), tc.taskID, tc.userID)
+
+			if (err != nil) != tc.expectedError {
+				t.Errorf("Expected error: %v, got: %v", tc.expectedError, err)
+			}
+			if err != nil && err.(ServerError).Code != tc.expectedCode {
+				t.Errorf("Expected code: %d, got: %d", tc.expectedCode, err.(ServerError).Code)
+			}
+		})
+	}
+}
+
+func TestCompleteTaskSchedulesNextOccurrence(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listID := 4
+	dueAt := time.Date(2024, 3, 2, 9, 0, 0, 0, newYork)
+	startAt := dueAt.Add(-time.Hour)
+
+	testCases := []struct {
+		name         string
+		before       models.Task
+		completed    models.Task
+		expectedDue  *time.Time
+		expectedRule string
+	}{
+		{
+			name:   "Weekly task across DST keeps the local time",
+			before: models.Task{ID: 1, UserID: 1, Status: models.TaskStatusTodo},
+			completed: models.Task{ID: 1, UserID: 1, Title: "Take out trash", Status: models.TaskStatusDone, Priority: 2,
+				ListID: &listID, Tags: []models.Tag{{Name: "chores"}}, StartAt: &startAt, DueAt: &dueAt, RRule: "FREQ=WEEKLY;COUNT=3"},
+			expectedDue:  func() *time.Time { d := time.Date(2024, 3, 9, 9, 0, 0, 0, newYork); return &d }(),
+			expectedRule: "FREQ=WEEKLY;COUNT=2",
+		},
+		{
+			name:      "Already done task is not repeated",
+			before:    models.Task{ID: 1, UserID: 1, Status: models.TaskStatusDone},
+			completed: models.Task{ID: 1, UserID: 1, Status: models.TaskStatusDone, DueAt: &dueAt, RRule: "FREQ=DAILY"},
+		},
+		{
+			name:      "Last occurrence",
+			before:    models.Task{ID: 1, UserID: 1, Status: models.TaskStatusTodo},
+			completed: models.Task{ID: 1, UserID: 1, Status: models.TaskStatusDone, DueAt: &dueAt, RRule: "FREQ=DAILY;COUNT=1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			UserRepoMock := mocks.NewUserRepositoryInterface(t)
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(UserRepoMock, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+			TaskRepoMock.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
+			TaskRepoMock.On("LockTask", mock.Anything, 1).Return(tc.before, nil)
+			TaskRepoMock.On("SetTaskStatus", mock.Anything, 1, 1, models.TaskStatusDone).Return(tc.completed, nil)
+			if tc.before.Status != models.TaskStatusDone {
+				UserRepoMock.On("GetUserByID", mock.Anything, 1).Return(models.User{ID: 1, TimeZone: "America/New_York"}, nil)
+			}
+			if tc.expectedDue != nil {
+				TaskRepoMock.On("AddTask", mock.Anything, mock.MatchedBy(func(task models.Task) bool {
+					return task.Title == tc.completed.Title && task.Status == "" && task.Priority == tc.completed.Priority &&
+						task.ListID == tc.completed.ListID && len(task.Tags) == 1 && task.RRule == tc.expectedRule &&
+						task.DueAt != nil && task.DueAt.Equal(*tc.expectedDue) &&
+						task.StartAt != nil && task.StartAt.Equal(tc.expectedDue.Add(-time.Hour))
+				})).Return(models.Task{ID: 2}, nil)
+			}
+
+			_, err := s.CompleteTask(context.TODO(), 1, 1)
+			if err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestCompleteTaskFailsWithNextOccurrence(t *testing.T) {
+	UserRepoMock := mocks.NewUserRepositoryInterface(t)
+	TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	s := NewService(UserRepoMock, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+	dueAt := time.Date(2024, 10, 20, 9, 0, 0, 0, time.UTC)
+	var txErr error
+	TaskRepoMock.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		txErr = fn(ctx)
+		return txErr
+	})
+	TaskRepoMock.On("LockTask", mock.Anything, 1).Return(models.Task{ID: 1, UserID: 1, Status: models.TaskStatusTodo}, nil)
+	TaskRepoMock.On("SetTaskStatus", mock.Anything, 1, 1, models.TaskStatusDone).Return(models.Task{ID: 1, UserID: 1, Status: models.TaskStatusDone, DueAt: &dueAt, RRule: "FREQ=DAILY"}, nil)
+	UserRepoMock.On("GetUserByID", mock.Anything, 1).Return(models.User{ID: 1}, nil)
+	TaskRepoMock.On("AddTask", mock.Anything, mock.Anything).Return(models.Task{}, sql.ErrConnDone)
+
+	_, err := s.CompleteTask(context.TODO(), 1, 1)
+	if err == nil || err.(ServerError).Code != http.StatusInternalServerError {
+		t.Errorf("Expected code %d, got: %v", http.StatusInternalServerError, err)
+	}
+	if txErr == nil {
+		t.Error("Expected the transaction to be rolled back")
+	}
+}
+
+func TestAddTaskSetsCompletedAt(t *testing.T) {
+	UserRepoMock := mocks.NewUserRepositoryInterface(t)
+	TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	s := NewService(UserRepoMock, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+	TaskRepoMock.On("AddTask", mock.Anything, mock.MatchedBy(func(task models.Task) bool {
+		return task.CompletedAt != nil
+	})).Return(models.Task{ID: 1, Status: models.TaskStatusDone}, nil)
+
+	_, err := s.AddTask(context.TODO(), models.Task{Title: "Done already", Status: models.TaskStatusDone})
+	if err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+}
+
+func TestDueRange(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name           string
+		mode           string
+		now            time.Time
+		loc            *time.Location
+		expectedFrom   time.Time
+		expectedBefore time.Time
+	}{
+		{
+			name:           "Today in UTC",
+			mode:           models.TaskDueToday,
+			now:            time.Date(2024, 10, 5, 15, 30, 0, 0, time.UTC),
+			loc:            time.UTC,
+			expectedFrom:   time.Date(2024, 10, 5, 0, 0, 0, 0, time.UTC),
+			expectedBefore: time.Date(2024, 10, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:           "Today uses the user's calendar day",
+			mode:           models.TaskDueToday,
+			now:            time.Date(2024, 10, 5, 2, 0, 0, 0, time.UTC),
+			loc:            newYork,
+			expectedFrom:   time.Date(2024, 10, 4, 0, 0, 0, 0, newYork),
+			expectedBefore: time.Date(2024, 10, 5, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:           "Tomorrow across DST start is 23 hours long",
+			mode:           models.TaskDueTomorrow,
+			now:            time.Date(2024, 3, 9, 12, 0, 0, 0, newYork),
+			loc:            newYork,
+			expectedFrom:   time.Date(2024, 3, 10, 0, 0, 0, 0, newYork),
+			expectedBefore: time.Date(2024, 3, 11, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:           "Week",
+			mode:           models.TaskDueWeek,
+			now:            time.Date(2024, 10, 30, 9, 0, 0, 0, time.UTC),
+			loc:            time.UTC,
+			expectedFrom:   time.Date(2024, 10, 30, 0, 0, 0, 0, time.UTC),
+			expectedBefore: time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			from, before := dueRange(tc.mode, tc.now, tc.loc)
+
+			if !from.Equal(tc.expectedFrom) || !before.Equal(tc.expectedBefore) {
+				t.Errorf("Expected [%v, %v), got: [%v, %v)", tc.expectedFrom, tc.expectedBefore, from, before)
+			}
+		})
+	}
+}
+
+func TestGetTasksDueToday(t *testing.T) {
+	UserRepoMock := mocks.NewUserRepositoryInterface(t)
+	TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	s := NewService(UserRepoMock, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+	UserRepoMock.On("GetUserByID", mock.Anything, 1).Return(models.User{ID: 1, TimeZone: "Asia/Tokyo"}, nil)
+	TaskRepoMock.On("GetTasks", mock.Anything, 1, mock.MatchedBy(func(filter models.TaskFilter) bool {
+		return filter.DueFrom != nil && filter.DueBefore != nil &&
+			filter.DueFrom.Location().String() == "Asia/Tokyo" &&
+			filter.DueBefore.Sub(*filter.DueFrom) == 24*time.Hour
+	}), 1, 10).Return([]models.Task{}, 0, nil)
+
+	_, _, err := s.GetTasks(context.TODO(), 1, models.TaskFilter{Due: models.TaskDueToday}, 1, 10)
+	if err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+}
+
+func TestAddTag(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputTag      models.Tag
+		mockSetup     func(tagRepoMock *mocks.TagRepositoryInterface)
+		expectedCode  int
+		expectedError bool
+	}{
+		{
+			name:     "Add tag successfully",
+			inputTag: models.Tag{UserID: 1, Name: "work"},
+			mockSetup: func(tagRepoMock *mocks.TagRepositoryInterface) {
+				tagRepoMock.On("AddTag", mock.Anything, mock.Anything).Return(models.Tag{ID: 1, UserID: 1, Name: "work"}, nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:     "Tag already exists",
+			inputTag: models.Tag{UserID: 1, Name: "work"},
+			mockSetup: func(tagRepoMock *mocks.TagRepositoryInterface) {
+				tagRepoMock.On("AddTag", mock.Anything, mock.Anything).Return(models.Tag{}, repository.ErrDuplicate)
+			},
+			expectedCode:  http.StatusConflict,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TagRepoMock := mocks.NewTagRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(nil, nil, TagRepoMock, nil, nil, nil, nil, logger)
+
+			tc.mockSetup(TagRepoMock)
+
+			_, err := s.AddTag(context.TODO(), tc.inputTag)
+
+			if (err != nil) != tc.expectedError {
+				t.Errorf("Expected error: %v, got: %v", tc.expectedError, err)
+			}
+			if err != nil && err.(ServerError).Code != tc.expectedCode {
+				t.Errorf("Expected code: %d, got: %d", tc.expectedCode, err.(ServerError).Code)
+			}
+		})
+	}
+}
+
+func TestDeleteTag(t *testing.T) {
+	testCases := []struct {
+		name          string
+		tagID         int
+		userID        int
+		mockSetup     func(tagRepoMock *mocks.TagRepositoryInterface)
+		expectedCode  int
+		expectedError bool
+	}{
+		{
+			name:   "Delete tag successfully",
+			tagID:  1,
+			userID: 1,
+			mockSetup: func(tagRepoMock *mocks.TagRepositoryInterface) {
+				tagRepoMock.On("DeleteTag", mock.Anything, 1, 1).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:   "Tag not found",
+			tagID:  2,
+			userID: 1,
+			mockSetup: func(tagRepoMock *mocks.TagRepositoryInterface) {
+				tagRepoMock.On("DeleteTag", mock.Anything, 2, 1).Return(sql.ErrNoRows)
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TagRepoMock := mocks.NewTagRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(nil, nil, TagRepoMock, nil, nil, nil, nil, logger)
+
+			tc.mockSetup(TagRepoMock)
+
+			err := s.DeleteTag(context.TODO(), tc.tagID, tc.userID)
+
+			if (err != nil) != tc.expectedError {
+				t.Errorf("Expected error: %v, got: %v", tc.expectedError, err)
+			}
+			if err != nil && err.(ServerError).Code != tc.expectedCode {
+				t.Errorf("Expected code: %d, got: %d", tc.expectedCode, err.(ServerError).Code)
+			}
+		})
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	listID := 3
+
+	testCases := []struct {
+		name          string
+		listID        *int
+		mockSetup     func(taskRepoMock *mocks.TaskRepositoryInterface, listRepoMock *mocks.ListRepositoryInterface)
+		expectedCode  int
+		expectedError bool
+	}{
+		{
+			name:   "Move to own list",
+			listID: &listID,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface, listRepoMock *mocks.ListRepositoryInterface) {
+				listRepoMock.On("GetListByID", mock.Anything, 3, 1).Return(models.List{ID: 3, UserID: 1}, nil)
+				taskRepoMock.On("MoveTask", mock.Anything, 5, 1, &listID).Return(models.Task{ID: 5, ListID: &listID}, nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:   "Move to inbox",
+			listID: nil,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface, listRepoMock *mocks.ListRepositoryInterface) {
+				taskRepoMock.On("MoveTask", mock.Anything, 5, 1, (*int)(nil)).Return(models.Task{ID: 5}, nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:   "List of another user",
+			listID: &listID,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface, listRepoMock *mocks.ListRepositoryInterface) {
+				listRepoMock.On("GetListByID", mock.Anything, 3, 1).Return(models.List{}, sql.ErrNoRows)
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: true,
+		},
+		{
+			name:   "Task not found",
+			listID: nil,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface, listRepoMock *mocks.ListRepositoryInterface) {
+				taskRepoMock.On("MoveTask", mock.Anything, 5, 1, (*int)(nil)).Return(models.Task{}, sql.ErrNoRows)
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			ListRepoMock := mocks.NewListRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(nil, TaskRepoMock, nil, ListRepoMock, nil, nil, nil, logger)
+
+			tc.mockSetup(TaskRepoMock, ListRepoMock)
+
+			_, err := s.MoveTask(context.TODO(), 5, 1, tc.listID)
 
 			if (err != nil) != tc.expectedError {
 				t.Errorf("Expected error: %v, got: %v", tc.expectedError, err)
 			}
+			if err != nil && err.(ServerError).Code != tc.expectedCode {
+				t.Errorf("Expected code: %d, got: %d", tc.expectedCode, err.(ServerError).Code)
+			}
 		})
 	}
 }
+
+func TestAddTaskToForeignList(t *testing.T) {
+	TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+	ListRepoMock := mocks.NewListRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	s := NewService(nil, TaskRepoMock, nil, ListRepoMock, nil, nil, nil, logger)
+
+	listID := 7
+	ListRepoMock.On("GetListByID", mock.Anything, 7, 1).Return(models.List{}, sql.ErrNoRows)
+
+	_, err := s.AddTask(context.TODO(), models.Task{Title: "Test Task", UserID: 1, ListID: &listID})
+	if err == nil || err.(ServerError).Code != http.StatusNotFound {
+		t.Errorf("Expected not found error, got: %v", err)
+	}
+}
+
+func TestUpdateTaskParent(t *testing.T) {
+	testCases := []struct {
+		name          string
+		taskID        int
+		parentID      int
+		mockSetup     func(taskRepoMock *mocks.TaskRepositoryInterface)
+		expectedCode  int
+		expectedError bool
+	}{
+		{
+			name:     "Valid parent",
+			taskID:   5,
+			parentID: 2,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 2).Return(models.Task{ID: 2, UserID: 1}, nil)
+				taskRepoMock.On("GetAncestorIDs", mock.Anything, 2).Return([]int{1}, nil)
+				taskRepoMock.On("GetSubtreeDepth", mock.Anything, 5).Return(1, nil)
+				taskRepoMock.On("UpdateTask", mock.Anything, mock.Anything, 5, 1, int64(0)).Return(models.Task{ID: 5}, nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:     "Own parent",
+			taskID:   5,
+			parentID: 5,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+			},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: true,
+		},
+		{
+			name:     "Cycle",
+			taskID:   5,
+			parentID: 8,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 8).Return(models.Task{ID: 8, UserID: 1}, nil)
+				taskRepoMock.On("GetAncestorIDs", mock.Anything, 8).Return([]int{6, 5, 1}, nil)
+			},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: true,
+		},
+		{
+			name:     "Too deep",
+			taskID:   5,
+			parentID: 4,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 4).Return(models.Task{ID: 4, UserID: 1}, nil)
+				taskRepoMock.On("GetAncestorIDs", mock.Anything, 4).Return([]int{3, 2}, nil)
+				taskRepoMock.On("GetSubtreeDepth", mock.Anything, 5).Return(2, nil)
+			},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: true,
+		},
+		{
+			name:     "Parent of another user",
+			taskID:   5,
+			parentID: 9,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 9).Return(models.Task{ID: 9, UserID: 2}, nil)
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+			TaskRepoMock.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			}).Maybe()
+			tc.mockSetup(TaskRepoMock)
+
+			_, err := s.UpdateTask(context.TODO(), models.Task{ParentID: &tc.parentID}, tc.taskID, 1, 0)
+
+			if (err != nil) != tc.expectedError {
+				t.Errorf("Expected error: %v, got: %v", tc.expectedError, err)
+			}
+			if err != nil && err.(ServerError).Code != tc.expectedCode {
+				t.Errorf("Expected code: %d, got: %d", tc.expectedCode, err.(ServerError).Code)
+			}
+		})
+	}
+}
+
+func TestGetTasksTree(t *testing.T) {
+	TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	s := NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+	one, two, three := 1, 2, 3
+	TaskRepoMock.On("GetTasks", mock.Anything, 1, mock.MatchedBy(func(filter models.TaskFilter) bool {
+		return filter.TopLevel
+	}), 1, 10).Return([]models.Task{{ID: 1}, {ID: 4}}, 2, nil)
+	TaskRepoMock.On("GetTaskDescendants", mock.Anything, 1, []int{1, 4}, "").Return([]models.Task{
+		{ID: 2, ParentID: &one},
+		{ID: 3, ParentID: &two},
+		{ID: 5, ParentID: &one},
+		{ID: 6, ParentID: &three},
+	}, nil)
+
+	tasks, _, err := s.GetTasks(context.TODO(), 1, models.TaskFilter{Tree: true}, 1, 10)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(tasks[0].Subtasks) != 2 || tasks[0].Subtasks[0].ID != 2 || tasks[0].Subtasks[1].ID != 5 {
+		t.Errorf("Unexpected subtasks of task 1: %v", tasks[0].Subtasks)
+	}
+	if len(tasks[0].Subtasks[0].Subtasks) != 1 || tasks[0].Subtasks[0].Subtasks[0].Subtasks[0].ID != 6 {
+		t.Errorf("Unexpected subtasks of task 2: %v", tasks[0].Subtasks[0].Subtasks)
+	}
+	if len(tasks[1].Subtasks) != 0 {
+		t.Errorf("Expected no subtasks of task 4, got: %v", tasks[1].Subtasks)
+	}
+}
+
+func TestAddReminder(t *testing.T) {
+	dueAt := time.Date(2024, 10, 8, 16, 0, 0, 0, time.UTC)
+	offset := 60
+
+	testCases := []struct {
+		name          string
+		reminder      models.Reminder
+		mockSetup     func(taskRepoMock *mocks.TaskRepositoryInterface, reminderRepoMock *mocks.ReminderRepositoryInterface)
+		expectedCode  int
+		expectedError bool
+	}{
+		{
+			name:     "Offset from due date",
+			reminder: models.Reminder{TaskID: 1, UserID: 1, OffsetMinutes: &offset},
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface, reminderRepoMock *mocks.ReminderRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(models.Task{ID: 1, UserID: 1, DueAt: &dueAt}, nil)
+				reminderRepoMock.On("AddReminder", mock.Anything, mock.Anything).Return(models.Reminder{ID: 1, TaskID: 1, OffsetMinutes: &offset}, nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:     "Offset without due date",
+			reminder: models.Reminder{TaskID: 1, UserID: 1, OffsetMinutes: &offset},
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface, reminderRepoMock *mocks.ReminderRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(models.Task{ID: 1, UserID: 1}, nil)
+			},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: true,
+		},
+		{
+			name:     "Task of another user",
+			reminder: models.Reminder{TaskID: 1, UserID: 1, RemindAt: &dueAt},
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface, reminderRepoMock *mocks.ReminderRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(models.Task{ID: 1, UserID: 2}, nil)
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			ReminderRepoMock := mocks.NewReminderRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(nil, TaskRepoMock, nil, nil, ReminderRepoMock, nil, nil, logger)
+
+			tc.mockSetup(TaskRepoMock, ReminderRepoMock)
+
+			_, err := s.AddReminder(context.TODO(), tc.reminder)
+
+			if (err != nil) != tc.expectedError {
+				t.Errorf("Expected error: %v, got: %v", tc.expectedError, err)
+			}
+			if err != nil && err.(ServerError).Code != tc.expectedCode {
+				t.Errorf("Expected code: %d, got: %d", tc.expectedCode, err.(ServerError).Code)
+			}
+		})
+	}
+}
+
+func TestRestoreTask(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mockSetup     func(taskRepoMock *mocks.TaskRepositoryInterface)
+		expectedCode  int
+		expectedError bool
+	}{
+		{
+			name: "Restore task successfully",
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("RestoreTask", mock.Anything, 1, 1).Return(models.Task{ID: 1, UserID: 1}, nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "Task not in trash",
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("RestoreTask", mock.Anything, 1, 1).Return(models.Task{}, sql.ErrNoRows)
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: true,
+		},
+		{
+			name: "Repository error",
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("RestoreTask", mock.Anything, 1, 1).Return(models.Task{}, sql.ErrConnDone)
+			},
+			expectedCode:  http.StatusInternalServerError,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+			tc.mockSetup(TaskRepoMock)
+
+			_, err := s.RestoreTask(context.TODO(), 1, 1)
+
+			if (err != nil) != tc.expectedError {
+				t.Errorf("Expected error: %v, got: %v", tc.expectedError, err)
+			}
+			if err != nil && err.(ServerError).Code != tc.expectedCode {
+				t.Errorf("Expected code: %d, got: %d", tc.expectedCode, err.(ServerError).Code)
+			}
+		})
+	}
+}
+
+func TestArchiveTask(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mockSetup     func(taskRepoMock *mocks.TaskRepositoryInterface)
+		expectedCode  int
+		expectedError bool
+	}{
+		{
+			name: "Archive task successfully",
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				now := time.Now()
+				taskRepoMock.On("ArchiveTask", mock.Anything, 1, 1).Return(models.Task{ID: 1, UserID: 1, ArchivedAt: &now}, nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "Task not found",
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("ArchiveTask", mock.Anything, 1, 1).Return(models.Task{}, sql.ErrNoRows)
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: true,
+		},
+		{
+			name: "Repository error",
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("ArchiveTask", mock.Anything, 1, 1).Return(models.Task{}, sql.ErrConnDone)
+			},
+			expectedCode:  http.StatusInternalServerError,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+			tc.mockSetup(TaskRepoMock)
+
+			_, err := s.ArchiveTask(context.TODO(), 1, 1)
+
+			if (err != nil) != tc.expectedError {
+				t.Errorf("Expected error: %v, got: %v", tc.expectedError, err)
+			}
+			if err != nil && err.(ServerError).Code != tc.expectedCode {
+				t.Errorf("Expected code: %d, got: %d", tc.expectedCode, err.(ServerError).Code)
+			}
+		})
+	}
+}
+
+func TestArchiveCompletedInForeignList(t *testing.T) {
+	TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+	ListRepoMock := mocks.NewListRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	s := NewService(nil, TaskRepoMock, nil, ListRepoMock, nil, nil, nil, logger)
+
+	listID := 7
+	ListRepoMock.On("GetListByID", mock.Anything, 7, 1).Return(models.List{}, sql.ErrNoRows)
+
+	_, err := s.ArchiveCompleted(context.TODO(), 1, &listID, false)
+	if err == nil || err.(ServerError).Code != http.StatusNotFound {
+		t.Errorf("Expected not found error, got: %v", err)
+	}
+}
+
+func TestSync(t *testing.T) {
+	clientEdit := time.Date(2024, 10, 9, 12, 0, 0, 0, time.UTC)
+	serverEdit := clientEdit.Add(-time.Hour)
+	current := models.Task{ID: 1, UserID: 1, Title: "Server title", Description: "Server description", Status: models.TaskStatusTodo, Version: 5}
+
+	change := models.TaskChange{
+		ID:          1,
+		BaseVersion: 3,
+		ChangedAt:   clientEdit,
+		Fields:      map[string]json.RawMessage{"title": nil, "description": nil},
+		Task:        models.Task{Title: "Client title", Description: "Client description"},
+	}
+
+	testCases := []struct {
+		name           string
+		strategy       string
+		change         models.TaskChange
+		mockSetup      func(taskRepoMock *mocks.TaskRepositoryInterface)
+		expectedStatus string
+		conflicts      []models.FieldConflict
+	}{
+		{
+			name:     "Fields changed on the server are kept",
+			strategy: models.SyncStrategyReport,
+			change:   change,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(current, nil)
+				taskRepoMock.On("GetFieldChanges", mock.Anything, 1, int64(3)).Return(map[string]time.Time{"title": serverEdit, "priority": serverEdit}, nil)
+				taskRepoMock.On("UpdateTaskFields", mock.Anything, change.Task, []string{"description"}, 1, 1, int64(5)).Return(models.Task{ID: 1, Version: 6}, nil)
+			},
+			expectedStatus: models.ChangeConflict,
+			conflicts:      []models.FieldConflict{{Field: "title", ServerValue: "Server title", ClientValue: "Client title", Resolution: "server"}},
+		},
+		{
+			name:     "Later client change wins",
+			strategy: models.SyncStrategyLastWriterWins,
+			change:   change,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(current, nil)
+				taskRepoMock.On("GetFieldChanges", mock.Anything, 1, int64(3)).Return(map[string]time.Time{"title": serverEdit}, nil)
+				taskRepoMock.On("UpdateTaskFields", mock.Anything, change.Task, []string{"description", "title"}, 1, 1, int64(5)).Return(models.Task{ID: 1, Version: 6}, nil)
+			},
+			expectedStatus: models.ChangeApplied,
+			conflicts:      []models.FieldConflict{{Field: "title", ServerValue: "Server title", ClientValue: "Client title", Resolution: "client"}},
+		},
+		{
+			name:     "Concurrent update is merged again",
+			strategy: models.SyncStrategyReport,
+			change:   models.TaskChange{ID: 1, BaseVersion: 5, ChangedAt: clientEdit, Fields: change.Fields, Task: change.Task},
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(current, nil).Once()
+				taskRepoMock.On("UpdateTaskFields", mock.Anything, change.Task, []string{"description", "title"}, 1, 1, int64(5)).Return(models.Task{}, sql.ErrNoRows).Once()
+				newer := current
+				newer.Version = 6
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(newer, nil).Once()
+				taskRepoMock.On("GetFieldChanges", mock.Anything, 1, int64(5)).Return(map[string]time.Time{"priority": serverEdit}, nil)
+				taskRepoMock.On("UpdateTaskFields", mock.Anything, change.Task, []string{"description", "title"}, 1, 1, int64(6)).Return(models.Task{ID: 1, Version: 7}, nil).Once()
+			},
+			expectedStatus: models.ChangeApplied,
+		},
+		{
+			name:     "Task of another user",
+			strategy: models.SyncStrategyReport,
+			change:   change,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(models.Task{ID: 1, UserID: 2}, nil)
+			},
+			expectedStatus: models.ChangeNotFound,
+		},
+		{
+			name:     "Changes purged from the log are treated as conflicts",
+			strategy: models.SyncStrategyReport,
+			change:   change,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(current, nil)
+				taskRepoMock.On("GetFieldChanges", mock.Anything, 1, int64(3)).Return(nil, repository.ErrChangeLogPurged)
+			},
+			expectedStatus: models.ChangeConflict,
+			conflicts: []models.FieldConflict{
+				{Field: "description", ServerValue: "Server description", ClientValue: "Client description", Resolution: "server"},
+				{Field: "title", ServerValue: "Server title", ClientValue: "Client title", Resolution: "server"},
+			},
+		},
+		{
+			name:     "Deleting a task changed later on the server",
+			strategy: models.SyncStrategyLastWriterWins,
+			change:   models.TaskChange{ID: 1, BaseVersion: 3, Deleted: true, ChangedAt: serverEdit},
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(current, nil)
+				taskRepoMock.On("GetFieldChanges", mock.Anything, 1, int64(3)).Return(map[string]time.Time{"title": clientEdit}, nil)
+			},
+			expectedStatus: models.ChangeConflict,
+			conflicts:      []models.FieldConflict{{Field: "deleted", ServerValue: false, ClientValue: true, Resolution: "server"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+			TaskRepoMock.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
+			TaskRepoMock.On("GetChangeLogHorizon", mock.Anything).Return(uint64(4), time.Time{}, nil)
+			tc.mockSetup(TaskRepoMock)
+			TaskRepoMock.On("GetChangesSince", mock.Anything, 1, uint64(10)).Return([]models.Task{}, []int{}, uint64(12), nil)
+
+			result, next, err := s.Sync(context.TODO(), 1, models.SyncRequest{Strategy: tc.strategy, Changes: []models.TaskChange{tc.change}, Since: 10})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if next != 12 {
+				t.Errorf("Expected next position 12, got: %d", next)
+			}
+
+			res := result.Results[0]
+			if res.Status != tc.expectedStatus {
+				t.Errorf("Expected status %s, got: %s", tc.expectedStatus, res.Status)
+			}
+			if !reflect.DeepEqual(res.Conflicts, tc.conflicts) {
+				t.Errorf("Expected conflicts %v, got: %v", tc.conflicts, res.Conflicts)
+			}
+		})
+	}
+}
+
+func TestSyncExpiredToken(t *testing.T) {
+	TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	s := NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+	TaskRepoMock.On("GetChangeLogHorizon", mock.Anything).Return(uint64(10), time.Now(), nil)
+
+	_, _, err := s.Sync(context.TODO(), 1, models.SyncRequest{Strategy: models.SyncStrategyReport, Since: 10})
+	if err == nil || err.(ServerError).Code != http.StatusGone {
+		t.Errorf("Expected gone error, got: %v", err)
+	}
+}
+
+func TestSyncRollsBackOnServerError(t *testing.T) {
+	TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	s := NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+	// The first call runs the whole batch, the others run single changes
+	var batchErr error
+	calls := 0
+	TaskRepoMock.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		calls++
+		batch := calls == 1
+		err := fn(ctx)
+		if batch {
+			batchErr = err
+		}
+		return err
+	})
+	created := models.Task{UserID: 1, Title: "New task", Description: "Offline"}
+	TaskRepoMock.On("AddTask", mock.Anything, created).Return(models.Task{ID: 5, UserID: 1, Version: 1}, nil)
+	TaskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(models.Task{}, sql.ErrConnDone)
+
+	_, _, err := s.Sync(context.TODO(), 1, models.SyncRequest{Strategy: models.SyncStrategyReport, Changes: []models.TaskChange{
+		{ClientID: "a", Fields: map[string]json.RawMessage{"title": json.RawMessage(`"New task"`)}, Task: models.Task{Title: "New task", Description: "Offline"}},
+		{ID: 1, BaseVersion: 3, Fields: map[string]json.RawMessage{"title": json.RawMessage(`"Client title"`)}, Task: models.Task{Title: "Client title"}},
+	}})
+	if err == nil || err.(ServerError).Code != http.StatusInternalServerError {
+		t.Errorf("Expected internal server error, got: %v", err)
+	}
+	if batchErr == nil {
+		t.Error("Expected the batch transaction to be rolled back")
+	}
+	TaskRepoMock.AssertNotCalled(t, "GetChangesSince", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteTask(t *testing.T) {
+	testCases := []struct {
+		name         string
+		version      int64
+		repoErr      error
+		owner        int
+		expectedCode int
+	}{
+		{name: "Delete task successfully"},
+		{name: "Delete task at the expected version", version: 3},
+		{name: "Task not found", repoErr: sql.ErrNoRows, expectedCode: http.StatusNotFound},
+		{name: "Task of another user", repoErr: sql.ErrNoRows, owner: 2, expectedCode: http.StatusNotFound},
+		{name: "Task changed in the meantime", version: 3, repoErr: sql.ErrNoRows, owner: 1, expectedCode: http.StatusPreconditionFailed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+			TaskRepoMock.On("DeleteTask", mock.Anything, 1, 1, tc.version).Return(tc.repoErr)
+			if tc.repoErr == sql.ErrNoRows {
+				if tc.owner == 0 {
+					TaskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(models.Task{}, sql.ErrNoRows)
+				} else {
+					TaskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(models.Task{ID: 1, UserID: tc.owner}, nil)
+				}
+			}
+
+			err := s.DeleteTask(context.TODO(), 1, 1, tc.version)
+			if tc.expectedCode == 0 {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.(ServerError).Code != tc.expectedCode {
+				t.Errorf("Expected code %d, got: %v", tc.expectedCode, err)
+			}
+		})
+	}
+}
+
+func TestUpdateTaskNoMatch(t *testing.T) {
+	testCases := []struct {
+		name         string
+		version      int64
+		owner        int
+		expectedCode int
+	}{
+		{name: "Task changed in the meantime", version: 4, owner: 1, expectedCode: http.StatusPreconditionFailed},
+		{name: "Task of another user", owner: 2, expectedCode: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+			TaskRepoMock.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
+			TaskRepoMock.On("UpdateTask", mock.Anything, mock.Anything, 1, 1, tc.version).Return(models.Task{}, sql.ErrNoRows)
+			TaskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(models.Task{ID: 1, UserID: tc.owner}, nil)
+
+			_, err := s.UpdateTask(context.TODO(), models.Task{Title: "Updated Task"}, 1, 1, tc.version)
+			if err == nil || err.(ServerError).Code != tc.expectedCode {
+				t.Errorf("Expected code %d, got: %v", tc.expectedCode, err)
+			}
+		})
+	}
+}
+
+func TestPatchTask(t *testing.T) {
+	due := time.Date(2024, 10, 20, 18, 0, 0, 0, time.UTC)
+	current := models.Task{ID: 1, UserID: 1, Title: "Buy milk", Description: "2 litres", Status: models.TaskStatusTodo, DueAt: &due, Version: 5}
+
+	ops := func(s string) []utils.PatchOperation {
+		var ops []utils.PatchOperation
+		if err := json.Unmarshal([]byte(s), &ops); err != nil {
+			t.Fatal(err)
+		}
+		return ops
+	}
+
+	testCases := []struct {
+		name         string
+		patch        models.TaskPatch
+		version      int64
+		mockSetup    func(taskRepoMock *mocks.TaskRepositoryInterface)
+		expectedCode int
+	}{
+		{
+			name:  "Merge patch clears the description",
+			patch: models.TaskPatch{Merge: map[string]any{"description": nil}},
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(current, nil)
+				taskRepoMock.On("UpdateTaskFields", mock.Anything, mock.MatchedBy(func(task models.Task) bool {
+					return task.Description == "" && task.Title == current.Title && task.DueAt.Equal(due)
+				}), []string{"description"}, 1, 1, int64(5)).Return(models.Task{ID: 1, Version: 6}, nil)
+			},
+		},
+		{
+			name:    "JSON patch at the expected version",
+			patch:   models.TaskPatch{Ops: ops(`[{"op": "test", "path": "/title", "value": "Buy milk"}, {"op": "remove", "path": "/due_at"}, {"op": "add", "path": "/tags/-", "value": {"name": "home"}}]`)},
+			version: 5,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(current, nil)
+				taskRepoMock.On("UpdateTaskFields", mock.Anything, mock.MatchedBy(func(task models.Task) bool {
+					return task.DueAt == nil && len(task.Tags) == 1 && task.Tags[0].Name == "home"
+				}), []string{"due_at", "tags"}, 1, 1, int64(5)).Return(models.Task{ID: 1, Version: 6}, nil)
+			},
+		},
+		{
+			name:  "Patch without changes",
+			patch: models.TaskPatch{Merge: map[string]any{"title": "Buy milk"}},
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(current, nil)
+			},
+		},
+		{
+			name:  "Task changed while patching",
+			patch: models.TaskPatch{Merge: map[string]any{"priority": 2.0}},
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				changed := current
+				changed.Version = 6
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(current, nil).Once()
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(changed, nil).Once()
+				taskRepoMock.On("UpdateTaskFields", mock.Anything, mock.Anything, []string{"priority"}, 1, 1, int64(5)).Return(models.Task{}, sql.ErrNoRows).Once()
+				taskRepoMock.On("UpdateTaskFields", mock.Anything, mock.Anything, []string{"priority"}, 1, 1, int64(6)).Return(models.Task{ID: 1, Version: 7}, nil).Once()
+			},
+		},
+		{
+			name:    "Stale version",
+			patch:   models.TaskPatch{Merge: map[string]any{"priority": 2.0}},
+			version: 4,
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(current, nil)
+			},
+			expectedCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:  "Task of another user",
+			patch: models.TaskPatch{Merge: map[string]any{"priority": 2.0}},
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(models.Task{ID: 1, UserID: 2}, nil)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:  "Failed test operation",
+			patch: models.TaskPatch{Ops: ops(`[{"op": "test", "path": "/title", "value": "Buy bread"}, {"op": "replace", "path": "/title", "value": "Buy milk and bread"}]`)},
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(current, nil)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:  "Clearing the title",
+			patch: models.TaskPatch{Merge: map[string]any{"title": nil}},
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(current, nil)
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:  "Start after the existing due date",
+			patch: models.TaskPatch{Merge: map[string]any{"start_at": "2024-10-21T09:00:00Z"}},
+			mockSetup: func(taskRepoMock *mocks.TaskRepositoryInterface) {
+				taskRepoMock.On("GetTaskByID", mock.Anything, 1).Return(current, nil)
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+			TaskRepoMock.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			}).Maybe()
+			tc.mockSetup(TaskRepoMock)
+
+			_, err := s.PatchTask(context.TODO(), tc.patch, 1, 1, tc.version)
+			if tc.expectedCode == 0 {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.(ServerError).Code != tc.expectedCode {
+				t.Errorf("Expected code %d, got: %v", tc.expectedCode, err)
+			}
+		})
+	}
+}
+
+func TestBulkTasks(t *testing.T) {
+	operations := []models.BulkOperation{
+		{Op: models.BulkCreate, Task: models.Task{Title: "Buy milk", Description: "2 litres"}},
+		{Op: models.BulkUpdate, ID: 2, Task: models.Task{Priority: 2}},
+		{Op: models.BulkDelete, ID: 3},
+	}
+
+	testCases := []struct {
+		name              string
+		atomic            bool
+		deleteErr         error
+		expectedCommitted bool
+		expectedStatuses  []string
+		expectedError     bool
+	}{
+		{
+			name:              "Failed operation is reported",
+			expectedCommitted: true,
+			expectedStatuses:  []string{models.BulkOK, models.BulkFailed, models.BulkOK},
+		},
+		{
+			name:             "Failed operation rolls back an atomic request",
+			atomic:           true,
+			expectedStatuses: []string{models.BulkRolledBack, models.BulkFailed, models.BulkSkipped},
+		},
+		{
+			name:          "Database failure fails the request",
+			deleteErr:     sql.ErrConnDone,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+			TaskRepoMock.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+				return fn(ctx)
+			})
+			TaskRepoMock.On("AddTask", mock.Anything, models.Task{UserID: 1, Title: "Buy milk", Description: "2 litres"}).Return(models.Task{ID: 10, UserID: 1, Title: "Buy milk", Description: "2 litres"}, nil)
+			TaskRepoMock.On("UpdateTask", mock.Anything, models.Task{Priority: 2}, 2, 1, int64(0)).Return(models.Task{}, sql.ErrNoRows)
+			TaskRepoMock.On("GetTaskByID", mock.Anything, 2).Return(models.Task{}, sql.ErrNoRows)
+			if !tc.atomic {
+				TaskRepoMock.On("DeleteTask", mock.Anything, 3, 1, int64(0)).Return(tc.deleteErr)
+			}
+
+			result, err := s.BulkTasks(context.TODO(), 1, models.BulkRequest{Atomic: tc.atomic, Operations: operations})
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("Expected error: %v, got: %v", tc.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if result.Committed != tc.expectedCommitted {
+				t.Errorf("Expected committed %v, got: %v", tc.expectedCommitted, result.Committed)
+			}
+			for i, status := range tc.expectedStatuses {
+				if result.Results[i].Status != status {
+					t.Errorf("Expected status %s of operation %d, got: %+v", status, i, result.Results[i])
+				}
+			}
+			if res := result.Results[1]; res.Code != http.StatusNotFound || res.ID != 2 {
+				t.Errorf("Expected the update to fail with 404, got: %+v", res)
+			}
+			if res := result.Results[0]; tc.expectedCommitted && (res.ID != 10 || res.Task == nil) {
+				t.Errorf("Expected the created task, got: %+v", res)
+			}
+			if res := result.Results[0]; !tc.expectedCommitted && (res.ID != 0 || res.Task != nil) {
+				t.Errorf("Expected no task for a rolled back creation, got: %+v", res)
+			}
+		})
+	}
+}
+
+func TestBulkTasksReportsRejectedCreation(t *testing.T) {
+	TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	s := NewService(nil, TaskRepoMock, nil, nil, nil, nil, nil, logger)
+
+	TaskRepoMock.On("RunInTx", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	TaskRepoMock.On("AddTask", mock.Anything, mock.Anything).Return(models.Task{}, repository.ErrInvalidRecord)
+
+	result, err := s.BulkTasks(context.TODO(), 1, models.BulkRequest{Operations: []models.BulkOperation{
+		{Op: models.BulkCreate, Task: models.Task{Title: "Buy milk"}},
+	}})
+	if err != nil {
+		t.Fatalf("Expected the failure to be reported per operation, got: %v", err)
+	}
+	if res := result.Results[0]; res.Status != models.BulkFailed || res.Code != http.StatusBadRequest {
+		t.Errorf("Expected the creation to fail with 400, got: %+v", res)
+	}
+}
+
+func TestRefreshTokens(t *testing.T) {
+	now := time.Now()
+	valid := models.RefreshToken{ID: 7, UserID: 1, FamilyID: "f", TokenHash: utils.HashToken("refresh"), ExpiresAt: now.Add(time.Hour)}
+
+	testCases := []struct {
+		name         string
+		mockSetup    func(tokenRepoMock *mocks.TokenRepositoryInterface)
+		expectedCode int
+	}{
+		{
+			name: "Token is rotated",
+			mockSetup: func(tokenRepoMock *mocks.TokenRepositoryInterface) {
+				tokenRepoMock.On("GetRefreshToken", mock.Anything, valid.TokenHash).Return(valid, nil)
+				tokenRepoMock.On("UseRefreshToken", mock.Anything, int64(7)).Return(true, nil)
+				tokenRepoMock.On("AddRefreshToken", mock.Anything, mock.MatchedBy(func(token models.RefreshToken) bool {
+					return token.UserID == 1 && token.FamilyID == "f" && token.TokenHash != valid.TokenHash && token.ExpiresAt.After(now.Add(24*time.Hour))
+				})).Return(nil)
+			},
+		},
+		{
+			name: "Unknown token",
+			mockSetup: func(tokenRepoMock *mocks.TokenRepositoryInterface) {
+				tokenRepoMock.On("GetRefreshToken", mock.Anything, valid.TokenHash).Return(models.RefreshToken{}, sql.ErrNoRows)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Expired token",
+			mockSetup: func(tokenRepoMock *mocks.TokenRepositoryInterface) {
+				expired := valid
+				expired.ExpiresAt = now.Add(-time.Minute)
+				tokenRepoMock.On("GetRefreshToken", mock.Anything, valid.TokenHash).Return(expired, nil)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Revoked token",
+			mockSetup: func(tokenRepoMock *mocks.TokenRepositoryInterface) {
+				revoked := valid
+				revoked.RevokedAt = &now
+				tokenRepoMock.On("GetRefreshToken", mock.Anything, valid.TokenHash).Return(revoked, nil)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Reused token revokes its family",
+			mockSetup: func(tokenRepoMock *mocks.TokenRepositoryInterface) {
+				used := valid
+				used.UsedAt = &now
+				tokenRepoMock.On("GetRefreshToken", mock.Anything, valid.TokenHash).Return(used, nil)
+				tokenRepoMock.On("RevokeTokenFamily", mock.Anything, "f").Return(nil)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "Concurrent refresh with the same token",
+			mockSetup: func(tokenRepoMock *mocks.TokenRepositoryInterface) {
+				tokenRepoMock.On("GetRefreshToken", mock.Anything, valid.TokenHash).Return(valid, nil)
+				tokenRepoMock.On("UseRefreshToken", mock.Anything, int64(7)).Return(false, nil)
+				tokenRepoMock.On("RevokeTokenFamily", mock.Anything, "f").Return(nil)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			UserRepoMock := mocks.NewUserRepositoryInterface(t)
+			TokenRepoMock := mocks.NewTokenRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(UserRepoMock, nil, nil, nil, nil, TokenRepoMock, nil, logger)
+			tc.mockSetup(TokenRepoMock)
+			UserRepoMock.On("GetTokenVersion", mock.Anything, 1).Return(0, nil).Maybe()
+
+			access, refresh, err := s.RefreshTokens(context.TODO(), "refresh")
+			if tc.expectedCode == 0 {
+				if err != nil || access == "" || refresh == "" || refresh == "refresh" {
+					t.Errorf("Expected new tokens, got: %q, %q, %v", access, refresh, err)
+				}
+				return
+			}
+			if err == nil || err.(ServerError).Code != tc.expectedCode {
+				t.Errorf("Expected code %d, got: %v", tc.expectedCode, err)
+			}
+		})
+	}
+}
+
+func TestCheckAccessToken(t *testing.T) {
+	token := models.AccessToken{UserID: 1, JTI: "j", SessionID: "f", Version: 2, ExpiresAt: time.Now().Add(time.Hour)}
+
+	testCases := []struct {
+		name          string
+		token         models.AccessToken
+		mockSetup     func(userRepoMock *mocks.UserRepositoryInterface, tokenRepoMock *mocks.TokenRepositoryInterface)
+		expectedError error
+	}{
+		{
+			name:  "Valid token",
+			token: token,
+			mockSetup: func(userRepoMock *mocks.UserRepositoryInterface, tokenRepoMock *mocks.TokenRepositoryInterface) {
+				userRepoMock.On("GetTokenVersion", mock.Anything, 1).Return(2, nil).Once()
+				tokenRepoMock.On("IsAccessTokenRevoked", mock.Anything, "j").Return(false, nil).Once()
+				tokenRepoMock.On("TouchSession", mock.Anything, "f").Return(true, nil).Once()
+			},
+		},
+		{
+			name:  "Session ended",
+			token: token,
+			mockSetup: func(userRepoMock *mocks.UserRepositoryInterface, tokenRepoMock *mocks.TokenRepositoryInterface) {
+				userRepoMock.On("GetTokenVersion", mock.Anything, 1).Return(2, nil).Once()
+				tokenRepoMock.On("IsAccessTokenRevoked", mock.Anything, "j").Return(false, nil).Once()
+				tokenRepoMock.On("TouchSession", mock.Anything, "f").Return(false, nil).Once()
+			},
+			expectedError: models.ErrTokenRevoked,
+		},
+		{
+			name:  "Token revoked by logout",
+			token: token,
+			mockSetup: func(userRepoMock *mocks.UserRepositoryInterface, tokenRepoMock *mocks.TokenRepositoryInterface) {
+				userRepoMock.On("GetTokenVersion", mock.Anything, 1).Return(2, nil).Once()
+				tokenRepoMock.On("IsAccessTokenRevoked", mock.Anything, "j").Return(true, nil).Once()
+			},
+			expectedError: models.ErrTokenRevoked,
+		},
+		{
+			name:  "Token issued before logout from all sessions",
+			token: token,
+			mockSetup: func(userRepoMock *mocks.UserRepositoryInterface, tokenRepoMock *mocks.TokenRepositoryInterface) {
+				userRepoMock.On("GetTokenVersion", mock.Anything, 1).Return(3, nil).Once()
+			},
+			expectedError: models.ErrTokenRevoked,
+		},
+		{
+			name:  "Deleted user",
+			token: token,
+			mockSetup: func(userRepoMock *mocks.UserRepositoryInterface, tokenRepoMock *mocks.TokenRepositoryInterface) {
+				userRepoMock.On("GetTokenVersion", mock.Anything, 1).Return(0, sql.ErrNoRows)
+			},
+			expectedError: models.ErrTokenRevoked,
+		},
+		{
+			name:  "Token without jti",
+			token: models.AccessToken{UserID: 1, ExpiresAt: token.ExpiresAt},
+			mockSetup: func(userRepoMock *mocks.UserRepositoryInterface, tokenRepoMock *mocks.TokenRepositoryInterface) {
+				userRepoMock.On("GetTokenVersion", mock.Anything, 1).Return(0, nil).Once()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			UserRepoMock := mocks.NewUserRepositoryInterface(t)
+			TokenRepoMock := mocks.NewTokenRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(UserRepoMock, nil, nil, nil, nil, TokenRepoMock, nil, logger)
+			tc.mockSetup(UserRepoMock, TokenRepoMock)
+
+			// The second check is answered from the cache
+			for i := 0; i < 2; i++ {
+				if err := s.CheckAccessToken(context.TODO(), tc.token); err != tc.expectedError {
+					t.Errorf("Expected error %v, got: %v", tc.expectedError, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	UserRepoMock := mocks.NewUserRepositoryInterface(t)
+	TokenRepoMock := mocks.NewTokenRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	s := NewService(UserRepoMock, nil, nil, nil, nil, TokenRepoMock, nil, logger)
+
+	token := models.AccessToken{UserID: 1, JTI: "j", SessionID: "f", ExpiresAt: time.Now().Add(time.Hour)}
+	UserRepoMock.On("GetTokenVersion", mock.Anything, 1).Return(0, nil).Once()
+	TokenRepoMock.On("RevokeTokenFamily", mock.Anything, "f").Return(nil).Once()
+	TokenRepoMock.On("RevokeAccessToken", mock.Anything, models.RevokedToken{JTI: "j", UserID: 1, ExpiresAt: token.ExpiresAt}).Return(nil).Once()
+
+	if err := s.Logout(context.TODO(), token); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	// The revocation is known without asking the repository
+	if err := s.CheckAccessToken(context.TODO(), token); err != models.ErrTokenRevoked {
+		t.Errorf("Expected revoked token, got: %v", err)
+	}
+}
+
+func TestLogoutAll(t *testing.T) {
+	UserRepoMock := mocks.NewUserRepositoryInterface(t)
+	TokenRepoMock := mocks.NewTokenRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	s := NewService(UserRepoMock, nil, nil, nil, nil, TokenRepoMock, nil, logger)
+
+	TokenRepoMock.On("RevokeUserTokens", mock.Anything, 1).Return(nil).Once()
+	UserRepoMock.On("IncrementTokenVersion", mock.Anything, 1).Return(1, nil).Once()
+
+	if err := s.LogoutAll(context.TODO(), 1); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	token := models.AccessToken{UserID: 1, JTI: "j", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := s.CheckAccessToken(context.TODO(), token); err != models.ErrTokenRevoked {
+		t.Errorf("Expected revoked token, got: %v", err)
+	}
+}
+
+func TestGetSessions(t *testing.T) {
+	TokenRepoMock := mocks.NewTokenRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	s := NewService(nil, nil, nil, nil, nil, TokenRepoMock, nil, logger)
+
+	TokenRepoMock.On("GetSessions", mock.Anything, 1).Return([]models.Session{{ID: "a"}, {ID: "b"}}, nil)
+
+	sessions, err := s.GetSessions(context.TODO(), models.AccessToken{UserID: 1, SessionID: "b"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if sessions[0].Current || !sessions[1].Current {
+		t.Errorf("Expected session b to be current, got: %+v", sessions)
+	}
+}
+
+func TestEndSession(t *testing.T) {
+	testCases := []struct {
+		name         string
+		repoErr      error
+		expectedCode int
+	}{
+		{
+			name: "Session ended",
+		},
+		{
+			name:         "No such session",
+			repoErr:      sql.ErrNoRows,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			UserRepoMock := mocks.NewUserRepositoryInterface(t)
+			TokenRepoMock := mocks.NewTokenRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(UserRepoMock, nil, nil, nil, nil, TokenRepoMock, nil, logger)
+
+			TokenRepoMock.On("DeleteSession", mock.Anything, 1, "f").Return(tc.repoErr)
+
+			err := s.EndSession(context.TODO(), 1, "f")
+			if tc.expectedCode != 0 {
+				if err == nil || err.(ServerError).Code != tc.expectedCode {
+					t.Errorf("Expected code %d, got: %v", tc.expectedCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			// Access tokens of the ended session are rejected right away
+			UserRepoMock.On("GetTokenVersion", mock.Anything, 1).Return(0, nil)
+			token := models.AccessToken{UserID: 1, SessionID: "f", ExpiresAt: time.Now().Add(time.Hour)}
+			if err := s.CheckAccessToken(context.TODO(), token); err != models.ErrTokenRevoked {
+				t.Errorf("Expected revoked token, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	APIKeyRepoMock := mocks.NewAPIKeyRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	s := NewService(nil, nil, nil, nil, nil, nil, APIKeyRepoMock, logger)
+
+	var stored models.APIKey
+	APIKeyRepoMock.On("AddAPIKey", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(models.APIKey)
+	}).Return(func(ctx context.Context, key models.APIKey) models.APIKey {
+		key.ID = 5
+		return key
+	}, nil)
+
+	key, err := s.CreateAPIKey(context.TODO(), models.APIKey{UserID: 1, Name: "CI", Scopes: []string{models.ScopeTasksRead}})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.HasPrefix(key.Key, models.APIKeyPrefix) || !strings.HasPrefix(key.Key, key.Prefix) || len(key.Prefix) != len(models.APIKeyPrefix)+6 {
+		t.Errorf("Unexpected key %q with prefix %q", key.Key, key.Prefix)
+	}
+	if stored.Key != "" || stored.KeyHash != utils.HashToken(key.Key) {
+		t.Errorf("Expected only the hash of the key to be stored, got: %+v", stored)
+	}
+	if key.ID != 5 {
+		t.Errorf("Expected the stored key, got: %+v", key)
+	}
+}
+
+func TestCheckAPIKey(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	recent := time.Now().Add(-time.Second)
+	future := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name          string
+		key           models.APIKey
+		repoErr       error
+		expectTouch   bool
+		expectedError error
+	}{
+		{
+			name:        "Valid key",
+			key:         models.APIKey{ID: 5, UserID: 1, ExpiresAt: &future},
+			expectTouch: true,
+		},
+		{
+			name: "Recently used key",
+			key:  models.APIKey{ID: 5, UserID: 1, LastUsedAt: &recent},
+		},
+		{
+			name:          "Expired key",
+			key:           models.APIKey{ID: 5, UserID: 1, ExpiresAt: &past},
+			expectedError: models.ErrInvalidAPIKey,
+		},
+		{
+			name:          "Unknown key",
+			repoErr:       sql.ErrNoRows,
+			expectedError: models.ErrInvalidAPIKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			APIKeyRepoMock := mocks.NewAPIKeyRepositoryInterface(t)
+			logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+			s := NewService(nil, nil, nil, nil, nil, nil, APIKeyRepoMock, logger)
+
+			APIKeyRepoMock.On("GetAPIKeyByHash", mock.Anything, utils.HashToken("tdl_secret")).Return(tc.key, tc.repoErr)
+			if tc.expectTouch {
+				APIKeyRepoMock.On("TouchAPIKey", mock.Anything, 5).Return(nil)
+			}
+
+			key, err := s.CheckAPIKey(context.TODO(), "tdl_secret")
+			if err != tc.expectedError {
+				t.Errorf("Expected error %v, got: %v", tc.expectedError, err)
+			}
+			if err == nil && key.UserID != 1 {
+				t.Errorf("Expected the key of user 1, got: %+v", key)
+			}
+		})
+	}
+}
+
+func TestDeleteAPIKey(t *testing.T) {
+	APIKeyRepoMock := mocks.NewAPIKeyRepositoryInterface(t)
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+
+	s := NewService(nil, nil, nil, nil, nil, nil, APIKeyRepoMock, logger)
+
+	APIKeyRepoMock.On("DeleteAPIKey", mock.Anything, 1, 5).Return(nil)
+	APIKeyRepoMock.On("DeleteAPIKey", mock.Anything, 1, 6).Return(sql.ErrNoRows)
+
+	if err := s.DeleteAPIKey(context.TODO(), 1, 5); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if err := s.DeleteAPIKey(context.TODO(), 1, 6); err == nil || err.(ServerError).Code != http.StatusNotFound {
+		t.Errorf("Expected not found, got: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+var (
+	cursorSecretMu sync.RWMutex
+	cursorSecret   []byte
+)
+
+// SetCursorSecret sets the key cursors are signed with. Until it is set,
+// cursors can be neither signed nor parsed.
+func SetCursorSecret(secret []byte) {
+	cursorSecretMu.Lock()
+	defer cursorSecretMu.Unlock()
+	cursorSecret = secret
+}
+
+func getCursorSecret() []byte {
+	cursorSecretMu.RLock()
+	defer cursorSecretMu.RUnlock()
+	return cursorSecret
+}
+
+// SignCursor encodes v as an opaque pagination cursor. The cursor is signed
+// with the secret set by SetCursorSecret so clients cannot forge positions.
+func SignCursor(v any) (string, error) {
+	secret := getCursorSecret()
+	if len(secret) == 0 {
+		return "", errors.New("no cursor secret set")
+	}
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(cursorSignature(secret, encoded)), nil
+}
+
+// ParseCursor verifies a cursor made by SignCursor and decodes it into v.
+func ParseCursor(cursor string, v any) error {
+	secret := getCursorSecret()
+	encoded, sig, ok := strings.Cut(cursor, ".")
+	if !ok || len(secret) == 0 {
+		return ErrInvalidCursor
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, cursorSignature(secret, encoded)) {
+		return ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(payload, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+func cursorSignature(secret []byte, encoded string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("cursor:" + encoded))
+	return mac.Sum(nil)
+}
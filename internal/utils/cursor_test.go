@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+type testCursor struct {
+	Sort   string    `json:"s"`
+	Values []*string `json:"v"`
+}
+
+func TestCursor(t *testing.T) {
+	SetCursorSecret([]byte("test"))
+	t.Cleanup(func() { SetCursorSecret(nil) })
+
+	due := "2024-10-05T18:00:00Z"
+	id := "42"
+	cursor, err := SignCursor(testCursor{Sort: "-due_at", Values: []*string{&due, nil, &id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed testCursor
+	if err := ParseCursor(cursor, &parsed); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if parsed.Sort != "-due_at" || len(parsed.Values) != 3 || *parsed.Values[0] != due || parsed.Values[1] != nil || *parsed.Values[2] != id {
+		t.Errorf("Unexpected cursor: %+v", parsed)
+	}
+
+	encoded, sig, _ := strings.Cut(cursor, ".")
+	forged, _ := SignCursor(testCursor{Sort: "-due_at", Values: []*string{&due, nil, &due}})
+	forgedEncoded, _, _ := strings.Cut(forged, ".")
+
+	testCases := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "Empty", cursor: ""},
+		{name: "No signature", cursor: encoded},
+		{name: "Tampered payload", cursor: forgedEncoded + "." + sig},
+		{name: "Garbage", cursor: "not.a-cursor"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := ParseCursor(tc.cursor, &parsed); err != ErrInvalidCursor {
+				t.Errorf("Expected ErrInvalidCursor, got: %v", err)
+			}
+		})
+	}
+
+	SetCursorSecret([]byte("other"))
+	if err := ParseCursor(cursor, &parsed); err != ErrInvalidCursor {
+		t.Errorf("Expected ErrInvalidCursor for another key, got: %v", err)
+	}
+
+	SetCursorSecret(nil)
+	if _, err := SignCursor(testCursor{Sort: "-due_at"}); err == nil {
+		t.Error("Expected signing without a secret to fail")
+	}
+	if err := ParseCursor(cursor, &parsed); err != ErrInvalidCursor {
+		t.Errorf("Expected ErrInvalidCursor without a secret, got: %v", err)
+	}
+}
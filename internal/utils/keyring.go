@@ -0,0 +1,302 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey is a key of a KeyRing. It signs tokens from NotBefore on, until
+// a key with a later NotBefore takes over, and verifies them until NotAfter.
+// A zero NotAfter never retires the key.
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// keyFileEntry describes a key in the key ring file. A relative File is
+// resolved against the directory of the key ring file.
+type keyFileEntry struct {
+	ID        string    `json:"kid"`
+	File      string    `json:"file"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// KeyRing signs and verifies JWTs with RS256 or EdDSA keys identified by the
+// kid header. Keys are rotated on schedule: giving the next key a NotBefore
+// before the previous one's NotAfter lets both verify tokens in the overlap.
+// A key ring not loaded from a file signs with HS256 and the secret.
+type KeyRing struct {
+	path   string
+	secret []byte
+
+	mu   sync.RWMutex
+	keys []SigningKey
+}
+
+var (
+	defaultKeyRingMu sync.RWMutex
+	defaultKeyRing   *KeyRing
+)
+
+// SetDefaultKeyRing makes GenerateJWT and ValidateJWT use kr.
+func SetDefaultKeyRing(kr *KeyRing) {
+	defaultKeyRingMu.Lock()
+	defer defaultKeyRingMu.Unlock()
+	defaultKeyRing = kr
+}
+
+// getDefaultKeyRing returns the key ring set by SetDefaultKeyRing, or one
+// that signs with SECRET_KEY.
+func getDefaultKeyRing() *KeyRing {
+	defaultKeyRingMu.RLock()
+	defer defaultKeyRingMu.RUnlock()
+
+	if defaultKeyRing == nil {
+		return NewKeyRing([]byte(os.Getenv("SECRET_KEY")))
+	}
+	return defaultKeyRing
+}
+
+// NewKeyRing returns a key ring without keys, which signs and verifies HS256
+// tokens with secret.
+func NewKeyRing(secret []byte) *KeyRing {
+	return &KeyRing{secret: secret}
+}
+
+// LoadKeyRing reads the keys listed in the JSON key ring file at path. The
+// secret verifies HS256 tokens issued before the keys were introduced, unless
+// it is empty.
+func LoadKeyRing(path string, secret []byte) (*KeyRing, error) {
+	kr := &KeyRing{path: path, secret: secret}
+	if err := kr.Reload(); err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// Reload reads the key ring file again, so that keys can be added and
+// retired without a restart. The keys are kept if the file is invalid.
+func (kr *KeyRing) Reload() error {
+	if kr.path == "" {
+		return nil
+	}
+
+	raw, err := os.ReadFile(kr.path)
+	if err != nil {
+		return err
+	}
+
+	var file struct {
+		Keys []keyFileEntry `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return fmt.Errorf("invalid key ring file: %w", err)
+	}
+
+	keys := make([]SigningKey, 0, len(file.Keys))
+	seen := make(map[string]bool)
+	for _, entry := range file.Keys {
+		if entry.ID == "" || seen[entry.ID] {
+			return fmt.Errorf("key ring file: missing or duplicate kid %q", entry.ID)
+		}
+		seen[entry.ID] = true
+
+		keyPath := entry.File
+		if !filepath.IsAbs(keyPath) {
+			keyPath = filepath.Join(filepath.Dir(kr.path), keyPath)
+		}
+		key, err := LoadSigningKey(keyPath)
+		if err != nil {
+			return fmt.Errorf("key %s: %w", entry.ID, err)
+		}
+
+		key.ID, key.NotBefore, key.NotAfter = entry.ID, entry.NotBefore, entry.NotAfter
+		keys = append(keys, key)
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	kr.keys = keys
+	return nil
+}
+
+// LoadSigningKey reads a PEM encoded PKCS #8 or PKCS #1 private key. RSA
+// keys sign with RS256 and Ed25519 keys with EdDSA.
+func LoadSigningKey(path string) (SigningKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return SigningKey{}, errors.New("no PEM encoded key found")
+	}
+
+	var private any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		return SigningKey{Method: jwt.SigningMethodRS256, Private: private}, nil
+	case ed25519.PrivateKey:
+		return SigningKey{Method: jwt.SigningMethodEdDSA, Private: private}, nil
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported key type %T", private)
+	}
+}
+
+// signingKey returns the key that signs tokens at now.
+func (kr *KeyRing) signingKey(now time.Time) (SigningKey, bool) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	var current SigningKey
+	found := false
+	for _, key := range kr.keys {
+		if now.Before(key.NotBefore) || key.retired(now) {
+			continue
+		}
+		if !found || key.NotBefore.After(current.NotBefore) {
+			current, found = key, true
+		}
+	}
+	return current, found
+}
+
+// verificationKey returns the unretired key with the given ID.
+func (kr *KeyRing) verificationKey(kid string, now time.Time) (SigningKey, bool) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	for _, key := range kr.keys {
+		if key.ID == kid && !key.retired(now) {
+			return key, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+func (k SigningKey) retired(now time.Time) bool {
+	return !k.NotAfter.IsZero() && !now.Before(k.NotAfter)
+}
+
+// Sign returns a JWT with claims signed by the current key, or with HS256 and
+// the secret if the key ring was not loaded from a file.
+func (kr *KeyRing) Sign(claims jwt.MapClaims) (string, error) {
+	if kr.path == "" {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(kr.secret)
+	}
+
+	key, ok := kr.signingKey(time.Now())
+	if !ok {
+		return "", errors.New("no signing key in effect")
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// Verify checks the signature of a JWT and returns its claims. A key ring
+// loaded from a file only accepts HS256 tokens if it has a secret.
+func (kr *KeyRing) Verify(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+			if kr.path != "" && len(kr.secret) == 0 {
+				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+			}
+			return kr.secret, nil
+		}
+
+		kid, _ := t.Header["kid"].(string)
+		key, ok := kr.verificationKey(kid, time.Now())
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return key.Private.Public(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
+	if err != nil {
+		return jwt.MapClaims{}, err
+	}
+
+	return token.Claims.(jwt.MapClaims), nil
+}
+
+// JWK is the public part of a signing key as a JSON Web Key (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty" enums:"RSA,OKP"`
+	Kid string `json:"kid"`
+	Use string `json:"use" example:"sig"`
+	Alg string `json:"alg" enums:"RS256,EdDSA"`
+	Crv string `json:"crv,omitempty" example:"Ed25519"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set of the keys access tokens are signed with.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys that verify tokens now or will sign them
+// later, so that they are known before they are used.
+func (kr *KeyRing) JWKS() JWKSet {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	now := time.Now()
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range kr.keys {
+		if key.retired(now) {
+			continue
+		}
+
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch public := key.Private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty, jwk.Crv = "OKP", "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
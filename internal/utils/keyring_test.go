@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writeTestKey(t *testing.T, dir, name string, key any) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTestKeyRing(t *testing.T, dir string, entries []keyFileEntry) string {
+	raw, err := json.Marshal(map[string]any{"keys": entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "keys.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestKeyRing(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestKey(t, dir, "old.pem", rsaKey)
+	writeTestKey(t, dir, "new.pem", edKey)
+
+	now := time.Now()
+	path := writeTestKeyRing(t, dir, []keyFileEntry{
+		{ID: "old", File: "old.pem", NotBefore: now.Add(-48 * time.Hour), NotAfter: now.Add(12 * time.Hour)},
+		{ID: "new", File: "new.pem", NotBefore: now.Add(-time.Hour)},
+	})
+
+	kr, err := LoadKeyRing(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.MapClaims{"uid": 1, "exp": now.Add(time.Hour).Unix()}
+	token, err := kr.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "new" || parsed.Method.Alg() != "EdDSA" {
+		t.Errorf("Expected a token signed by the newest key, got: %v", parsed.Header)
+	}
+	if verified, err := kr.Verify(token); err != nil || verified["uid"] != float64(1) {
+		t.Errorf("Expected the token to verify, got: %v, %v", verified, err)
+	}
+
+	// Tokens of the previous key verify until it is retired
+	oldToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	oldToken.Header["kid"] = "old"
+	oldString, err := oldToken.SignedString(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kr.Verify(oldString); err != nil {
+		t.Errorf("Expected the token of the previous key to verify, got: %v", err)
+	}
+
+	// A key cannot verify tokens with another algorithm
+	oldToken.Header["kid"] = "new"
+	wrongKid, err := oldToken.SignedString(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kr.Verify(wrongKid); err == nil {
+		t.Error("Expected a token with a mismatched kid to fail")
+	}
+
+	// HS256 tokens are rejected without a secret
+	hsToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(""))
+	if _, err := kr.Verify(hsToken); err == nil {
+		t.Error("Expected an HS256 token to fail")
+	}
+
+	jwks := kr.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("Expected 2 keys, got: %+v", jwks)
+	}
+	for _, key := range jwks.Keys {
+		switch key.Kid {
+		case "old":
+			if key.Kty != "RSA" || key.Alg != "RS256" || key.N == "" || key.E != "AQAB" {
+				t.Errorf("Unexpected RSA key: %+v", key)
+			}
+		case "new":
+			if key.Kty != "OKP" || key.Crv != "Ed25519" || key.Alg != "EdDSA" || key.X == "" {
+				t.Errorf("Unexpected Ed25519 key: %+v", key)
+			}
+		}
+	}
+
+	// Retiring the previous key on reload stops it from verifying
+	writeTestKeyRing(t, dir, []keyFileEntry{
+		{ID: "old", File: "old.pem", NotBefore: now.Add(-48 * time.Hour), NotAfter: now.Add(-time.Minute)},
+		{ID: "new", File: "new.pem", NotBefore: now.Add(-time.Hour)},
+	})
+	if err := kr.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kr.Verify(oldString); err == nil {
+		t.Error("Expected the token of a retired key to fail")
+	}
+	if jwks := kr.JWKS(); len(jwks.Keys) != 1 || jwks.Keys[0].Kid != "new" {
+		t.Errorf("Expected only the new key to be published, got: %+v", jwks)
+	}
+
+	// An invalid file keeps the keys loaded before
+	os.WriteFile(path, []byte("{"), 0o600)
+	if err := kr.Reload(); err == nil {
+		t.Error("Expected an invalid key ring file to fail")
+	}
+	if _, err := kr.Verify(token); err != nil {
+		t.Errorf("Expected the keys to be kept, got: %v", err)
+	}
+}
+
+func TestKeyRingSchedule(t *testing.T) {
+	dir := t.TempDir()
+
+	_, current, _ := ed25519.GenerateKey(rand.Reader)
+	_, next, _ := ed25519.GenerateKey(rand.Reader)
+	writeTestKey(t, dir, "current.pem", current)
+	writeTestKey(t, dir, "next.pem", next)
+
+	now := time.Now()
+	path := writeTestKeyRing(t, dir, []keyFileEntry{
+		{ID: "current", File: "current.pem", NotBefore: now.Add(-time.Hour)},
+		{ID: "next", File: filepath.Join(dir, "next.pem"), NotBefore: now.Add(time.Hour)},
+	})
+
+	kr, err := LoadKeyRing(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key, _ := kr.signingKey(now); key.ID != "current" {
+		t.Errorf("Expected the current key to sign, got: %s", key.ID)
+	}
+	if key, _ := kr.signingKey(now.Add(2 * time.Hour)); key.ID != "next" {
+		t.Errorf("Expected the next key to sign once in effect, got: %s", key.ID)
+	}
+	// The next key is published before it signs anything
+	if jwks := kr.JWKS(); len(jwks.Keys) != 2 {
+		t.Errorf("Expected both keys to be published, got: %+v", jwks)
+	}
+}
+
+func TestKeyRingSecret(t *testing.T) {
+	kr := NewKeyRing([]byte("test"))
+
+	token, err := kr.Sign(jwt.MapClaims{"uid": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kr.Verify(token); err != nil {
+		t.Errorf("Expected the token to verify, got: %v", err)
+	}
+	if _, err := NewKeyRing([]byte("other")).Verify(token); err == nil {
+		t.Error("Expected a token signed with another secret to fail")
+	}
+	if jwks := kr.JWKS(); len(jwks.Keys) != 0 {
+		t.Errorf("Expected no published keys, got: %+v", jwks)
+	}
+}
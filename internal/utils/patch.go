@@ -0,0 +1,300 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PatchOperation is a single operation of a JSON Patch (RFC 6902).
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+}
+
+// Operations of a JSON Patch
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchMove    = "move"
+	PatchCopy    = "copy"
+	PatchTest    = "test"
+)
+
+// Check validates the syntax of an operation, without a document to apply it to.
+func (op PatchOperation) Check() error {
+	switch op.Op {
+	case PatchAdd, PatchReplace, PatchTest:
+		if len(op.Value) == 0 {
+			return fmt.Errorf("%s operation requires a value", op.Op)
+		}
+	case PatchMove, PatchCopy:
+		if _, err := ParsePointer(op.From); err != nil {
+			return err
+		}
+	case PatchRemove:
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	_, err := ParsePointer(op.Path)
+	return err
+}
+
+// ParsePointer splits a JSON Pointer (RFC 6901) into its unescaped
+// reference tokens. The empty pointer refers to the whole document.
+func ParsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid path %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to a decoded JSON
+// document. The target is left unchanged.
+func MergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+	if ok {
+		t = maps.Clone(t)
+	} else {
+		t = map[string]any{}
+	}
+
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = MergePatch(t[key], value)
+		}
+	}
+	return t
+}
+
+// ApplyJSONPatch applies the operations of a JSON Patch to a decoded JSON
+// document, all or nothing. The document is left unchanged.
+func ApplyJSONPatch(doc any, ops []PatchOperation) (any, error) {
+	doc = copyValue(doc)
+	for _, op := range ops {
+		var err error
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func applyOperation(doc any, op PatchOperation) (any, error) {
+	if err := op.Check(); err != nil {
+		return nil, err
+	}
+	path, _ := ParsePointer(op.Path)
+
+	var value any
+	if len(op.Value) > 0 {
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, errors.New("invalid value")
+		}
+	}
+
+	switch op.Op {
+	case PatchAdd:
+		return addValue(doc, path, value)
+	case PatchRemove:
+		return removeValue(doc, path)
+	case PatchReplace:
+		doc, err := removeValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	case PatchMove:
+		if op.Path == op.From {
+			return doc, nil
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("cannot move %s into itself", op.From)
+		}
+		from, _ := ParsePointer(op.From)
+		value, err := getValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		doc, err = removeValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	case PatchCopy:
+		from, _ := ParsePointer(op.From)
+		value, err := getValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, copyValue(value))
+	case PatchTest:
+		current, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("test failed at %s", op.Path)
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+func getValue(doc any, path []string) (any, error) {
+	for i, token := range path {
+		switch c := doc.(type) {
+		case map[string]any:
+			value, ok := c[token]
+			if !ok {
+				return nil, pathNotFound(path[:i+1])
+			}
+			doc = value
+		case []any:
+			idx, err := arrayIndex(token, len(c))
+			if err != nil {
+				return nil, pathNotFound(path[:i+1])
+			}
+			doc = c[idx]
+		default:
+			return nil, pathNotFound(path[:i+1])
+		}
+	}
+	return doc, nil
+}
+
+func addValue(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, path, func(parent any, key string) (any, error) {
+		switch c := parent.(type) {
+		case map[string]any:
+			c[key] = value
+			return c, nil
+		case []any:
+			if key == "-" {
+				return append(c, value), nil
+			}
+			idx, err := arrayIndex(key, len(c)+1)
+			if err != nil {
+				return nil, pathNotFound(path)
+			}
+			return slices.Insert(c, idx, value), nil
+		}
+		return nil, pathNotFound(path)
+	})
+}
+
+func removeValue(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, errors.New("cannot remove the whole document")
+	}
+	return updateParent(doc, path, func(parent any, key string) (any, error) {
+		switch c := parent.(type) {
+		case map[string]any:
+			if _, ok := c[key]; !ok {
+				return nil, pathNotFound(path)
+			}
+			delete(c, key)
+			return c, nil
+		case []any:
+			idx, err := arrayIndex(key, len(c))
+			if err != nil {
+				return nil, pathNotFound(path)
+			}
+			return slices.Delete(c, idx, idx+1), nil
+		}
+		return nil, pathNotFound(path)
+	})
+}
+
+// updateParent replaces the container holding the last token of path by the
+// result of fn.
+func updateParent(doc any, path []string, fn func(parent any, key string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+
+	child, err := getValue(doc, path[:1])
+	if err != nil {
+		return nil, pathNotFound(path)
+	}
+	child, err = updateParent(child, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := doc.(type) {
+	case map[string]any:
+		c[path[0]] = child
+	case []any:
+		idx, _ := arrayIndex(path[0], len(c))
+		c[idx] = child
+	}
+	return doc, nil
+}
+
+// arrayIndex parses an array index token, which must be below size.
+func arrayIndex(token string, size int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, errors.New("invalid array index")
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || idx >= size {
+		return 0, errors.New("invalid array index")
+	}
+	return idx, nil
+}
+
+func pathNotFound(path []string) error {
+	escaped := make([]string, len(path))
+	for i, token := range path {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+	}
+	return fmt.Errorf("path /%s does not exist", strings.Join(escaped, "/"))
+}
+
+// copyValue deep copies a decoded JSON value.
+func copyValue(v any) any {
+	switch c := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(c))
+		for key, value := range c {
+			m[key] = copyValue(value)
+		}
+		return m
+	case []any:
+		s := make([]any, len(c))
+		for i, value := range c {
+			s[i] = copyValue(value)
+		}
+		return s
+	}
+	return v
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decodeJSON(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestMergePatch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		target   string
+		patch    string
+		expected string
+	}{
+		{name: "Replace a value", target: `{"a":"b"}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{name: "Add a value", target: `{"a":"b"}`, patch: `{"b":"c"}`, expected: `{"a":"b","b":"c"}`},
+		{name: "Remove a value", target: `{"a":"b","b":"c"}`, patch: `{"a":null}`, expected: `{"b":"c"}`},
+		{name: "Replace an array", target: `{"a":["b"]}`, patch: `{"a":["c","d"]}`, expected: `{"a":["c","d"]}`},
+		{name: "Merge nested objects", target: `{"a":{"b":"c","d":"e"}}`, patch: `{"a":{"d":null,"f":"g"}}`, expected: `{"a":{"b":"c","f":"g"}}`},
+		{name: "Replace the document", target: `{"a":"b"}`, patch: `["c"]`, expected: `["c"]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			target := decodeJSON(t, tc.target)
+			result := MergePatch(target, decodeJSON(t, tc.patch))
+			if !reflect.DeepEqual(result, decodeJSON(t, tc.expected)) {
+				t.Errorf("Expected %s, got: %v", tc.expected, result)
+			}
+			if !reflect.DeepEqual(target, decodeJSON(t, tc.target)) {
+				t.Errorf("Target was changed: %v", target)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	testCases := []struct {
+		name          string
+		doc           string
+		patch         string
+		expected      string
+		expectedError bool
+	}{
+		{name: "Add a member", doc: `{"a":1}`, patch: `[{"op":"add","path":"/b","value":null}]`, expected: `{"a":1,"b":null}`},
+		{name: "Insert into an array", doc: `{"a":["x","z"]}`, patch: `[{"op":"add","path":"/a/1","value":"y"}]`, expected: `{"a":["x","y","z"]}`},
+		{name: "Append to an array", doc: `{"a":["x"]}`, patch: `[{"op":"add","path":"/a/-","value":{"b":1}}]`, expected: `{"a":["x",{"b":1}]}`},
+		{name: "Remove an array element", doc: `{"a":["x","y"]}`, patch: `[{"op":"remove","path":"/a/0"}]`, expected: `{"a":["y"]}`},
+		{name: "Replace a nested value", doc: `{"a":[{"b":"c"}]}`, patch: `[{"op":"replace","path":"/a/0/b","value":"d"}]`, expected: `{"a":[{"b":"d"}]}`},
+		{name: "Move a value", doc: `{"a":"x","b":"y"}`, patch: `[{"op":"move","from":"/a","path":"/b"}]`, expected: `{"b":"x"}`},
+		{name: "Copy a value", doc: `{"a":["x"]}`, patch: `[{"op":"copy","from":"/a","path":"/b"}]`, expected: `{"a":["x"],"b":["x"]}`},
+		{name: "Escaped pointer", doc: `{"a/b":1,"c~d":2}`, patch: `[{"op":"remove","path":"/a~1b"},{"op":"replace","path":"/c~0d","value":3}]`, expected: `{"c~d":3}`},
+		{name: "Passing test", doc: `{"a":[1,"x"]}`, patch: `[{"op":"test","path":"/a","value":[1,"x"]},{"op":"remove","path":"/a"}]`, expected: `{}`},
+		{name: "Failing test", doc: `{"a":"x"}`, patch: `[{"op":"remove","path":"/a"},{"op":"test","path":"/a","value":"x"}]`, expectedError: true},
+		{name: "Replace a missing member", doc: `{}`, patch: `[{"op":"replace","path":"/a","value":1}]`, expectedError: true},
+		{name: "Remove past the end", doc: `{"a":[1]}`, patch: `[{"op":"remove","path":"/a/1"}]`, expectedError: true},
+		{name: "Index with leading zero", doc: `{"a":[1,2]}`, patch: `[{"op":"remove","path":"/a/01"}]`, expectedError: true},
+		{name: "Move into itself", doc: `{"a":{}}`, patch: `[{"op":"move","from":"/a","path":"/a/b"}]`, expectedError: true},
+		{name: "Missing value", doc: `{}`, patch: `[{"op":"add","path":"/a"}]`, expectedError: true},
+		{name: "Unknown operation", doc: `{}`, patch: `[{"op":"merge","path":"/a","value":1}]`, expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ops []PatchOperation
+			if err := json.Unmarshal([]byte(tc.patch), &ops); err != nil {
+				t.Fatal(err)
+			}
+
+			doc := decodeJSON(t, tc.doc)
+			result, err := ApplyJSONPatch(doc, ops)
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("Expected error: %v, got: %v", tc.expectedError, err)
+			}
+			if err == nil && !reflect.DeepEqual(result, decodeJSON(t, tc.expected)) {
+				t.Errorf("Expected %s, got: %v", tc.expected, result)
+			}
+			if !reflect.DeepEqual(doc, decodeJSON(t, tc.doc)) {
+				t.Errorf("Document was changed: %v", doc)
+			}
+		})
+	}
+}
@@ -0,0 +1,349 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+)
+
+// maxPeriods bounds the search for the next occurrence of rules that rarely
+// match, e.g. the fifth Monday of every twelfth month.
+const maxPeriods = 1000
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum is a BYDAY entry such as MO, 2TU or -1FR. N is 0 when the rule
+// applies to every such weekday of the period.
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// RRule is the subset of an iCalendar (RFC 5545) recurrence rule supported for
+// recurring tasks: FREQ, INTERVAL, COUNT, UNTIL, BYDAY and BYMONTHDAY.
+type RRule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+}
+
+// ParseRRule parses a rule such as "FREQ=WEEKLY;BYDAY=MO,WE". A leading
+// "RRULE:" is accepted.
+func ParseRRule(s string) (RRule, error) {
+	r := RRule{Interval: 1}
+
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	if s == "" {
+		return r, errors.New("empty recurrence rule")
+	}
+
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return r, fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+		if seen[key] {
+			return r, fmt.Errorf("duplicate recurrence rule part %s", key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			switch value {
+			case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+				r.Freq = value
+			default:
+				return r, fmt.Errorf("unsupported frequency %s", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err != nil || r.Interval < 1 || r.Interval > 1000 {
+				return r, errors.New("INTERVAL must be between 1 and 1000")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err != nil || r.Count < 1 {
+				return r, errors.New("COUNT must be a positive number")
+			}
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return r, err
+			}
+			r.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				wd, err := parseWeekdayNum(day)
+				if err != nil {
+					return r, err
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				md, err := strconv.Atoi(day)
+				if err != nil || md == 0 || md < -31 || md > 31 {
+					return r, fmt.Errorf("invalid BYMONTHDAY %q", day)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, md)
+			}
+		default:
+			return r, fmt.Errorf("unsupported recurrence rule part %s", key)
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return r, errors.New("FREQ is required")
+	case r.Count > 0 && r.Until != nil:
+		return r, errors.New("COUNT and UNTIL cannot be combined")
+	case len(r.ByMonthDay) > 0 && r.Freq != FreqMonthly:
+		return r, errors.New("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	case len(r.ByDay) > 0 && r.Freq == FreqYearly:
+		return r, errors.New("BYDAY is not supported with FREQ=YEARLY")
+	}
+
+	if r.Freq != FreqMonthly {
+		for _, wd := range r.ByDay {
+			if wd.N != 0 {
+				return r, errors.New("numbered BYDAY is only supported with FREQ=MONTHLY")
+			}
+		}
+	}
+
+	return r, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	// A date only UNTIL includes the whole day
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+
+	day, ok := weekdays[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+
+	wd := WeekdayNum{Day: day}
+	if prefix := s[:len(s)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+		}
+		wd.N = n
+	}
+
+	return wd, nil
+}
+
+// String returns the canonical form of the rule.
+func (r RRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = strings.ToUpper(wd.Day.String()[:2])
+			if wd.N != 0 {
+				days[i] = strconv.Itoa(wd.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, md := range r.ByMonthDay {
+			days[i] = strconv.Itoa(md)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Advance returns the rule that applies to the occurrence after this one,
+// i.e. with one occurrence less left when COUNT is set.
+func (r RRule) Advance() RRule {
+	if r.Count > 0 {
+		r.Count--
+	}
+	return r
+}
+
+// Next returns the first occurrence strictly after prev, treating prev as an
+// occurrence of the rule. Dates are computed on the wall clock of prev's
+// location, so a task due at 09:00 stays at 09:00 across DST changes. Invalid
+// dates such as February 30 are skipped as required by RFC 5545. The second
+// result is false when the rule has no further occurrences.
+func (r RRule) Next(prev time.Time) (time.Time, bool) {
+	if r.Count == 1 {
+		return time.Time{}, false
+	}
+
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	loc := prev.Location()
+	y, m, d := prev.Date()
+	h, mi, sec := prev.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, h, mi, sec, 0, loc)
+	}
+
+	for p := 0; p < maxPeriods; p++ {
+		var candidates []time.Time
+
+		switch r.Freq {
+		case FreqDaily:
+			day := at(y, m, d+p*interval)
+			if len(r.ByDay) == 0 || r.hasWeekday(day.Weekday()) {
+				candidates = append(candidates, day)
+			}
+		case FreqWeekly:
+			// Weeks start on Monday
+			weekStart := d - (int(prev.Weekday())+6)%7 + 7*interval*p
+			if len(r.ByDay) == 0 {
+				candidates = append(candidates, at(y, m, weekStart+(int(prev.Weekday())+6)%7))
+			}
+			for _, wd := range r.ByDay {
+				candidates = append(candidates, at(y, m, weekStart+(int(wd.Day)+6)%7))
+			}
+		case FreqMonthly:
+			first := time.Date(y, m+time.Month(interval*p), 1, 0, 0, 0, 0, loc)
+			for _, day := range r.monthDays(first.Year(), first.Month(), d) {
+				candidates = append(candidates, at(first.Year(), first.Month(), day))
+			}
+		case FreqYearly:
+			year := y + interval*p
+			if d <= daysIn(year, m) {
+				candidates = append(candidates, at(year, m, d))
+			}
+		default:
+			return time.Time{}, false
+		}
+
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+		for _, c := range candidates {
+			if !c.After(prev) {
+				continue
+			}
+			if r.Until != nil && c.After(*r.Until) {
+				return time.Time{}, false
+			}
+			return c, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (r RRule) hasWeekday(day time.Weekday) bool {
+	for _, wd := range r.ByDay {
+		if wd.Day == day {
+			return true
+		}
+	}
+	return false
+}
+
+// monthDays returns the days of a month matched by a monthly rule; dtDay is
+// the day of month of the previous occurrence, used when no BY* part is set.
+func (r RRule) monthDays(year int, month time.Month, dtDay int) []int {
+	n := daysIn(year, month)
+	firstWeekday := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	lastWeekday := time.Date(year, month, n, 0, 0, 0, 0, time.UTC).Weekday()
+
+	var days []int
+	for _, md := range r.ByMonthDay {
+		day := md
+		if md < 0 {
+			day = n + md + 1
+		}
+		if day < 1 || day > n {
+			continue
+		}
+		// BYDAY limits BYMONTHDAY when both are given
+		if len(r.ByDay) > 0 && !r.hasWeekday(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday()) {
+			continue
+		}
+		days = append(days, day)
+	}
+	if len(r.ByMonthDay) > 0 {
+		return days
+	}
+
+	for _, wd := range r.ByDay {
+		firstDay := 1 + (int(wd.Day)-int(firstWeekday)+7)%7
+		lastDay := n - (int(lastWeekday)-int(wd.Day)+7)%7
+
+		switch {
+		case wd.N == 0:
+			for day := firstDay; day <= n; day += 7 {
+				days = append(days, day)
+			}
+		case wd.N > 0:
+			if day := firstDay + 7*(wd.N-1); day <= n {
+				days = append(days, day)
+			}
+		default:
+			if day := lastDay + 7*(wd.N+1); day >= 1 {
+				days = append(days, day)
+			}
+		}
+	}
+	if len(r.ByDay) > 0 {
+		return days
+	}
+
+	if dtDay <= n {
+		days = append(days, dtDay)
+	}
+	return days
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRRule(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         string
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:     "Weekly with days",
+			input:    "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			expected: "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+		},
+		{
+			name:     "Prefix and lower case",
+			input:    "RRULE:freq=monthly;interval=2;byday=-1fr",
+			expected: "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR",
+		},
+		{
+			name:     "Date only until",
+			input:    "FREQ=DAILY;UNTIL=20241231",
+			expected: "FREQ=DAILY;UNTIL=20241231T235959Z",
+		},
+		{
+			name:          "Missing frequency",
+			input:         "INTERVAL=2",
+			expectedError: true,
+		},
+		{
+			name:          "Unknown weekday",
+			input:         "FREQ=WEEKLY;BYDAY=XX",
+			expectedError: true,
+		},
+		{
+			name:          "Numbered weekday in weekly rule",
+			input:         "FREQ=WEEKLY;BYDAY=2MO",
+			expectedError: true,
+		},
+		{
+			name:          "Count and until",
+			input:         "FREQ=DAILY;COUNT=3;UNTIL=20241231",
+			expectedError: true,
+		},
+		{
+			name:          "Unsupported part",
+			input:         "FREQ=YEARLY;BYMONTH=3",
+			expectedError: true,
+		},
+		{
+			name:          "Invalid month day",
+			input:         "FREQ=MONTHLY;BYMONTHDAY=32",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := ParseRRule(tc.input)
+
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("Expected error: %v, got: %v", tc.expectedError, err)
+			}
+			if err == nil && rule.String() != tc.expected {
+				t.Errorf("Expected: %s, got: %s", tc.expected, rule.String())
+			}
+		})
+	}
+}
+
+func TestRRuleNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		rule     string
+		prev     time.Time
+		expected time.Time
+		none     bool
+	}{
+		{
+			name:     "Daily across DST start keeps wall clock",
+			rule:     "FREQ=DAILY",
+			prev:     time.Date(2024, 3, 9, 9, 0, 0, 0, newYork),
+			expected: time.Date(2024, 3, 10, 9, 0, 0, 0, newYork),
+		},
+		{
+			name:     "Weekly across DST end keeps wall clock",
+			rule:     "FREQ=WEEKLY",
+			prev:     time.Date(2024, 10, 21, 9, 0, 0, 0, kyiv),
+			expected: time.Date(2024, 10, 28, 9, 0, 0, 0, kyiv),
+		},
+		{
+			name:     "Weekly by day within the week",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			prev:     time.Date(2024, 10, 2, 18, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 10, 4, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Weekly by day into next week",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			prev:     time.Date(2024, 10, 4, 18, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 10, 7, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Every other week",
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+			prev:     time.Date(2024, 10, 2, 8, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 10, 14, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Daily on weekdays skips the weekend",
+			rule:     "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			prev:     time.Date(2024, 10, 4, 8, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 10, 7, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Monthly on the 31st skips short months",
+			rule:     "FREQ=MONTHLY",
+			prev:     time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Monthly on the last day",
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=-1",
+			prev:     time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Monthly on the last Friday",
+			rule:     "FREQ=MONTHLY;BYDAY=-1FR",
+			prev:     time.Date(2024, 10, 25, 17, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 11, 29, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Monthly on the second Tuesday",
+			rule:     "FREQ=MONTHLY;BYDAY=2TU",
+			prev:     time.Date(2024, 12, 10, 10, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 1, 14, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Quarterly on the 15th",
+			rule:     "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=15",
+			prev:     time.Date(2024, 11, 15, 10, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 2, 15, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Yearly on February 29",
+			rule:     "FREQ=YEARLY",
+			prev:     time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC),
+			expected: time.Date(2028, 2, 29, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Last occurrence by count",
+			rule: "FREQ=DAILY;COUNT=1",
+			prev: time.Date(2024, 10, 1, 10, 0, 0, 0, time.UTC),
+			none: true,
+		},
+		{
+			name: "Past until",
+			rule: "FREQ=WEEKLY;UNTIL=20241005T000000Z",
+			prev: time.Date(2024, 10, 1, 10, 0, 0, 0, time.UTC),
+			none: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := ParseRRule(tc.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			next, ok := rule.Next(tc.prev)
+
+			if ok == tc.none {
+				t.Fatalf("Expected next occurrence: %v, got: %v", !tc.none, ok)
+			}
+			if ok && !next.Equal(tc.expected) {
+				t.Errorf("Expected: %v, got: %v", tc.expected, next)
+			}
+		})
+	}
+}
+
+func TestRRuleAdvance(t *testing.T) {
+	rule, err := ParseRRule("FREQ=DAILY;COUNT=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prev := time.Date(2024, 10, 1, 10, 0, 0, 0, time.UTC)
+	if _, ok := rule.Next(prev); !ok {
+		t.Fatal("Expected a second occurrence")
+	}
+	if _, ok := rule.Advance().Next(prev.AddDate(0, 0, 1)); ok {
+		t.Error("Expected no occurrence after the last one")
+	}
+}
@@ -1,31 +1,49 @@
 package utils
 
 import (
-	"fmt"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"os"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// GenerateJWT signs claims with the default key ring.
 func GenerateJWT(claims jwt.MapClaims) (string, error) {
-	secretKey := []byte(os.Getenv("SECRET_KEY"))
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	return token.SignedString(secretKey)
+	return getDefaultKeyRing().Sign(claims)
 }
 
+// ValidateJWT verifies a token with the default key ring and returns its
+// claims.
 func ValidateJWT(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
+	return getDefaultKeyRing().Verify(tokenString)
+}
 
-		return []byte(os.Getenv("SECRET_KEY")), nil
-	})
-	if err != nil {
-		return jwt.MapClaims{}, err
+// NewOpaqueToken returns a random URL-safe token of n random bytes.
+func NewOpaqueToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	return token.Claims.(jwt.MapClaims), nil
+// HashToken returns the hex encoded SHA-256 hash under which an opaque token
+// is stored.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// EnvDuration reads a time.Duration such as "30s" from the environment,
+// falling back to def when it is unset or invalid.
+func EnvDuration(key string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
 }
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/repository"
+)
+
+// IdempotencyKeyPurger periodically deletes expired idempotency keys.
+type IdempotencyKeyPurger struct {
+	rep      repository.IdempotencyRepositoryInterface
+	interval time.Duration
+	logger   *log.Logger
+}
+
+func NewIdempotencyKeyPurger(rep repository.IdempotencyRepositoryInterface, interval time.Duration, logger *log.Logger) *IdempotencyKeyPurger {
+	return &IdempotencyKeyPurger{rep: rep, interval: interval, logger: logger}
+}
+
+// Run purges expired keys every interval until ctx is done.
+func (p *IdempotencyKeyPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge deletes the keys that have expired.
+func (p *IdempotencyKeyPurger) Purge(ctx context.Context) {
+	purged, err := p.rep.PurgeExpiredKeys(ctx, time.Now())
+	if err != nil {
+		p.logger.Print(err)
+		return
+	}
+
+	if purged > 0 {
+		p.logger.Printf("Purged %d expired idempotency keys", purged)
+	}
+}
@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Reloader is something reloaded from its files, such as a utils.KeyRing.
+type Reloader interface {
+	Reload() error
+}
+
+// KeyReloader periodically reloads the token signing keys, so that keys
+// added to or retired from the key ring file take effect without a restart.
+type KeyReloader struct {
+	keys     Reloader
+	interval time.Duration
+	logger   *log.Logger
+}
+
+func NewKeyReloader(keys Reloader, interval time.Duration, logger *log.Logger) *KeyReloader {
+	return &KeyReloader{keys: keys, interval: interval, logger: logger}
+}
+
+// Run reloads the keys every interval until ctx is done. A failed reload
+// keeps the keys loaded before.
+func (kr *KeyReloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(kr.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := kr.keys.Reload(); err != nil {
+			kr.logger.Print(err)
+		}
+	}
+}
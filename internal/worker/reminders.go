@@ -0,0 +1,103 @@
+// Package worker contains the jobs that run in the background of the API
+// server, outside of any request.
+package worker
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/NeGat1FF/todolist-api/internal/notifier"
+	"github.com/NeGat1FF/todolist-api/internal/repository"
+)
+
+// ReminderBatchSize is the number of reminders handled per poll.
+const ReminderBatchSize = 50
+
+// ReminderRetryDelay is how long a claimed reminder waits before it is tried
+// again. It must be longer than any delivery takes.
+const ReminderRetryDelay = 5 * time.Minute
+
+// ReminderWorker periodically delivers due reminders through its notifiers.
+type ReminderWorker struct {
+	rep       repository.ReminderRepositoryInterface
+	notifiers map[string]notifier.Notifier
+	interval  time.Duration
+	logger    *log.Logger
+}
+
+// NewReminderWorker creates a worker delivering every reminder through each of
+// notifiers. Their names record the deliveries, so they must stay the same
+// across restarts.
+func NewReminderWorker(rep repository.ReminderRepositoryInterface, notifiers map[string]notifier.Notifier, interval time.Duration, logger *log.Logger) *ReminderWorker {
+	return &ReminderWorker{rep: rep, notifiers: notifiers, interval: interval, logger: logger}
+}
+
+// Run polls for due reminders every interval until ctx is done.
+func (w *ReminderWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.Poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll delivers one batch of due reminders.
+func (w *ReminderWorker) Poll(ctx context.Context) {
+	due, err := w.rep.ClaimDueReminders(ctx, ReminderBatchSize, ReminderRetryDelay)
+	if err != nil {
+		w.logger.Print(err)
+		return
+	}
+
+	sent := 0
+	for _, n := range due {
+		if w.deliver(ctx, n) {
+			sent++
+		}
+	}
+
+	if sent > 0 {
+		w.logger.Printf("Sent %d reminders", sent)
+	}
+}
+
+// deliver sends n through the notifiers that have not delivered it yet, so a
+// retry does not repeat the deliveries that succeeded. It reports whether
+// every notifier has delivered n.
+func (w *ReminderWorker) deliver(ctx context.Context, n models.Notification) bool {
+	delivered := true
+	for name, nt := range w.notifiers {
+		if slices.Contains(n.Delivered, name) {
+			continue
+		}
+
+		if err := nt.Notify(ctx, n); err != nil {
+			w.logger.Printf("failed to send reminder %d through %s: %v", n.ReminderID, name, err)
+			delivered = false
+			continue
+		}
+		if err := w.rep.MarkReminderDelivered(ctx, n.ReminderID, name); err != nil {
+			w.logger.Print(err)
+			delivered = false
+		}
+	}
+
+	if !delivered {
+		return false
+	}
+	if err := w.rep.MarkReminderSent(ctx, n.ReminderID); err != nil {
+		w.logger.Print(err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/repository"
+)
+
+// TokenPurger periodically deletes expired refresh tokens and revoked access
+// tokens.
+type TokenPurger struct {
+	rep      repository.TokenRepositoryInterface
+	interval time.Duration
+	logger   *log.Logger
+}
+
+func NewTokenPurger(rep repository.TokenRepositoryInterface, interval time.Duration, logger *log.Logger) *TokenPurger {
+	return &TokenPurger{rep: rep, interval: interval, logger: logger}
+}
+
+// Run purges expired tokens every interval until ctx is done.
+func (p *TokenPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge deletes the tokens that have expired.
+func (p *TokenPurger) Purge(ctx context.Context) {
+	purged, err := p.rep.PurgeExpiredTokens(ctx, time.Now())
+	if err != nil {
+		p.logger.Print(err)
+		return
+	}
+
+	if purged > 0 {
+		p.logger.Printf("Purged %d expired tokens", purged)
+	}
+}
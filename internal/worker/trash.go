@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/repository"
+)
+
+// TrashPurger periodically deletes tasks that have been in the trash for
+// longer than the retention period, and change log entries older than the
+// change log retention.
+type TrashPurger struct {
+	rep             repository.TaskRepositoryInterface
+	retention       time.Duration
+	changeRetention time.Duration
+	interval        time.Duration
+	logger          *log.Logger
+}
+
+func NewTrashPurger(rep repository.TaskRepositoryInterface, retention, changeRetention, interval time.Duration, logger *log.Logger) *TrashPurger {
+	return &TrashPurger{rep: rep, retention: retention, changeRetention: changeRetention, interval: interval, logger: logger}
+}
+
+// Run purges the trash every interval until ctx is done.
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge deletes the tasks trashed before the retention period and the change
+// log entries made before the change log retention.
+func (p *TrashPurger) Purge(ctx context.Context) {
+	now := time.Now()
+
+	purged, err := p.rep.PurgeTrash(ctx, now.Add(-p.retention))
+	if err != nil {
+		p.logger.Print(err)
+	} else if purged > 0 {
+		p.logger.Printf("Purged %d tasks from the trash", purged)
+	}
+
+	purged, err = p.rep.PurgeChanges(ctx, now.Add(-p.changeRetention))
+	if err != nil {
+		p.logger.Print(err)
+	} else if purged > 0 {
+		p.logger.Printf("Purged %d change log entries", purged)
+	}
+}
@@ -0,0 +1,126 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/NeGat1FF/todolist-api/internal/models"
+	"github.com/NeGat1FF/todolist-api/internal/notifier"
+	"github.com/NeGat1FF/todolist-api/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+type notifierFunc func(ctx context.Context, n models.Notification) error
+
+func (f notifierFunc) Notify(ctx context.Context, n models.Notification) error {
+	return f(ctx, n)
+}
+
+func TestReminderWorkerPoll(t *testing.T) {
+	due := []models.Notification{{ReminderID: 1}, {ReminderID: 2}, {ReminderID: 3, Delivered: []string{"webhook"}}}
+
+	ReminderRepoMock := mocks.NewReminderRepositoryInterface(t)
+	ReminderRepoMock.On("ClaimDueReminders", mock.Anything, ReminderBatchSize, ReminderRetryDelay).Return(due, nil)
+	ReminderRepoMock.On("MarkReminderDelivered", mock.Anything, 1, "webhook").Return(nil).Once()
+	ReminderRepoMock.On("MarkReminderDelivered", mock.Anything, 1, "email").Return(nil).Once()
+	ReminderRepoMock.On("MarkReminderDelivered", mock.Anything, 2, "email").Return(nil).Once()
+	ReminderRepoMock.On("MarkReminderDelivered", mock.Anything, 3, "email").Return(nil).Once()
+	ReminderRepoMock.On("MarkReminderSent", mock.Anything, 1).Return(nil).Once()
+	ReminderRepoMock.On("MarkReminderSent", mock.Anything, 3).Return(nil).Once()
+
+	notified := map[string][]int{}
+	notifyAs := func(name string) notifierFunc {
+		return func(ctx context.Context, n models.Notification) error {
+			notified[name] = append(notified[name], n.ReminderID)
+			if name == "webhook" && n.ReminderID == 2 {
+				return errors.New("unreachable")
+			}
+			return nil
+		}
+	}
+	notifiers := map[string]notifier.Notifier{"webhook": notifyAs("webhook"), "email": notifyAs("email")}
+
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+	NewReminderWorker(ReminderRepoMock, notifiers, 0, logger).Poll(context.TODO())
+
+	// Reminder 3 was already delivered through the webhook on an earlier try
+	if len(notified["webhook"]) != 2 || len(notified["email"]) != 3 {
+		t.Errorf("Expected 2 webhook and 3 email notifications, got: %v", notified)
+	}
+}
+
+func TestTrashPurgerPurge(t *testing.T) {
+	TaskRepoMock := mocks.NewTaskRepositoryInterface(t)
+
+	retention := 30 * 24 * time.Hour
+	now := time.Now()
+	TaskRepoMock.On("PurgeTrash", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		cutoff := now.Add(-retention)
+		return !before.Before(cutoff) && before.Sub(cutoff) < time.Minute
+	})).Return(3, nil)
+	changeRetention := 90 * 24 * time.Hour
+	TaskRepoMock.On("PurgeChanges", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		cutoff := now.Add(-changeRetention)
+		return !before.Before(cutoff) && before.Sub(cutoff) < time.Minute
+	})).Return(20, nil)
+
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+	NewTrashPurger(TaskRepoMock, retention, changeRetention, time.Hour, logger).Purge(context.TODO())
+}
+
+func TestIdempotencyKeyPurgerPurge(t *testing.T) {
+	IdempotencyRepoMock := mocks.NewIdempotencyRepositoryInterface(t)
+
+	now := time.Now()
+	IdempotencyRepoMock.On("PurgeExpiredKeys", mock.Anything, mock.MatchedBy(func(at time.Time) bool {
+		return !at.Before(now) && at.Sub(now) < time.Minute
+	})).Return(2, nil)
+
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+	NewIdempotencyKeyPurger(IdempotencyRepoMock, time.Hour, logger).Purge(context.TODO())
+}
+
+func TestTokenPurgerPurge(t *testing.T) {
+	TokenRepoMock := mocks.NewTokenRepositoryInterface(t)
+
+	now := time.Now()
+	TokenRepoMock.On("PurgeExpiredTokens", mock.Anything, mock.MatchedBy(func(at time.Time) bool {
+		return !at.Before(now) && at.Sub(now) < time.Minute
+	})).Return(4, nil)
+
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+	NewTokenPurger(TokenRepoMock, time.Hour, logger).Purge(context.TODO())
+}
+
+type reloaderFunc func() error
+
+func (f reloaderFunc) Reload() error {
+	return f()
+}
+
+func TestKeyReloaderRun(t *testing.T) {
+	reloaded := make(chan struct{}, 1)
+	keys := reloaderFunc(func() error {
+		select {
+		case reloaded <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := log.New(os.Stdout, "test: ", log.LstdFlags)
+	go NewKeyReloader(keys, time.Millisecond, logger).Run(ctx)
+
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Error("Expected the keys to be reloaded")
+	}
+}
@@ -7,6 +7,8 @@ import (
 
 	models "github.com/NeGat1FF/todolist-api/internal/models"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // TaskRepositoryInterface is an autogenerated mock type for the TaskRepositoryInterface type
@@ -42,17 +44,73 @@ func (_m *TaskRepositoryInterface) AddTask(ctx context.Context, task models.Task
 	return r0, r1
 }
 
-// DeleteTask provides a mock function with given fields: ctx, task_id, user_id
-func (_m *TaskRepositoryInterface) DeleteTask(ctx context.Context, task_id int, user_id int) error {
+// ArchiveCompleted provides a mock function with given fields: ctx, user_id, list_id, inbox
+func (_m *TaskRepositoryInterface) ArchiveCompleted(ctx context.Context, user_id int, list_id *int, inbox bool) (int, error) {
+	ret := _m.Called(ctx, user_id, list_id, inbox)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ArchiveCompleted")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, *int, bool) (int, error)); ok {
+		return rf(ctx, user_id, list_id, inbox)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, *int, bool) int); ok {
+		r0 = rf(ctx, user_id, list_id, inbox)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, *int, bool) error); ok {
+		r1 = rf(ctx, user_id, list_id, inbox)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ArchiveTask provides a mock function with given fields: ctx, task_id, user_id
+func (_m *TaskRepositoryInterface) ArchiveTask(ctx context.Context, task_id int, user_id int) (models.Task, error) {
 	ret := _m.Called(ctx, task_id, user_id)
 
+	if len(ret) == 0 {
+		panic("no return value specified for ArchiveTask")
+	}
+
+	var r0 models.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (models.Task, error)); ok {
+		return rf(ctx, task_id, user_id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) models.Task); ok {
+		r0 = rf(ctx, task_id, user_id)
+	} else {
+		r0 = ret.Get(0).(models.Task)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, task_id, user_id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteTask provides a mock function with given fields: ctx, task_id, user_id, version
+func (_m *TaskRepositoryInterface) DeleteTask(ctx context.Context, task_id int, user_id int, version int64) error {
+	ret := _m.Called(ctx, task_id, user_id, version)
+
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteTask")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
-		r0 = rf(ctx, task_id, user_id)
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int64) error); ok {
+		r0 = rf(ctx, task_id, user_id, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -60,6 +118,233 @@ func (_m *TaskRepositoryInterface) DeleteTask(ctx context.Context, task_id int,
 	return r0
 }
 
+// EmptyTrash provides a mock function with given fields: ctx, user_id
+func (_m *TaskRepositoryInterface) EmptyTrash(ctx context.Context, user_id int) (int, error) {
+	ret := _m.Called(ctx, user_id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EmptyTrash")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (int, error)); ok {
+		return rf(ctx, user_id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(ctx, user_id)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, user_id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAncestorIDs provides a mock function with given fields: ctx, task_id
+func (_m *TaskRepositoryInterface) GetAncestorIDs(ctx context.Context, task_id int) ([]int, error) {
+	ret := _m.Called(ctx, task_id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAncestorIDs")
+	}
+
+	var r0 []int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]int, error)); ok {
+		return rf(ctx, task_id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []int); ok {
+		r0 = rf(ctx, task_id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, task_id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetChangeLogHorizon provides a mock function with given fields: ctx
+func (_m *TaskRepositoryInterface) GetChangeLogHorizon(ctx context.Context) (uint64, time.Time, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetChangeLogHorizon")
+	}
+
+	var r0 uint64
+	var r1 time.Time
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context) (uint64, time.Time, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) uint64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) time.Time); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Get(1).(time.Time)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context) error); ok {
+		r2 = rf(ctx)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetChangesSince provides a mock function with given fields: ctx, user_id, since
+func (_m *TaskRepositoryInterface) GetChangesSince(ctx context.Context, user_id int, since uint64) ([]models.Task, []int, uint64, error) {
+	ret := _m.Called(ctx, user_id, since)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetChangesSince")
+	}
+
+	var r0 []models.Task
+	var r1 []int
+	var r2 uint64
+	var r3 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, uint64) ([]models.Task, []int, uint64, error)); ok {
+		return rf(ctx, user_id, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, uint64) []models.Task); ok {
+		r0 = rf(ctx, user_id, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, uint64) []int); ok {
+		r1 = rf(ctx, user_id, since)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]int)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int, uint64) uint64); ok {
+		r2 = rf(ctx, user_id, since)
+	} else {
+		r2 = ret.Get(2).(uint64)
+	}
+
+	if rf, ok := ret.Get(3).(func(context.Context, int, uint64) error); ok {
+		r3 = rf(ctx, user_id, since)
+	} else {
+		r3 = ret.Error(3)
+	}
+
+	return r0, r1, r2, r3
+}
+
+// GetFieldChanges provides a mock function with given fields: ctx, task_id, since_version
+func (_m *TaskRepositoryInterface) GetFieldChanges(ctx context.Context, task_id int, since_version int64) (map[string]time.Time, error) {
+	ret := _m.Called(ctx, task_id, since_version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFieldChanges")
+	}
+
+	var r0 map[string]time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int64) (map[string]time.Time, error)); ok {
+		return rf(ctx, task_id, since_version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int64) map[string]time.Time); ok {
+		r0 = rf(ctx, task_id, since_version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]time.Time)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int64) error); ok {
+		r1 = rf(ctx, task_id, since_version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPurgedTaskIDs provides a mock function with given fields: ctx, user_id, since
+func (_m *TaskRepositoryInterface) GetPurgedTaskIDs(ctx context.Context, user_id int, since time.Time) ([]int, error) {
+	ret := _m.Called(ctx, user_id, since)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPurgedTaskIDs")
+	}
+
+	var r0 []int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) ([]int, error)); ok {
+		return rf(ctx, user_id, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) []int); ok {
+		r0 = rf(ctx, user_id, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, time.Time) error); ok {
+		r1 = rf(ctx, user_id, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubtreeDepth provides a mock function with given fields: ctx, task_id
+func (_m *TaskRepositoryInterface) GetSubtreeDepth(ctx context.Context, task_id int) (int, error) {
+	ret := _m.Called(ctx, task_id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSubtreeDepth")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (int, error)); ok {
+		return rf(ctx, task_id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(ctx, task_id)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, task_id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTaskByID provides a mock function with given fields: ctx, task_id
 func (_m *TaskRepositoryInterface) GetTaskByID(ctx context.Context, task_id int) (models.Task, error) {
 	ret := _m.Called(ctx, task_id)
@@ -88,17 +373,85 @@ func (_m *TaskRepositoryInterface) GetTaskByID(ctx context.Context, task_id int)
 	return r0, r1
 }
 
-// GetTasks provides a mock function with given fields: ctx, user_id, page, limit
-func (_m *TaskRepositoryInterface) GetTasks(ctx context.Context, user_id int, page int, limit int) ([]models.Task, error) {
-	ret := _m.Called(ctx, user_id, page, limit)
+// GetTaskDescendants provides a mock function with given fields: ctx, user_id, root_ids, archived
+func (_m *TaskRepositoryInterface) GetTaskDescendants(ctx context.Context, user_id int, root_ids []int, archived string) ([]models.Task, error) {
+	ret := _m.Called(ctx, user_id, root_ids, archived)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetTasks")
+		panic("no return value specified for GetTaskDescendants")
 	}
 
 	var r0 []models.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) ([]models.Task, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int, []int, string) ([]models.Task, error)); ok {
+		return rf(ctx, user_id, root_ids, archived)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, []int, string) []models.Task); ok {
+		r0 = rf(ctx, user_id, root_ids, archived)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, []int, string) error); ok {
+		r1 = rf(ctx, user_id, root_ids, archived)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTasks provides a mock function with given fields: ctx, user_id, filter, page, limit
+func (_m *TaskRepositoryInterface) GetTasks(ctx context.Context, user_id int, filter models.TaskFilter, page int, limit int) ([]models.Task, int, error) {
+	ret := _m.Called(ctx, user_id, filter, page, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTasks")
+	}
+
+	var r0 []models.Task
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TaskFilter, int, int) ([]models.Task, int, error)); ok {
+		return rf(ctx, user_id, filter, page, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TaskFilter, int, int) []models.Task); ok {
+		r0 = rf(ctx, user_id, filter, page, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.TaskFilter, int, int) int); ok {
+		r1 = rf(ctx, user_id, filter, page, limit)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int, models.TaskFilter, int, int) error); ok {
+		r2 = rf(ctx, user_id, filter, page, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetTrash provides a mock function with given fields: ctx, user_id, page, limit
+func (_m *TaskRepositoryInterface) GetTrash(ctx context.Context, user_id int, page int, limit int) ([]models.Task, int, error) {
+	ret := _m.Called(ctx, user_id, page, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTrash")
+	}
+
+	var r0 []models.Task
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) ([]models.Task, int, error)); ok {
 		return rf(ctx, user_id, page, limit)
 	}
 	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) []models.Task); ok {
@@ -109,8 +462,144 @@ func (_m *TaskRepositoryInterface) GetTasks(ctx context.Context, user_id int, pa
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) int); ok {
 		r1 = rf(ctx, user_id, page, limit)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int, int, int) error); ok {
+		r2 = rf(ctx, user_id, page, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// LockTask provides a mock function with given fields: ctx, task_id
+func (_m *TaskRepositoryInterface) LockTask(ctx context.Context, task_id int) (models.Task, error) {
+	ret := _m.Called(ctx, task_id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockTask")
+	}
+
+	var r0 models.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (models.Task, error)); ok {
+		return rf(ctx, task_id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) models.Task); ok {
+		r0 = rf(ctx, task_id)
+	} else {
+		r0 = ret.Get(0).(models.Task)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, task_id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MoveTask provides a mock function with given fields: ctx, task_id, user_id, list_id
+func (_m *TaskRepositoryInterface) MoveTask(ctx context.Context, task_id int, user_id int, list_id *int) (models.Task, error) {
+	ret := _m.Called(ctx, task_id, user_id, list_id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MoveTask")
+	}
+
+	var r0 models.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, *int) (models.Task, error)); ok {
+		return rf(ctx, task_id, user_id, list_id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, *int) models.Task); ok {
+		r0 = rf(ctx, task_id, user_id, list_id)
+	} else {
+		r0 = ret.Get(0).(models.Task)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, *int) error); ok {
+		r1 = rf(ctx, task_id, user_id, list_id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeChanges provides a mock function with given fields: ctx, before
+func (_m *TaskRepositoryInterface) PurgeChanges(ctx context.Context, before time.Time) (int, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeChanges")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeTask provides a mock function with given fields: ctx, task_id, user_id
+func (_m *TaskRepositoryInterface) PurgeTask(ctx context.Context, task_id int, user_id int) error {
+	ret := _m.Called(ctx, task_id, user_id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeTask")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, task_id, user_id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PurgeTrash provides a mock function with given fields: ctx, before
+func (_m *TaskRepositoryInterface) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeTrash")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -118,9 +607,148 @@ func (_m *TaskRepositoryInterface) GetTasks(ctx context.Context, user_id int, pa
 	return r0, r1
 }
 
-// UpdateTask provides a mock function with given fields: ctx, task, task_id, user_id
-func (_m *TaskRepositoryInterface) UpdateTask(ctx context.Context, task models.Task, task_id int, user_id int) (models.Task, error) {
-	ret := _m.Called(ctx, task, task_id, user_id)
+// RestoreTask provides a mock function with given fields: ctx, task_id, user_id
+func (_m *TaskRepositoryInterface) RestoreTask(ctx context.Context, task_id int, user_id int) (models.Task, error) {
+	ret := _m.Called(ctx, task_id, user_id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreTask")
+	}
+
+	var r0 models.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (models.Task, error)); ok {
+		return rf(ctx, task_id, user_id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) models.Task); ok {
+		r0 = rf(ctx, task_id, user_id)
+	} else {
+		r0 = ret.Get(0).(models.Task)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, task_id, user_id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RunInTx provides a mock function with given fields: ctx, fn
+func (_m *TaskRepositoryInterface) RunInTx(ctx context.Context, fn func(context.Context) error) error {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RunInTx")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchTasks provides a mock function with given fields: ctx, user_id, query, archived, page, limit
+func (_m *TaskRepositoryInterface) SearchTasks(ctx context.Context, user_id int, query string, archived string, page int, limit int) ([]models.TaskSearchResult, int, error) {
+	ret := _m.Called(ctx, user_id, query, archived, page, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchTasks")
+	}
+
+	var r0 []models.TaskSearchResult
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string, int, int) ([]models.TaskSearchResult, int, error)); ok {
+		return rf(ctx, user_id, query, archived, page, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string, int, int) []models.TaskSearchResult); ok {
+		r0 = rf(ctx, user_id, query, archived, page, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TaskSearchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, string, int, int) int); ok {
+		r1 = rf(ctx, user_id, query, archived, page, limit)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int, string, string, int, int) error); ok {
+		r2 = rf(ctx, user_id, query, archived, page, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SetTaskStatus provides a mock function with given fields: ctx, task_id, user_id, status
+func (_m *TaskRepositoryInterface) SetTaskStatus(ctx context.Context, task_id int, user_id int, status string) (models.Task, error) {
+	ret := _m.Called(ctx, task_id, user_id, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetTaskStatus")
+	}
+
+	var r0 models.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, string) (models.Task, error)); ok {
+		return rf(ctx, task_id, user_id, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, string) models.Task); ok {
+		r0 = rf(ctx, task_id, user_id, status)
+	} else {
+		r0 = ret.Get(0).(models.Task)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, string) error); ok {
+		r1 = rf(ctx, task_id, user_id, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnarchiveTask provides a mock function with given fields: ctx, task_id, user_id
+func (_m *TaskRepositoryInterface) UnarchiveTask(ctx context.Context, task_id int, user_id int) (models.Task, error) {
+	ret := _m.Called(ctx, task_id, user_id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnarchiveTask")
+	}
+
+	var r0 models.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (models.Task, error)); ok {
+		return rf(ctx, task_id, user_id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) models.Task); ok {
+		r0 = rf(ctx, task_id, user_id)
+	} else {
+		r0 = ret.Get(0).(models.Task)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, task_id, user_id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateTask provides a mock function with given fields: ctx, task, task_id, user_id, version
+func (_m *TaskRepositoryInterface) UpdateTask(ctx context.Context, task models.Task, task_id int, user_id int, version int64) (models.Task, error) {
+	ret := _m.Called(ctx, task, task_id, user_id, version)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateTask")
@@ -128,17 +756,45 @@ func (_m *TaskRepositoryInterface) UpdateTask(ctx context.Context, task models.T
 
 	var r0 models.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, models.Task, int, int) (models.Task, error)); ok {
-		return rf(ctx, task, task_id, user_id)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Task, int, int, int64) (models.Task, error)); ok {
+		return rf(ctx, task, task_id, user_id, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Task, int, int, int64) models.Task); ok {
+		r0 = rf(ctx, task, task_id, user_id, version)
+	} else {
+		r0 = ret.Get(0).(models.Task)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.Task, int, int, int64) error); ok {
+		r1 = rf(ctx, task, task_id, user_id, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateTaskFields provides a mock function with given fields: ctx, task, fields, task_id, user_id, version
+func (_m *TaskRepositoryInterface) UpdateTaskFields(ctx context.Context, task models.Task, fields []string, task_id int, user_id int, version int64) (models.Task, error) {
+	ret := _m.Called(ctx, task, fields, task_id, user_id, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateTaskFields")
+	}
+
+	var r0 models.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Task, []string, int, int, int64) (models.Task, error)); ok {
+		return rf(ctx, task, fields, task_id, user_id, version)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, models.Task, int, int) models.Task); ok {
-		r0 = rf(ctx, task, task_id, user_id)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Task, []string, int, int, int64) models.Task); ok {
+		r0 = rf(ctx, task, fields, task_id, user_id, version)
 	} else {
 		r0 = ret.Get(0).(models.Task)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, models.Task, int, int) error); ok {
-		r1 = rf(ctx, task, task_id, user_id)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Task, []string, int, int, int64) error); ok {
+		r1 = rf(ctx, task, fields, task_id, user_id, version)
 	} else {
 		r1 = ret.Error(1)
 	}
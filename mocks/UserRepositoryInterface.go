@@ -42,6 +42,34 @@ func (_m *UserRepositoryInterface) AddUser(ctx context.Context, user models.User
 	return r0, r1
 }
 
+// GetTokenVersion provides a mock function with given fields: ctx, user_id
+func (_m *UserRepositoryInterface) GetTokenVersion(ctx context.Context, user_id int) (int, error) {
+	ret := _m.Called(ctx, user_id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTokenVersion")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (int, error)); ok {
+		return rf(ctx, user_id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(ctx, user_id)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, user_id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserByEmail provides a mock function with given fields: ctx, email
 func (_m *UserRepositoryInterface) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
 	ret := _m.Called(ctx, email)
@@ -70,6 +98,80 @@ func (_m *UserRepositoryInterface) GetUserByEmail(ctx context.Context, email str
 	return r0, r1
 }
 
+// GetUserByID provides a mock function with given fields: ctx, user_id
+func (_m *UserRepositoryInterface) GetUserByID(ctx context.Context, user_id int) (models.User, error) {
+	ret := _m.Called(ctx, user_id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByID")
+	}
+
+	var r0 models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (models.User, error)); ok {
+		return rf(ctx, user_id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) models.User); ok {
+		r0 = rf(ctx, user_id)
+	} else {
+		r0 = ret.Get(0).(models.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, user_id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IncrementTokenVersion provides a mock function with given fields: ctx, user_id
+func (_m *UserRepositoryInterface) IncrementTokenVersion(ctx context.Context, user_id int) (int, error) {
+	ret := _m.Called(ctx, user_id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrementTokenVersion")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (int, error)); ok {
+		return rf(ctx, user_id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(ctx, user_id)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, user_id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateTimeZone provides a mock function with given fields: ctx, user_id, timeZone
+func (_m *UserRepositoryInterface) UpdateTimeZone(ctx context.Context, user_id int, timeZone string) error {
+	ret := _m.Called(ctx, user_id, timeZone)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateTimeZone")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, user_id, timeZone)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewUserRepositoryInterface creates a new instance of UserRepositoryInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUserRepositoryInterface(t interface {